@@ -1,6 +1,6 @@
 -- name: CreateSource :one
-INSERT INTO sources (name, source_type, path, content, hash, enabled)
-VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO sources (name, source_type, path, content, hash, enabled, options, pending)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetSource :one
@@ -15,7 +15,7 @@ LIMIT 1;
 
 -- name: GetSourceByHash :one
 SELECT * FROM sources
-WHERE hash = ?
+WHERE hash = ? AND pending = 0
 LIMIT 1;
 
 -- name: ListSources :many
@@ -25,12 +25,13 @@ ORDER BY created_at DESC;
 -- name: ListEnabledSources :many
 SELECT * FROM sources
 WHERE enabled = 1
-ORDER BY created_at ASC;
+ORDER BY priority DESC, created_at ASC;
 
 -- name: UpdateSourceContent :exec
 UPDATE sources
 SET content = ?,
     hash = ?,
+    pending = 0,
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
 
@@ -40,6 +41,32 @@ SET enabled = ?,
     updated_at = strftime('%s', 'now')
 WHERE name = ?;
 
+-- name: UpdateSourceName :exec
+UPDATE sources
+SET name = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?;
+
+-- name: UpdateSourceOptions :exec
+UPDATE sources
+SET options = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?;
+
+-- name: UpdateSourcePath :exec
+UPDATE sources
+SET path = ?,
+    source_type = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?;
+
+-- name: UpdateSourceSettings :exec
+UPDATE sources
+SET priority = ?,
+    refresh_policy = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?;
+
 -- name: DeleteSource :exec
 DELETE FROM sources
 WHERE name = ?;
@@ -80,6 +107,18 @@ ORDER BY created_at DESC;
 DELETE FROM presets
 WHERE id = ?;
 
+-- name: RenamePreset :exec
+UPDATE presets
+SET name = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?;
+
+-- name: ApplyPreset :execrows
+UPDATE sources
+SET enabled = (id IN (SELECT source_id FROM preset_sources WHERE preset_id = ?1)),
+    updated_at = strftime('%s', 'now')
+WHERE enabled != (id IN (SELECT source_id FROM preset_sources WHERE preset_id = ?1));
+
 -- name: AddSourceToPreset :exec
 INSERT INTO preset_sources (preset_id, source_id)
 VALUES (?, ?);
@@ -88,24 +127,118 @@ VALUES (?, ?);
 DELETE FROM preset_sources
 WHERE preset_id = ? AND source_id = ?;
 
+-- name: ClearPresetSources :exec
+DELETE FROM preset_sources
+WHERE preset_id = ?;
+
 -- name: GetPresetSources :many
 SELECT s.* FROM sources s
 INNER JOIN preset_sources ps ON s.id = ps.source_id
 WHERE ps.preset_id = ?
-ORDER BY s.created_at ASC;
+ORDER BY s.priority DESC, s.created_at ASC;
 
 -- History
 
 -- name: CreateHistory :one
-INSERT INTO history (preset_name, output_path, source_count)
-VALUES (?, ?, ?)
+INSERT INTO history (preset_name, output_path, source_count, format, content_hash, generated_at)
+VALUES (?, ?, ?, ?, ?, ?)
 RETURNING *;
 
+-- name: GetHistory :one
+SELECT * FROM history
+WHERE id = ?;
+
 -- name: ListHistory :many
 SELECT * FROM history
-ORDER BY generated_at DESC
+ORDER BY generated_at DESC, id DESC
 LIMIT ?;
 
--- name: DeleteOldHistory :exec
+-- name: DeleteOldHistory :execrows
 DELETE FROM history
 WHERE generated_at < strftime('%s', 'now') - ?;
+
+-- name: AddHistorySource :exec
+INSERT INTO history_sources (history_id, position, source_id, name, source_type, path, hash)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListHistorySources :many
+SELECT * FROM history_sources
+WHERE history_id = ?
+ORDER BY position ASC;
+
+-- name: ListLatestIncludedHashes :many
+SELECT hs.source_id, hs.hash
+FROM history_sources hs
+JOIN (
+    SELECT source_id, MAX(history_id) AS history_id
+    FROM history_sources
+    GROUP BY source_id
+) latest ON latest.source_id = hs.source_id AND latest.history_id = hs.history_id;
+
+-- name: DeleteOldHistorySources :exec
+DELETE FROM history_sources
+WHERE history_id IN (
+    SELECT id FROM history
+    WHERE generated_at < strftime('%s', 'now') - ?
+);
+
+-- name: CreateContentVersion :exec
+INSERT OR IGNORE INTO content_versions (hash, content)
+VALUES (?, ?);
+
+-- name: GetContentVersion :one
+SELECT content FROM content_versions
+WHERE hash = ?;
+
+-- name: DeleteUnusedContentVersions :exec
+DELETE FROM content_versions
+WHERE hash NOT IN (SELECT hash FROM history_sources);
+
+-- Maintenance
+
+-- name: GetMaintenanceRun :one
+SELECT last_run_at FROM maintenance
+WHERE task = ?;
+
+-- name: SetMaintenanceRun :exec
+INSERT INTO maintenance (task, last_run_at)
+VALUES (?, ?)
+ON CONFLICT (task) DO UPDATE SET last_run_at = excluded.last_run_at;
+
+-- Import failures
+
+-- name: UpsertImportFailure :exec
+INSERT INTO import_failures (url, title, folder, error)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (url) DO UPDATE SET
+    title = excluded.title,
+    folder = excluded.folder,
+    error = excluded.error,
+    failed_at = strftime('%s', 'now');
+
+-- name: ListImportFailures :many
+SELECT * FROM import_failures
+ORDER BY id ASC;
+
+-- name: DeleteImportFailure :exec
+DELETE FROM import_failures
+WHERE url = ?;
+
+-- Source tags
+
+-- name: AddSourceTag :exec
+INSERT OR IGNORE INTO source_tags (source_id, tag)
+VALUES (?, ?);
+
+-- name: ListSourceTags :many
+SELECT tag FROM source_tags
+WHERE source_id = ?
+ORDER BY tag ASC;
+
+-- name: ListAllSourceTags :many
+SELECT * FROM source_tags
+ORDER BY source_id ASC, tag ASC;
+
+-- name: DeleteSourceTags :exec
+DELETE FROM source_tags
+WHERE source_id = ?;
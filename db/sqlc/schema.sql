@@ -4,13 +4,21 @@
 CREATE TABLE IF NOT EXISTS sources (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL UNIQUE,
-    source_type TEXT NOT NULL CHECK(source_type IN ('file', 'url', 'bookmark')),
+    source_type TEXT NOT NULL CHECK(source_type IN ('file', 'url', 'bookmark', 'pdf', 'notebook', 'openapi')),
     path TEXT NOT NULL,
     content TEXT NOT NULL,
     hash TEXT NOT NULL,
     enabled INTEGER NOT NULL DEFAULT 1 CHECK(enabled IN (0, 1)),
     created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
-    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    -- per-source parsing options as JSON, e.g. whether to include notebook outputs
+    options TEXT NOT NULL DEFAULT '{}',
+    -- 1 for placeholders whose content is fetched when first enabled or generated
+    pending INTEGER NOT NULL DEFAULT 0 CHECK(pending IN (0, 1)),
+    -- higher priorities come first in generated output and the token budget
+    priority INTEGER NOT NULL DEFAULT 0,
+    -- 'auto' re-reads the source on every generation, 'manual' only on refresh
+    refresh_policy TEXT NOT NULL DEFAULT 'auto' CHECK(refresh_policy IN ('auto', 'manual'))
 );
 
 -- Create index on enabled for fast filtering
@@ -46,8 +54,59 @@ CREATE TABLE IF NOT EXISTS history (
     preset_name TEXT,
     output_path TEXT NOT NULL,
     source_count INTEGER NOT NULL,
-    generated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+    generated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    -- output format, used to replay the generation
+    format TEXT NOT NULL DEFAULT '',
+    -- hash of the generated content, empty for entries recorded before snapshots
+    content_hash TEXT NOT NULL DEFAULT ''
 );
 
 -- Create index on generated_at for sorting
 CREATE INDEX IF NOT EXISTS idx_history_generated_at ON history(generated_at DESC);
+
+-- history_sources: the sources each generation included, in output order
+CREATE TABLE IF NOT EXISTS history_sources (
+    history_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    source_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    source_type TEXT NOT NULL,
+    path TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    PRIMARY KEY (history_id, position),
+    FOREIGN KEY (history_id) REFERENCES history(id) ON DELETE CASCADE,
+    FOREIGN KEY (hash) REFERENCES content_versions(hash)
+);
+
+-- Create index on hash for pruning unused content versions
+CREATE INDEX IF NOT EXISTS idx_history_sources_hash ON history_sources(hash);
+
+-- content_versions: source content as it was included in past generations, by hash
+CREATE TABLE IF NOT EXISTS content_versions (
+    hash TEXT PRIMARY KEY,
+    content TEXT NOT NULL
+);
+
+-- import_failures table: bookmarks that could not be fetched, retried by import-bookmarks --resume
+CREATE TABLE IF NOT EXISTS import_failures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    folder TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL,
+    failed_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+);
+
+-- source_tags: free-form labels for grouping and filtering sources
+CREATE TABLE IF NOT EXISTS source_tags (
+    source_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (source_id, tag),
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+-- maintenance: when periodic housekeeping, like pruning history, last ran
+CREATE TABLE IF NOT EXISTS maintenance (
+    task TEXT PRIMARY KEY,
+    last_run_at INTEGER NOT NULL
+);
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,6 +16,22 @@ type Config struct {
 	MaxFileSize    int64  `yaml:"max_file_size"`
 	ExcludePattern string `yaml:"exclude_pattern"`
 	LogLevel       string `yaml:"log_level"`
+	// HistoryRetention is how long generation history is kept; 0 keeps it forever
+	HistoryRetention time.Duration `yaml:"history_retention"`
+	// HistoryPruneInterval is how often history older than HistoryRetention is pruned
+	HistoryPruneInterval time.Duration `yaml:"history_prune_interval"`
+	// TUI holds the interactive UI's theme and key bindings
+	TUI TUIConfig `yaml:"tui"`
+}
+
+// TUIConfig customizes the interactive UI
+type TUIConfig struct {
+	// Theme is a built in theme: dark, light or high-contrast
+	Theme string `yaml:"theme"`
+	// Colors overrides theme colors by name, e.g. accent: "#5f87ff"
+	Colors map[string]string `yaml:"colors,omitempty"`
+	// Keys rebinds actions, e.g. delete: ["x", "delete"]
+	Keys map[string][]string `yaml:"keys,omitempty"`
 }
 
 // DefaultConfig returns default configuration
@@ -23,11 +40,14 @@ func DefaultConfig() *Config {
 	cacheDir := filepath.Join(home, ".context-vacuum")
 
 	return &Config{
-		CacheDir:       cacheDir,
-		OutputDir:      filepath.Join(cacheDir, "output"),
-		MaxFileSize:    10 * 1024 * 1024, // 10MB
-		ExcludePattern: "*.test.ts,*.spec.ts,node_modules/*",
-		LogLevel:       "warn",
+		CacheDir:             cacheDir,
+		OutputDir:            filepath.Join(cacheDir, "output"),
+		MaxFileSize:          10 * 1024 * 1024, // 10MB
+		ExcludePattern:       "*.test.ts,*.spec.ts,node_modules/*",
+		LogLevel:             "warn",
+		HistoryRetention:     30 * 24 * time.Hour,
+		HistoryPruneInterval: 24 * time.Hour,
+		TUI:                  TUIConfig{Theme: "dark"},
 	}
 }
 
@@ -48,12 +68,13 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	// Settings missing from older config files keep their defaults
+	cfg := DefaultConfig()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
 
 // Save saves configuration to file
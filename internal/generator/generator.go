@@ -2,7 +2,6 @@ package generator
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log/slog"
 	"os"
@@ -11,6 +10,7 @@ import (
 	"time"
 
 	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/redact"
 	"github.com/brojonat/context-vacuum/internal/storage"
 	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
 )
@@ -35,111 +35,154 @@ func NewGenerator(store *storage.Store, parser *parser.Parser, logger *slog.Logg
 type GenerateOptions struct {
 	OutputPath string
 	Format     string // "claude", "cursor", or custom
-	PresetName string
+	PresetName string // generate from this preset's sources instead of the enabled ones
+	// TokenBudget caps the estimated tokens of included source content; 0 means no limit
+	TokenBudget int
+	// Redact controls secret redaction; empty means redact.ModeMask
+	Redact redact.Mode
+}
+
+// Result is generated context along with the sources that went into it
+type Result struct {
+	Content string
+	// Sources are the sources included in Content, in order
+	Sources []dbgen.Source
+	// Omitted are the sources left out to stay within the token budget
+	Omitted []dbgen.Source
+	// Tokens is the estimated token count of Content
+	Tokens int
+	// Redactions are the secrets masked in Content
+	Redactions []Redaction
+	// GeneratedAt is when Content was rendered
+	GeneratedAt time.Time
 }
 
 // GenerateToString creates context content and returns it as a string
 func (g *Generator) GenerateToString(ctx context.Context, opts GenerateOptions) (string, error) {
-	// Query DB for enabled sources
-	sources, err := g.store.Queries().ListEnabledSources(ctx)
+	result, err := g.Render(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return result.Content, nil
+}
+
+// Render refreshes the selected sources and formats them without writing
+// any output. The result is empty if there are no sources.
+func (g *Generator) Render(ctx context.Context, opts GenerateOptions) (*Result, error) {
+	sources, err := g.selectSources(ctx, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to list enabled sources: %w", err)
+		return nil, err
 	}
 
 	// short circuit if no sources found
 	if len(sources) == 0 {
-		return "", nil
+		return &Result{}, nil
 	}
 
-	g.logger.DebugContext(ctx, "generating context to string",
+	g.logger.DebugContext(ctx, "rendering context",
 		"source_count", len(sources),
 	)
 
 	// Check for cache misses and parse fresh content if needed
 	updatedSources, err := g.checkAndRefreshCache(ctx, sources)
 	if err != nil {
-		return "", fmt.Errorf("failed to refresh cache: %w", err)
+		return nil, fmt.Errorf("failed to refresh cache: %w", err)
 	}
 
-	// Generate content based on format
-	var content string
-	switch opts.Format {
-	case "claude", "":
-		content = g.generateClaudeFormat(updatedSources)
-	case "cursor":
-		content = g.generateCursorFormat(updatedSources)
-	default:
-		content = g.generateDefaultFormat(updatedSources)
+	redacted, redactions, err := g.redactSources(updatedSources, opts.Redact)
+	if err != nil {
+		return nil, err
+	}
+	if len(redactions) > 0 {
+		g.logger.InfoContext(ctx, "masked secrets in source content",
+			"count", len(redactions),
+		)
 	}
 
-	return content, nil
+	included, omitted := applyTokenBudget(redacted, opts.TokenBudget)
+	if len(omitted) > 0 {
+		g.logger.InfoContext(ctx, "omitted sources to stay within token budget",
+			"budget", opts.TokenBudget,
+			"omitted_count", len(omitted),
+		)
+	}
+
+	now := time.Now()
+	content := g.format(opts.Format, included, now)
+
+	return &Result{
+		Content:     content,
+		Sources:     included,
+		Omitted:     omitted,
+		Tokens:      EstimateTokens(content),
+		Redactions:  redactions,
+		GeneratedAt: now,
+	}, nil
 }
 
-// Generate creates a context file from all enabled sources
-func (g *Generator) Generate(ctx context.Context, opts GenerateOptions) error {
-	// Query DB for enabled sources
-	sources, err := g.store.Queries().ListEnabledSources(ctx)
+// Generate creates a context file from all enabled sources and returns
+// what was written
+func (g *Generator) Generate(ctx context.Context, opts GenerateOptions) (*Result, error) {
+	result, err := g.Render(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("failed to list enabled sources: %w", err)
+		return nil, err
 	}
 
-	if len(sources) == 0 {
-		return fmt.Errorf("no enabled sources found")
+	if len(result.Sources) == 0 && len(result.Omitted) == 0 {
+		return nil, fmt.Errorf("no enabled sources found")
 	}
 
 	g.logger.DebugContext(ctx, "generating context",
-		"source_count", len(sources),
+		"source_count", len(result.Sources),
 		"output_path", opts.OutputPath,
 	)
 
-	// Check for cache misses and parse fresh content if needed
-	updatedSources, err := g.checkAndRefreshCache(ctx, sources)
-	if err != nil {
-		return fmt.Errorf("failed to refresh cache: %w", err)
-	}
-
-	// Generate content based on format
-	var content string
-	switch opts.Format {
-	case "claude", "":
-		content = g.generateClaudeFormat(updatedSources)
-	case "cursor":
-		content = g.generateCursorFormat(updatedSources)
-	default:
-		content = g.generateDefaultFormat(updatedSources)
-	}
-
 	// Ensure output directory exists
 	outputDir := filepath.Dir(opts.OutputPath)
 	if err := os.MkdirAll(outputDir, 0o755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Write to file
-	if err := os.WriteFile(opts.OutputPath, []byte(content), 0o644); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+	if err := os.WriteFile(opts.OutputPath, []byte(result.Content), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write output file: %w", err)
 	}
 
 	// Record in history
-	presetName := sql.NullString{
-		String: opts.PresetName,
-		Valid:  opts.PresetName != "",
-	}
-	_, err = g.store.Queries().CreateHistory(ctx, dbgen.CreateHistoryParams{
-		PresetName:  presetName,
-		OutputPath:  opts.OutputPath,
-		SourceCount: int64(len(updatedSources)),
-	})
-	if err != nil {
+	if _, err := g.Record(ctx, opts, result); err != nil {
 		g.logger.WarnContext(ctx, "failed to record history", "error", err)
 	}
 
 	g.logger.InfoContext(ctx, "context generated",
-		"source_count", len(updatedSources),
+		"source_count", len(result.Sources),
 		"output_path", opts.OutputPath,
 	)
 
-	return nil
+	return result, nil
+}
+
+// selectSources returns the sources to generate from: the named preset's
+// sources, or all enabled sources when no preset is given
+func (g *Generator) selectSources(ctx context.Context, opts GenerateOptions) ([]dbgen.Source, error) {
+	if opts.PresetName == "" {
+		sources, err := g.store.Queries().ListEnabledSources(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list enabled sources: %w", err)
+		}
+		return sources, nil
+	}
+
+	preset, err := g.store.Queries().GetPresetByName(ctx, opts.PresetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find preset %q: %w", opts.PresetName, err)
+	}
+
+	sources, err := g.store.Queries().GetPresetSources(ctx, preset.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list preset sources: %w", err)
+	}
+	return sources, nil
 }
 
 // checkAndRefreshCache checks each source for cache misses and refreshes content if needed
@@ -147,7 +190,22 @@ func (g *Generator) checkAndRefreshCache(ctx context.Context, sources []dbgen.So
 	updatedSources := make([]dbgen.Source, 0, len(sources))
 
 	for _, source := range sources {
+		// Manually refreshed sources keep their cached content until
+		// refreshed explicitly, unless there is none yet
+		if source.RefreshPolicy == storage.RefreshManual && source.Pending == 0 {
+			updatedSources = append(updatedSources, source)
+			continue
+		}
+
 		needsRefresh, freshContent, err := g.detectCacheMiss(ctx, source)
+		if err != nil && source.Pending == 1 {
+			// A placeholder has no cached content to fall back on
+			g.logger.WarnContext(ctx, "failed to fetch pending source, skipping",
+				"source", source.Name,
+				"error", err,
+			)
+			continue
+		}
 		if err != nil {
 			g.logger.WarnContext(ctx, "failed to check cache miss, using cached content",
 				"source", source.Name,
@@ -210,7 +268,7 @@ func (g *Generator) detectCacheMiss(ctx context.Context, source dbgen.Source) (b
 
 	case "url", "bookmark":
 		// For URLs, always re-fetch to check for changes
-		content, err := g.parser.ParseURL(source.Path)
+		content, err := g.parser.ParseURLContext(ctx, source.Path)
 		if err != nil {
 			return false, "", fmt.Errorf("failed to parse URL: %w", err)
 		}
@@ -222,11 +280,76 @@ func (g *Generator) detectCacheMiss(ctx context.Context, source dbgen.Source) (b
 
 		return false, "", nil
 
+	case "pdf":
+		// PDFs may be local files or URLs, re-extract and compare hash
+		content, err := g.parser.ParsePDF(source.Path)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to parse PDF: %w", err)
+		}
+
+		currentHash := storage.ComputeHash(content)
+		if currentHash != source.Hash {
+			return true, content, nil
+		}
+
+		return false, "", nil
+
+	case "notebook":
+		// Notebooks are rendered with the options they were added with
+		opts, err := parser.DecodeOptions(source.Options)
+		if err != nil {
+			return false, "", err
+		}
+
+		content, err := g.parser.ParseNotebook(source.Path, opts)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to parse notebook: %w", err)
+		}
+
+		currentHash := storage.ComputeHash(content)
+		if currentHash != source.Hash {
+			return true, content, nil
+		}
+
+		return false, "", nil
+
+	case "openapi":
+		// Specs are condensed with the tag and path filters they were added with
+		opts, err := parser.DecodeOptions(source.Options)
+		if err != nil {
+			return false, "", err
+		}
+
+		content, err := g.parser.ParseOpenAPI(source.Path, opts)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+		}
+
+		currentHash := storage.ComputeHash(content)
+		if currentHash != source.Hash {
+			return true, content, nil
+		}
+
+		return false, "", nil
+
 	default:
 		return false, "", fmt.Errorf("unknown source type: %s", source.SourceType)
 	}
 }
 
+// format renders sources in the named format; now is the generation time
+// shown by formats that include one
+func (g *Generator) format(format string, sources []dbgen.Source, now time.Time) string {
+	switch format {
+	case "claude", "":
+		return g.generateClaudeFormat(sources)
+	case "cursor":
+		return g.generateCursorFormat(sources, now)
+	default:
+		return g.generateDefaultFormat(sources)
+	}
+}
+
 // generateClaudeFormat generates content in Claude.md format
 func (g *Generator) generateClaudeFormat(sources []dbgen.Source) string {
 	var sb strings.Builder
@@ -238,21 +361,37 @@ func (g *Generator) generateClaudeFormat(sources []dbgen.Source) string {
 	for i, source := range sources {
 		sb.WriteString(fmt.Sprintf("## %d. %s\n\n", i+1, source.Name))
 		sb.WriteString(fmt.Sprintf("**Source:** %s (%s)\n\n", source.Path, source.SourceType))
-		sb.WriteString("```\n")
+		fence := codeFence(source.Content)
+		sb.WriteString(fence + "\n")
 		sb.WriteString(source.Content)
-		sb.WriteString("\n```\n\n")
+		sb.WriteString("\n" + fence + "\n\n")
 		sb.WriteString("---\n\n")
 	}
 
 	return sb.String()
 }
 
+// codeFence returns a backtick fence longer than any backtick run in
+// content, so fences inside it, like a notebook's cells, can't close it
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
 // generateCursorFormat generates content in Cursor format
-func (g *Generator) generateCursorFormat(sources []dbgen.Source) string {
+func (g *Generator) generateCursorFormat(sources []dbgen.Source, now time.Time) string {
 	var sb strings.Builder
 
 	sb.WriteString("# Cursor Context\n\n")
-	sb.WriteString(fmt.Sprintf("Generated: %s\n\n", time.Now().Format(time.RFC3339)))
+	sb.WriteString(fmt.Sprintf("Generated: %s\n\n", now.Format(time.RFC3339)))
 
 	for _, source := range sources {
 		sb.WriteString(fmt.Sprintf("## %s\n\n", source.Name))
@@ -0,0 +1,263 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/redact"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+)
+
+// ErrNoSnapshot is returned when replaying history recorded before source
+// content was kept with each generation
+var ErrNoSnapshot = errors.New("history entry has no snapshot to replay")
+
+// pruneHistoryTask names history pruning in the maintenance table
+const pruneHistoryTask = "prune-history"
+
+// Record saves a generation to history along with the content of every
+// included source, so it can be replayed later. It returns the history ID.
+func (g *Generator) Record(ctx context.Context, opts GenerateOptions, result *Result) (int64, error) {
+	format := opts.Format
+	if format == "" {
+		format = "claude"
+	}
+
+	tx, err := g.store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := g.store.Queries().WithTx(tx)
+	entry, err := q.CreateHistory(ctx, dbgen.CreateHistoryParams{
+		PresetName:  sql.NullString{String: opts.PresetName, Valid: opts.PresetName != ""},
+		OutputPath:  opts.OutputPath,
+		SourceCount: int64(len(result.Sources)),
+		Format:      format,
+		ContentHash: storage.ComputeHash(result.Content),
+		GeneratedAt: result.GeneratedAt.Unix(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create history entry: %w", err)
+	}
+
+	// Content is stored as included, after redaction, and shared between
+	// generations that saw the same version
+	for i, source := range result.Sources {
+		hash := storage.ComputeHash(source.Content)
+		if err := q.CreateContentVersion(ctx, dbgen.CreateContentVersionParams{
+			Hash:    hash,
+			Content: source.Content,
+		}); err != nil {
+			return 0, fmt.Errorf("failed to store content of %s: %w", source.Name, err)
+		}
+
+		if err := q.AddHistorySource(ctx, dbgen.AddHistorySourceParams{
+			HistoryID:  entry.ID,
+			Position:   int64(i),
+			SourceID:   source.ID,
+			Name:       source.Name,
+			SourceType: source.SourceType,
+			Path:       source.Path,
+			Hash:       hash,
+		}); err != nil {
+			return 0, fmt.Errorf("failed to record source %s: %w", source.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit history entry: %w", err)
+	}
+
+	return entry.ID, nil
+}
+
+// Replay regenerates the output of a past generation from the source
+// content recorded with it. The output is checked against the recorded
+// hash, so a successful replay is byte-identical to the original.
+func (g *Generator) Replay(ctx context.Context, id int64) (*Result, error) {
+	entry, err := g.store.Queries().GetHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history entry %d: %w", id, err)
+	}
+	if entry.ContentHash == "" {
+		return nil, fmt.Errorf("%w: entry %d predates snapshots", ErrNoSnapshot, id)
+	}
+
+	recorded, err := g.store.Queries().ListHistorySources(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history sources: %w", err)
+	}
+
+	sources := make([]dbgen.Source, 0, len(recorded))
+	for _, r := range recorded {
+		content, err := g.store.Queries().GetContentVersion(ctx, r.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recorded content of %s: %w", r.Name, err)
+		}
+
+		sources = append(sources, dbgen.Source{
+			ID:         r.SourceID,
+			Name:       r.Name,
+			SourceType: r.SourceType,
+			Path:       r.Path,
+			Content:    content,
+			Hash:       r.Hash,
+		})
+	}
+
+	generatedAt := time.Unix(entry.GeneratedAt, 0)
+	content := g.format(entry.Format, sources, generatedAt)
+	if storage.ComputeHash(content) != entry.ContentHash {
+		return nil, fmt.Errorf("replayed output of history entry %d does not match the recorded hash", id)
+	}
+
+	return &Result{
+		Content:     content,
+		Sources:     sources,
+		Tokens:      EstimateTokens(content),
+		GeneratedAt: generatedAt,
+	}, nil
+}
+
+// Changed returns the sources whose content differs from the version
+// included in their latest generation, mapped to the hash of that version.
+// Sources never included in a generation are left out.
+func (g *Generator) Changed(ctx context.Context, sources []dbgen.Source) (map[int64]string, error) {
+	rows, err := g.store.Queries().ListLatestIncludedHashes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list included hashes: %w", err)
+	}
+	included := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		included[row.SourceID] = row.Hash
+	}
+
+	changed := make(map[int64]string)
+	for _, source := range sources {
+		hash, ok := included[source.ID]
+		if !ok || source.Pending == 1 || hash == source.Hash {
+			continue
+		}
+
+		// Content is recorded after redaction, so a source with secrets
+		// matches its masked version
+		masked, err := g.masked(source)
+		if err != nil {
+			return nil, err
+		}
+		if storage.ComputeHash(masked) != hash {
+			changed[source.ID] = hash
+		}
+	}
+
+	return changed, nil
+}
+
+// Diff returns a unified diff from the version of a source with the given
+// hash, as included in a generation, to its current content. The current
+// content is masked first if the included version was.
+func (g *Generator) Diff(ctx context.Context, source dbgen.Source, hash string) (string, error) {
+	previous, err := g.store.Queries().GetContentVersion(ctx, hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to get included content of %s: %w", source.Name, err)
+	}
+
+	current := source.Content
+	if strings.Contains(previous, "[REDACTED:") {
+		if current, err = g.masked(source); err != nil {
+			return "", err
+		}
+	}
+
+	edits := myers.ComputeEdits(span.URIFromPath(source.Name), previous, current)
+	return fmt.Sprint(gotextdiff.ToUnified("included", "current", previous, edits)), nil
+}
+
+// masked returns a source's content with secrets masked, as a generation
+// would include it by default
+func (g *Generator) masked(source dbgen.Source) (string, error) {
+	redacted, _, err := g.redactSources([]dbgen.Source{source}, redact.ModeMask)
+	if err != nil {
+		return "", err
+	}
+	return redacted[0].Content, nil
+}
+
+// PruneHistory deletes history older than retention, along with source
+// content no remaining entry refers to. It returns the number of entries deleted.
+func (g *Generator) PruneHistory(ctx context.Context, retention time.Duration) (int64, error) {
+	seconds := int64(retention.Seconds())
+
+	tx, err := g.store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := g.store.Queries().WithTx(tx)
+	if err := q.DeleteOldHistorySources(ctx, seconds); err != nil {
+		return 0, fmt.Errorf("failed to delete old history sources: %w", err)
+	}
+	deleted, err := q.DeleteOldHistory(ctx, seconds)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old history: %w", err)
+	}
+	if err := q.DeleteUnusedContentVersions(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete unused content versions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit history pruning: %w", err)
+	}
+
+	g.logger.DebugContext(ctx, "pruned history",
+		"retention", retention,
+		"deleted", deleted,
+	)
+
+	return deleted, nil
+}
+
+// PruneHistoryOnSchedule prunes history older than retention unless that
+// already happened within the last interval. A zero retention keeps
+// history forever.
+func (g *Generator) PruneHistoryOnSchedule(ctx context.Context, retention, interval time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	lastRun, err := g.store.Queries().GetMaintenanceRun(ctx, pruneHistoryTask)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Never pruned before
+	case err != nil:
+		return 0, fmt.Errorf("failed to get last history pruning: %w", err)
+	case now.Sub(time.Unix(lastRun, 0)) < interval:
+		return 0, nil
+	}
+
+	deleted, err := g.PruneHistory(ctx, retention)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := g.store.Queries().SetMaintenanceRun(ctx, dbgen.SetMaintenanceRunParams{
+		Task:      pruneHistoryTask,
+		LastRunAt: now.Unix(),
+	}); err != nil {
+		return 0, fmt.Errorf("failed to record history pruning: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,224 @@
+package generator_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+)
+
+func TestGenerator_HistoryReplay(t *testing.T) {
+	gen, store, cleanup := setupTestGenerator(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+
+	testFile := filepath.Join(tmpDir, "notes.md")
+	if err := os.WriteFile(testFile, []byte("version one"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if _, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+		Name:       "notes",
+		SourceType: "file",
+		Path:       testFile,
+		Content:    "version one",
+		Hash:       storage.ComputeHash("version one"),
+		Enabled:    1,
+		Options:    "{}",
+	}); err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	// The cursor format includes the generation time, which replay must reproduce
+	outputPath := filepath.Join(tmpDir, "CURSOR.md")
+	first, err := gen.Generate(ctx, generator.GenerateOptions{OutputPath: outputPath, Format: "cursor"})
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	// The source changes after the first generation
+	if err := os.WriteFile(testFile, []byte("version two"), 0644); err != nil {
+		t.Fatalf("failed to update test file: %v", err)
+	}
+	if _, err := gen.Generate(ctx, generator.GenerateOptions{OutputPath: outputPath, Format: "cursor"}); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	entries, err := store.Queries().ListHistory(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to list history: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Format != "cursor" {
+		t.Fatalf("expected two cursor entries, got %+v", entries)
+	}
+
+	sources, err := store.Queries().ListHistorySources(ctx, entries[1].ID)
+	if err != nil {
+		t.Fatalf("failed to list history sources: %v", err)
+	}
+	if len(sources) != 1 || sources[0].Name != "notes" || sources[0].Hash != storage.ComputeHash("version one") {
+		t.Errorf("expected the first version of notes, got %+v", sources)
+	}
+
+	replayed, err := gen.Replay(ctx, entries[1].ID)
+	if err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+	if replayed.Content != first.Content {
+		t.Errorf("expected byte-identical replay, got:\n%s\nwant:\n%s", replayed.Content, first.Content)
+	}
+
+	// Entries recorded before snapshots can't be replayed
+	old, err := store.Queries().CreateHistory(ctx, dbgen.CreateHistoryParams{
+		OutputPath:  outputPath,
+		SourceCount: 1,
+		GeneratedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create history: %v", err)
+	}
+	if _, err := gen.Replay(ctx, old.ID); !errors.Is(err, generator.ErrNoSnapshot) {
+		t.Errorf("expected ErrNoSnapshot, got %v", err)
+	}
+}
+
+func TestGenerator_ChangedSinceGeneration(t *testing.T) {
+	gen, store, cleanup := setupTestGenerator(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+
+	create := func(name, content string) dbgen.Source {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+		source, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+			Name:       name,
+			SourceType: "file",
+			Path:       path,
+			Content:    content,
+			Hash:       storage.ComputeHash(content),
+			Enabled:    1,
+			Options:    "{}",
+		})
+		if err != nil {
+			t.Fatalf("failed to create source: %v", err)
+		}
+		return source
+	}
+
+	notes := create("notes", "line one\nline two\n")
+	// Included masked, so its raw hash never matches the recorded one
+	create("env", "DATABASE_PASSWORD=hunter2\n")
+
+	result, err := gen.Render(ctx, generator.GenerateOptions{})
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	if _, err := gen.Record(ctx, generator.GenerateOptions{}, result); err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+
+	// notes changes after the generation and a new source is never included
+	if err := store.Queries().UpdateSourceContent(ctx, dbgen.UpdateSourceContentParams{
+		Content: "line one\nline 2\n",
+		Hash:    storage.ComputeHash("line one\nline 2\n"),
+		ID:      notes.ID,
+	}); err != nil {
+		t.Fatalf("failed to update source: %v", err)
+	}
+	create("new", "never generated")
+
+	sources, err := store.Queries().ListSources(ctx)
+	if err != nil {
+		t.Fatalf("failed to list sources: %v", err)
+	}
+	changed, err := gen.Changed(ctx, sources)
+	if err != nil {
+		t.Fatalf("failed to find changes: %v", err)
+	}
+	if len(changed) != 1 || changed[notes.ID] != storage.ComputeHash("line one\nline two\n") {
+		t.Fatalf("expected only notes changed, got %v", changed)
+	}
+
+	updated, err := store.Queries().GetSource(ctx, notes.ID)
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	diff, err := gen.Diff(ctx, updated, changed[notes.ID])
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	for _, want := range []string{"--- included", "+++ current", "-line two", "+line 2", " line one"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected %q in diff:\n%s", want, diff)
+		}
+	}
+}
+
+func TestGenerator_PruneHistory(t *testing.T) {
+	gen, store, cleanup := setupTestGenerator(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	record := func(content string, age time.Duration) int64 {
+		t.Helper()
+
+		id, err := gen.Record(ctx, generator.GenerateOptions{}, &generator.Result{
+			Content:     "# " + content,
+			Sources:     []dbgen.Source{{ID: 1, Name: "notes", SourceType: "file", Path: "notes.md", Content: content}},
+			GeneratedAt: time.Now().Add(-age),
+		})
+		if err != nil {
+			t.Fatalf("failed to record history: %v", err)
+		}
+		return id
+	}
+
+	old := record("old version", 48*time.Hour)
+	recent := record("new version", time.Minute)
+
+	deleted, err := gen.PruneHistoryOnSchedule(ctx, 24*time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 entry pruned, got %d", deleted)
+	}
+
+	if _, err := store.Queries().GetHistory(ctx, old); err == nil {
+		t.Error("expected old entry to be pruned")
+	}
+	if _, err := store.Queries().GetContentVersion(ctx, storage.ComputeHash("old version")); err == nil {
+		t.Error("expected unused content version to be pruned")
+	}
+	if _, err := store.Queries().GetContentVersion(ctx, storage.ComputeHash("new version")); err != nil {
+		t.Errorf("expected recent content to be kept: %v", err)
+	}
+	if _, err := store.Queries().GetHistory(ctx, recent); err != nil {
+		t.Errorf("expected recent entry to be kept: %v", err)
+	}
+
+	// Pruning already ran within the interval
+	record("another old version", 48*time.Hour)
+	deleted, err = gen.PruneHistoryOnSchedule(ctx, 24*time.Hour, time.Hour)
+	if err != nil || deleted != 0 {
+		t.Errorf("expected pruning to wait for the interval, got %d, %v", deleted, err)
+	}
+
+	// Zero retention keeps history forever
+	deleted, err = gen.PruneHistoryOnSchedule(ctx, 0, 0)
+	if err != nil || deleted != 0 {
+		t.Errorf("expected nothing pruned without retention, got %d, %v", deleted, err)
+	}
+}
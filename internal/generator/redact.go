@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/redact"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+)
+
+// ErrSecretsFound is returned when redaction is set to fail and source
+// content contains secrets
+var ErrSecretsFound = errors.New("secrets found in source content")
+
+// Redaction records a secret masked in generated output
+type Redaction struct {
+	Source string `json:"source"`
+	Rule   string `json:"rule"`
+	Line   int    `json:"line"`
+}
+
+// String describes where the secret was, without revealing it
+func (r Redaction) String() string {
+	return fmt.Sprintf("%s:%d (%s)", r.Source, r.Line, r.Rule)
+}
+
+// redactSources masks secrets in source content, honoring each source's
+// allowlist. The cached content is left as is; only the output is masked.
+func (g *Generator) redactSources(sources []dbgen.Source, mode redact.Mode) ([]dbgen.Source, []Redaction, error) {
+	if mode == redact.ModeOff {
+		return sources, nil, nil
+	}
+
+	var redactions []Redaction
+	redacted := make([]dbgen.Source, 0, len(sources))
+	for _, source := range sources {
+		opts, err := parser.DecodeOptions(source.Options)
+		if err != nil {
+			return nil, nil, err
+		}
+		allow, err := redact.NewAllowlist(opts.RedactAllow)
+		if err != nil {
+			return nil, nil, fmt.Errorf("source %s: %w", source.Name, err)
+		}
+
+		findings := redact.Scan(source.Content, allow)
+		for _, f := range findings {
+			redactions = append(redactions, Redaction{Source: source.Name, Rule: f.Rule, Line: f.Line})
+		}
+
+		source.Content = redact.Mask(source.Content, findings)
+		redacted = append(redacted, source)
+	}
+
+	if mode == redact.ModeFail && len(redactions) > 0 {
+		locations := make([]string, 0, len(redactions))
+		for _, r := range redactions {
+			locations = append(locations, r.String())
+		}
+		return nil, nil, fmt.Errorf("%w: %s", ErrSecretsFound, strings.Join(locations, ", "))
+	}
+
+	return redacted, redactions, nil
+}
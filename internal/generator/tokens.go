@@ -0,0 +1,35 @@
+package generator
+
+import (
+	"unicode/utf8"
+
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+)
+
+// EstimateTokens approximates how many LLM tokens text uses, at roughly four
+// characters per token. It's meant for budgeting, not billing.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// applyTokenBudget keeps sources in order while their content fits in the
+// budget, skipping any source too large for what's left. A budget of zero
+// or less keeps everything.
+func applyTokenBudget(sources []dbgen.Source, budget int) (included, omitted []dbgen.Source) {
+	if budget <= 0 {
+		return sources, nil
+	}
+
+	remaining := budget
+	for _, source := range sources {
+		tokens := EstimateTokens(source.Content)
+		if tokens > remaining {
+			omitted = append(omitted, source)
+			continue
+		}
+		remaining -= tokens
+		included = append(included, source)
+	}
+
+	return included, omitted
+}
@@ -0,0 +1,414 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	neturl "net/url"
+	"strings"
+	"sync"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+)
+
+// DefaultConcurrency is the number of bookmarks fetched at once
+const DefaultConcurrency = 8
+
+// Importer adds bookmarks to the store as disabled sources
+type Importer struct {
+	store  *storage.Store
+	parser *parser.Parser
+	logger *slog.Logger
+}
+
+// NewImporter creates a new Importer with explicit dependencies
+func NewImporter(store *storage.Store, parser *parser.Parser, logger *slog.Logger) *Importer {
+	return &Importer{
+		store:  store,
+		parser: parser,
+		logger: logger,
+	}
+}
+
+// Options controls how bookmarks are imported
+type Options struct {
+	// Concurrency is the number of parallel fetches (DefaultConcurrency if zero)
+	Concurrency int
+	// DryRun plans the import without fetching or writing anything
+	DryRun bool
+	// Lazy stores pending placeholders instead of fetching; see Fetch
+	Lazy bool
+	// Progress receives a progress bar while fetching; nil disables it
+	Progress io.Writer
+}
+
+// Item is a bookmark with the source name it will be imported under
+type Item struct {
+	Bookmark parser.Bookmark
+	Name     string
+	// Skip explains why the bookmark won't be imported; empty if it will be
+	Skip string
+}
+
+// Failure describes a bookmark that could not be imported
+type Failure struct {
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Folder string `json:"folder,omitempty"`
+	Error  string `json:"error"`
+}
+
+// Result summarizes an import
+type Result struct {
+	Items    []Item
+	Imported int
+	Skipped  int
+	Failures []Failure
+}
+
+// Plan dedups bookmarks by URL, both within the batch and against existing
+// sources, and gives each remaining bookmark a unique source name
+func (im *Importer) Plan(ctx context.Context, bookmarks []parser.Bookmark) ([]Item, error) {
+	sources, err := im.store.Queries().ListSources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+
+	names := make(map[string]bool, len(sources))
+	urls := make(map[string]string, len(sources))
+	for _, source := range sources {
+		names[source.Name] = true
+		if parser.IsURL(source.Path) {
+			urls[normalizeURL(source.Path)] = source.Name
+		}
+	}
+
+	seen := make(map[string]string)
+	items := make([]Item, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		item := Item{Bookmark: bookmark}
+		key := normalizeURL(bookmark.URL)
+
+		switch {
+		case urls[key] != "":
+			item.Name = urls[key]
+			item.Skip = "already imported"
+		case seen[key] != "":
+			item.Name = seen[key]
+			item.Skip = "duplicate URL"
+		default:
+			item.Name = uniqueName(strings.TrimSpace(bookmark.Title), names)
+			names[item.Name] = true
+			seen[key] = item.Name
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Import fetches bookmarks in parallel and stores them as disabled sources,
+// or stores pending placeholders without fetching when opts.Lazy is set.
+// Fetch failures are recorded so a later run can retry them with Failures.
+func (im *Importer) Import(ctx context.Context, bookmarks []parser.Bookmark, opts Options) (*Result, error) {
+	items, err := im.Plan(ctx, bookmarks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Items: items}
+
+	var queued []Item
+	for _, item := range items {
+		if item.Skip != "" {
+			result.Skipped++
+			continue
+		}
+		queued = append(queued, item)
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// Bookmarks that are already sources no longer need retrying
+	for _, item := range items {
+		if item.Skip == "already imported" {
+			if err := im.store.Queries().DeleteImportFailure(ctx, item.Bookmark.URL); err != nil {
+				return nil, fmt.Errorf("failed to clear import failure: %w", err)
+			}
+		}
+	}
+
+	if opts.Lazy {
+		for _, item := range queued {
+			if err := im.savePlaceholder(ctx, item); err != nil {
+				if err := im.recordFailure(ctx, result, item, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			result.Imported++
+		}
+		return result, nil
+	}
+
+	progress := newProgressBar(opts.Progress, len(queued))
+	defer progress.finish()
+
+	// Fetch in parallel but write from this goroutine, since SQLite
+	// serializes writers anyway. Returning early cancels the fetches still
+	// running and drains their results, so no worker is left blocked.
+	fetchCtx, cancel := context.WithCancel(ctx)
+	results := im.fetchAll(fetchCtx, queued, opts.Concurrency)
+	defer func() {
+		cancel()
+		for range results {
+		}
+	}()
+
+	for fetched := range results {
+		progress.increment()
+
+		// Cancelled fetches aren't failures worth retrying
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if fetched.err != nil {
+			if err := im.recordFailure(ctx, result, fetched.item, fetched.err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		imported, err := im.save(ctx, fetched.item, fetched.content)
+		if err != nil {
+			if err := im.recordFailure(ctx, result, fetched.item, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := im.store.Queries().DeleteImportFailure(ctx, fetched.item.Bookmark.URL); err != nil {
+			return nil, fmt.Errorf("failed to clear import failure: %w", err)
+		}
+
+		if imported {
+			result.Imported++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	return result, nil
+}
+
+// Failures returns the bookmarks whose last import attempt failed
+func (im *Importer) Failures(ctx context.Context) ([]parser.Bookmark, error) {
+	failures, err := im.store.Queries().ListImportFailures(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import failures: %w", err)
+	}
+
+	bookmarks := make([]parser.Bookmark, 0, len(failures))
+	for _, failure := range failures {
+		bookmarks = append(bookmarks, parser.Bookmark{
+			Title:  failure.Title,
+			URL:    failure.Url,
+			Folder: failure.Folder,
+		})
+	}
+
+	return bookmarks, nil
+}
+
+// Fetch downloads the content of a pending placeholder and stores it,
+// returning the updated source. Sources that aren't pending are returned as is.
+func (im *Importer) Fetch(ctx context.Context, source dbgen.Source) (dbgen.Source, error) {
+	if source.Pending == 0 {
+		return source, nil
+	}
+
+	content, err := im.parser.ParseURLContext(ctx, source.Path)
+	if err != nil {
+		return source, fmt.Errorf("failed to fetch %s: %w", source.Name, err)
+	}
+
+	hash := storage.ComputeHash(content)
+	if err := im.store.Queries().UpdateSourceContent(ctx, dbgen.UpdateSourceContentParams{
+		Content: content,
+		Hash:    hash,
+		ID:      source.ID,
+	}); err != nil {
+		return source, fmt.Errorf("failed to update source: %w", err)
+	}
+
+	source.Content = content
+	source.Hash = hash
+	source.Pending = 0
+	return source, nil
+}
+
+type fetchResult struct {
+	item    Item
+	content string
+	err     error
+}
+
+// fetchAll fetches items with a pool of workers, delivering results as they
+// complete. The channel is closed once every item has been fetched, or once
+// ctx is cancelled and the running fetches have stopped; callers must drain it.
+func (im *Importer) fetchAll(ctx context.Context, items []Item, concurrency int) <-chan fetchResult {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	jobs := make(chan Item)
+	results := make(chan fetchResult)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				content, err := im.parser.ParseURLContext(ctx, item.Bookmark.URL)
+				results <- fetchResult{item: item, content: content, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, item := range items {
+			select {
+			case jobs <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// save stores fetched content as a disabled source. It returns false when
+// another source already has the same content; pending placeholders have
+// none yet, so they don't count.
+func (im *Importer) save(ctx context.Context, item Item, content string) (bool, error) {
+	hash := storage.ComputeHash(content)
+
+	if existing, err := im.store.Queries().GetSourceByHash(ctx, hash); err == nil {
+		im.logger.DebugContext(ctx, "bookmark content already exists",
+			"title", item.Bookmark.Title,
+			"source", existing.Name,
+		)
+		return false, nil
+	}
+
+	_, err := im.store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+		Name:       item.Name,
+		SourceType: "bookmark",
+		Path:       item.Bookmark.URL,
+		Content:    content,
+		Hash:       hash,
+		Enabled:    0, // Disabled by default
+		Options:    parser.SourceOptions{}.Encode(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create source: %w", err)
+	}
+
+	return true, nil
+}
+
+// savePlaceholder stores a disabled, pending source with no content
+func (im *Importer) savePlaceholder(ctx context.Context, item Item) error {
+	_, err := im.store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+		Name:       item.Name,
+		SourceType: "bookmark",
+		Path:       item.Bookmark.URL,
+		Content:    "",
+		Hash:       storage.ComputeHash(""),
+		Enabled:    0,
+		Options:    parser.SourceOptions{}.Encode(),
+		Pending:    1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create source: %w", err)
+	}
+	return nil
+}
+
+func (im *Importer) recordFailure(ctx context.Context, result *Result, item Item, cause error) error {
+	im.logger.DebugContext(ctx, "failed to import bookmark",
+		"title", item.Bookmark.Title,
+		"url", item.Bookmark.URL,
+		"error", cause,
+	)
+
+	result.Failures = append(result.Failures, Failure{
+		Title:  item.Bookmark.Title,
+		URL:    item.Bookmark.URL,
+		Folder: item.Bookmark.Folder,
+		Error:  cause.Error(),
+	})
+
+	err := im.store.Queries().UpsertImportFailure(ctx, dbgen.UpsertImportFailureParams{
+		Url:    item.Bookmark.URL,
+		Title:  item.Bookmark.Title,
+		Folder: item.Bookmark.Folder,
+		Error:  cause.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record import failure: %w", err)
+	}
+
+	return nil
+}
+
+// uniqueName returns name, or name with the lowest free " (n)" suffix if it's taken
+func uniqueName(name string, taken map[string]bool) string {
+	if name == "" {
+		name = "Untitled"
+	}
+	if !taken[name] {
+		return name
+	}
+
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// normalizeURL returns a key for URL dedup. Fragments and host case don't
+// change what gets fetched, so they're ignored.
+func normalizeURL(raw string) string {
+	u, err := neturl.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return strings.TrimSpace(raw)
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String()
+}
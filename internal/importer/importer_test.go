@@ -0,0 +1,333 @@
+package importer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/importer"
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+)
+
+func setupTestImporter(t *testing.T) (*importer.Importer, *storage.Store) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelError, // Quiet during tests
+	}))
+
+	store, err := storage.NewStore(dbPath, logger)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	p := parser.NewParser(10 * 1024 * 1024)
+	return importer.NewImporter(store, p, logger), store
+}
+
+// newBookmarkServer serves a distinct text page per path; paths under
+// /broken fail until fixed is set
+func newBookmarkServer(t *testing.T, fixed *atomic.Bool) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if strings.HasPrefix(r.URL.Path, "/broken") && !fixed.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "content of %s", r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestImporter_Plan(t *testing.T) {
+	imp, store := setupTestImporter(t)
+	ctx := context.Background()
+
+	_, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+		Name:       "Go",
+		SourceType: "bookmark",
+		Path:       "https://go.dev/",
+		Content:    "existing",
+		Hash:       storage.ComputeHash("existing"),
+		Options:    "{}",
+	})
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	items, err := imp.Plan(ctx, []parser.Bookmark{
+		{Title: "Go", URL: "https://go.dev/doc/"},
+		{Title: "Go", URL: "https://go.dev/blog/"},
+		{Title: "Go home", URL: "https://GO.dev#top"},
+		{Title: "Docs again", URL: "https://go.dev/doc/"},
+	})
+	if err != nil {
+		t.Fatalf("failed to plan import: %v", err)
+	}
+
+	expected := []struct{ name, skip string }{
+		{"Go (2)", ""},
+		{"Go (3)", ""},
+		{"Go", "already imported"},
+		{"Go (2)", "duplicate URL"},
+	}
+
+	if len(items) != len(expected) {
+		t.Fatalf("expected %d items, got %d", len(expected), len(items))
+	}
+	for i, want := range expected {
+		if items[i].Name != want.name || items[i].Skip != want.skip {
+			t.Errorf("item %d = (%q, %q), want (%q, %q)", i, items[i].Name, items[i].Skip, want.name, want.skip)
+		}
+	}
+}
+
+func TestImporter_Import(t *testing.T) {
+	imp, store := setupTestImporter(t)
+	ctx := context.Background()
+
+	var fixed atomic.Bool
+	server, _ := newBookmarkServer(t, &fixed)
+
+	var bookmarks []parser.Bookmark
+	for i := range 20 {
+		bookmarks = append(bookmarks, parser.Bookmark{Title: "Page", URL: fmt.Sprintf("%s/page/%d", server.URL, i)})
+	}
+	bookmarks = append(bookmarks, parser.Bookmark{Title: "Flaky", URL: server.URL + "/broken", Folder: "Dev"})
+
+	var progress bytes.Buffer
+	result, err := imp.Import(ctx, bookmarks, importer.Options{Concurrency: 4, Progress: &progress})
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+
+	if result.Imported != 20 {
+		t.Errorf("expected 20 imported, got %d", result.Imported)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].URL != server.URL+"/broken" || result.Failures[0].Folder != "Dev" {
+		t.Fatalf("expected one failure for the broken bookmark, got %+v", result.Failures)
+	}
+	if !strings.Contains(progress.String(), "21/21") {
+		t.Errorf("expected progress output to reach 21/21, got %q", progress.String())
+	}
+
+	sources, err := store.Queries().ListSources(ctx)
+	if err != nil {
+		t.Fatalf("failed to list sources: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, source := range sources {
+		if source.Enabled != 0 {
+			t.Errorf("expected imported source %q to be disabled", source.Name)
+		}
+		names[source.Name] = true
+	}
+	if len(names) != 20 || !names["Page"] || !names["Page (20)"] {
+		t.Errorf("expected 20 uniquely named sources, got %v", names)
+	}
+
+	// Resume retries only the recorded failure
+	failed, err := imp.Failures(ctx)
+	if err != nil {
+		t.Fatalf("failed to list failures: %v", err)
+	}
+	if len(failed) != 1 || failed[0].Title != "Flaky" || failed[0].Folder != "Dev" {
+		t.Fatalf("expected the broken bookmark to be recorded, got %+v", failed)
+	}
+
+	fixed.Store(true)
+	result, err = imp.Import(ctx, failed, importer.Options{})
+	if err != nil {
+		t.Fatalf("failed to resume import: %v", err)
+	}
+	if result.Imported != 1 || len(result.Failures) != 0 {
+		t.Errorf("expected resumed bookmark to import, got %+v", result)
+	}
+
+	failed, err = imp.Failures(ctx)
+	if err != nil {
+		t.Fatalf("failed to list failures: %v", err)
+	}
+	if len(failed) != 0 {
+		t.Errorf("expected failures to be cleared, got %+v", failed)
+	}
+}
+
+func TestImporter_Cancel(t *testing.T) {
+	imp, _ := setupTestImporter(t)
+
+	// Pages hang until their request is cancelled
+	started := make(chan struct{}, 10)
+	var cancelled atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+		cancelled.Add(1)
+	}))
+	t.Cleanup(server.Close)
+
+	var bookmarks []parser.Bookmark
+	for i := range 6 {
+		bookmarks = append(bookmarks, parser.Bookmark{Title: "Slow", URL: fmt.Sprintf("%s/slow/%d", server.URL, i)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := imp.Import(ctx, bookmarks, importer.Options{Concurrency: 3})
+		done <- err
+	}()
+
+	for range 3 {
+		<-started
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the import to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("import did not stop after being cancelled")
+	}
+
+	// The requests already running were cancelled too
+	deadline := time.Now().Add(5 * time.Second)
+	for cancelled.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := cancelled.Load(); n < 3 {
+		t.Errorf("expected the running requests to be cancelled, got %d", n)
+	}
+}
+
+func TestImporter_DryRun(t *testing.T) {
+	imp, store := setupTestImporter(t)
+	ctx := context.Background()
+
+	var fixed atomic.Bool
+	server, requests := newBookmarkServer(t, &fixed)
+
+	result, err := imp.Import(ctx, []parser.Bookmark{
+		{Title: "One", URL: server.URL + "/one"},
+		{Title: "Two", URL: server.URL + "/broken"},
+	}, importer.Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("failed to plan import: %v", err)
+	}
+
+	if len(result.Items) != 2 || result.Imported != 0 {
+		t.Errorf("expected two planned items and nothing imported, got %+v", result)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected dry run not to fetch, got %d requests", requests.Load())
+	}
+
+	count, err := store.Queries().CountSources(ctx)
+	if err != nil {
+		t.Fatalf("failed to count sources: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected dry run not to create sources, got %d", count)
+	}
+}
+
+func TestImporter_Lazy(t *testing.T) {
+	imp, store := setupTestImporter(t)
+	ctx := context.Background()
+
+	var fixed atomic.Bool
+	server, requests := newBookmarkServer(t, &fixed)
+
+	result, err := imp.Import(ctx, []parser.Bookmark{
+		{Title: "Docs", URL: server.URL + "/docs"},
+	}, importer.Options{Lazy: true})
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if result.Imported != 1 {
+		t.Fatalf("expected one placeholder, got %+v", result)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected lazy import not to fetch, got %d requests", requests.Load())
+	}
+
+	source, err := store.Queries().GetSourceByName(ctx, "Docs")
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	if source.Pending != 1 || source.Content != "" || source.Enabled != 0 {
+		t.Fatalf("expected disabled pending placeholder, got %+v", source)
+	}
+
+	fetched, err := imp.Fetch(ctx, source)
+	if err != nil {
+		t.Fatalf("failed to fetch pending source: %v", err)
+	}
+	if fetched.Pending != 0 || fetched.Content != "content of /docs" {
+		t.Errorf("expected fetched content, got %+v", fetched)
+	}
+
+	stored, err := store.Queries().GetSourceByName(ctx, "Docs")
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	if stored.Pending != 0 || stored.Hash != storage.ComputeHash("content of /docs") {
+		t.Errorf("expected stored source to be fetched, got %+v", stored)
+	}
+}
+
+func TestImporter_EmptyPageIsNotAPlaceholderDuplicate(t *testing.T) {
+	imp, store := setupTestImporter(t)
+	ctx := context.Background()
+
+	var fixed atomic.Bool
+	server, _ := newBookmarkServer(t, &fixed)
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+	}))
+	t.Cleanup(empty.Close)
+
+	// Placeholders are stored with the hash of no content
+	if _, err := imp.Import(ctx, []parser.Bookmark{
+		{Title: "Later", URL: server.URL + "/later"},
+	}, importer.Options{Lazy: true}); err != nil {
+		t.Fatalf("failed to import placeholder: %v", err)
+	}
+
+	result, err := imp.Import(ctx, []parser.Bookmark{
+		{Title: "Blank", URL: empty.URL + "/blank"},
+	}, importer.Options{})
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if result.Imported != 1 || result.Skipped != 0 || len(result.Failures) != 0 {
+		t.Fatalf("expected the empty page imported, got %+v", result)
+	}
+	if _, err := store.Queries().GetSourceByName(ctx, "Blank"); err != nil {
+		t.Errorf("expected the empty page stored: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const progressWidth = 30
+
+// progressBar redraws a single line on w as bookmarks are fetched
+type progressBar struct {
+	w     io.Writer
+	total int
+	done  int
+}
+
+func newProgressBar(w io.Writer, total int) *progressBar {
+	bar := &progressBar{w: w, total: total}
+	bar.draw()
+	return bar
+}
+
+func (b *progressBar) increment() {
+	b.done++
+	b.draw()
+}
+
+func (b *progressBar) draw() {
+	if b.w == nil || b.total == 0 {
+		return
+	}
+
+	filled := b.done * progressWidth / b.total
+	fmt.Fprintf(b.w, "\rFetching [%s%s] %d/%d",
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", progressWidth-filled),
+		b.done,
+		b.total,
+	)
+}
+
+// finish ends the progress line so later output starts on a fresh line
+func (b *progressBar) finish() {
+	if b.w == nil || b.total == 0 {
+		return
+	}
+	fmt.Fprintln(b.w)
+}
@@ -0,0 +1,225 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/importer"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// sourceURIPrefix prefixes the resource URI of each source, followed by its ID
+const sourceURIPrefix = "context-vacuum://sources/"
+
+// Server exposes sources, presets and generation over the Model Context Protocol
+type Server struct {
+	store     *storage.Store
+	generator *generator.Generator
+	importer  *importer.Importer
+	logger    *slog.Logger
+	server    *mcp.Server
+
+	// Resources and prompts currently registered, so syncs only apply changes
+	mu        sync.Mutex
+	resources map[string]string // URI to resourceKey
+	prompts   map[string]string // name to description
+}
+
+// NewServer creates a new Server with explicit dependencies
+func NewServer(store *storage.Store, gen *generator.Generator, imp *importer.Importer, logger *slog.Logger, version string) *Server {
+	s := &Server{
+		store:     store,
+		generator: gen,
+		importer:  imp,
+		logger:    logger,
+		resources: make(map[string]string),
+		prompts:   make(map[string]string),
+	}
+
+	s.server = mcp.NewServer(&mcp.Implementation{
+		Name:    "context-vacuum",
+		Version: version,
+	}, &mcp.ServerOptions{
+		Instructions: "Sources are cached documents (files, URLs, bookmarks, specs) curated by the user. " +
+			"Read them as resources, use search_sources to find relevant ones, enable_source to select them, " +
+			"and generate_context to combine the enabled sources into a single context document.",
+		Logger: logger,
+		// Resources and prompts are synced from the database on every
+		// request, so there are no list changes to announce
+		Capabilities: &mcp.ServerCapabilities{
+			Resources: &mcp.ResourceCapabilities{},
+			Prompts:   &mcp.PromptCapabilities{},
+			Tools:     &mcp.ToolCapabilities{},
+		},
+	})
+
+	s.server.AddReceivingMiddleware(s.syncMiddleware)
+	s.addTools()
+
+	return s
+}
+
+// Run serves a single client over the transport until it disconnects
+func (s *Server) Run(ctx context.Context, transport mcp.Transport) error {
+	if err := s.sync(ctx); err != nil {
+		return err
+	}
+
+	if err := s.server.Run(ctx, transport); err != nil {
+		return fmt.Errorf("mcp server failed: %w", err)
+	}
+	return nil
+}
+
+// syncMiddleware refreshes resources and prompts before requests that use
+// them, so sources and presets changed by the CLI or TUI show up immediately
+func (s *Server) syncMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		switch method {
+		case "resources/list", "resources/read", "prompts/list", "prompts/get":
+			if err := s.sync(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return next(ctx, method, req)
+	}
+}
+
+// sync registers a resource per source and a prompt per preset, removing
+// any that no longer exist
+func (s *Server) sync(ctx context.Context) error {
+	sources, err := s.store.Queries().ListSources(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list sources: %w", err)
+	}
+
+	presets, err := s.store.Queries().ListPresets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list presets: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		resource := sourceResource(source)
+		seen[resource.URI] = true
+		key := resourceKey(resource)
+		if s.resources[resource.URI] == key {
+			continue
+		}
+		s.server.AddResource(&resource, s.readSource)
+		s.resources[resource.URI] = key
+	}
+	for uri := range s.resources {
+		if !seen[uri] {
+			s.server.RemoveResources(uri)
+			delete(s.resources, uri)
+		}
+	}
+
+	seen = make(map[string]bool, len(presets))
+	for _, preset := range presets {
+		seen[preset.Name] = true
+		if description, ok := s.prompts[preset.Name]; ok && description == preset.Description.String {
+			continue
+		}
+
+		s.server.AddPrompt(&mcp.Prompt{
+			Name:        preset.Name,
+			Description: preset.Description.String,
+			Arguments: []*mcp.PromptArgument{{
+				Name:        "format",
+				Description: "Output format: claude (default), cursor or default",
+			}},
+		}, s.getPresetPrompt)
+		s.prompts[preset.Name] = preset.Description.String
+	}
+	for name := range s.prompts {
+		if !seen[name] {
+			s.server.RemovePrompts(name)
+			delete(s.prompts, name)
+		}
+	}
+
+	return nil
+}
+
+// sourceResource describes a source as an MCP resource
+func sourceResource(source dbgen.Source) mcp.Resource {
+	status := "disabled"
+	if source.Enabled == 1 {
+		status = "enabled"
+	}
+	if source.Pending == 1 {
+		status += ", not fetched yet"
+	}
+
+	return mcp.Resource{
+		URI:         sourceURIPrefix + strconv.FormatInt(source.ID, 10),
+		Name:        source.Name,
+		Description: fmt.Sprintf("%s source (%s): %s", source.SourceType, status, source.Path),
+		MIMEType:    "text/plain",
+		Size:        int64(len(source.Content)),
+	}
+}
+
+// resourceKey identifies what a client sees of a resource, to detect changes
+func resourceKey(r mcp.Resource) string {
+	return fmt.Sprintf("%s\x00%s\x00%d", r.Name, r.Description, r.Size)
+}
+
+// readSource returns the cached content of a source, fetching placeholders first
+func (s *Server) readSource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(uri, sourceURIPrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(uri, sourceURIPrefix) {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	source, err := s.store.Queries().GetSource(ctx, id)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	source, err = s.importer.Fetch(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      uri,
+			MIMEType: "text/plain",
+			Text:     source.Content,
+		}},
+	}, nil
+}
+
+// getPresetPrompt returns the generated context of a preset's sources as a user message
+func (s *Server) getPresetPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	content, err := s.generator.GenerateToString(ctx, generator.GenerateOptions{
+		Format:     req.Params.Arguments["format"],
+		PresetName: req.Params.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.GetPromptResult{
+		Description: fmt.Sprintf("Context from preset %s", req.Params.Name),
+		Messages: []*mcp.PromptMessage{{
+			Role:    "user",
+			Content: &mcp.TextContent{Text: content},
+		}},
+	}, nil
+}
@@ -0,0 +1,410 @@
+package mcpserver_test
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/importer"
+	"github.com/brojonat/context-vacuum/internal/mcpserver"
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// client speaks newline-delimited JSON-RPC to a server over pipes
+type client struct {
+	t      *testing.T
+	w      io.WriteCloser
+	lines  *bufio.Scanner
+	nextID int
+}
+
+type rpcResponse struct {
+	ID     *int            `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *client) send(msg map[string]any) {
+	c.t.Helper()
+
+	msg["jsonrpc"] = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatalf("failed to encode message: %v", err)
+	}
+	if _, err := c.w.Write(append(data, '\n')); err != nil {
+		c.t.Fatalf("failed to write message: %v", err)
+	}
+}
+
+// call sends a request and returns its response, skipping notifications
+func (c *client) call(method string, params any) rpcResponse {
+	c.t.Helper()
+
+	c.nextID++
+	c.send(map[string]any{"id": c.nextID, "method": method, "params": params})
+
+	for c.lines.Scan() {
+		var resp rpcResponse
+		if err := json.Unmarshal(c.lines.Bytes(), &resp); err != nil {
+			c.t.Fatalf("failed to decode %q: %v", c.lines.Text(), err)
+		}
+		if resp.ID != nil && *resp.ID == c.nextID {
+			return resp
+		}
+	}
+	c.t.Fatalf("connection closed waiting for %s: %v", method, c.lines.Err())
+	return rpcResponse{}
+}
+
+// result calls a method that must succeed and decodes its result into v
+func (c *client) result(method string, params any, v any) {
+	c.t.Helper()
+
+	resp := c.call(method, params)
+	if resp.Error != nil {
+		c.t.Fatalf("%s failed: %s", method, resp.Error.Message)
+	}
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		c.t.Fatalf("failed to decode %s result: %v", method, err)
+	}
+}
+
+func setupTestServer(t *testing.T) (*client, *storage.Store) {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelError, // Quiet during tests
+	}))
+
+	store, err := storage.NewStore(dbPath, logger)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	// Sources are files so generation can refresh them from disk
+	ctx := context.Background()
+	dir := t.TempDir()
+	sources := map[string]string{
+		"style-guide": "Prefer composition over inheritance.",
+		"api-notes":   "The orders endpoint paginates with cursors.",
+	}
+	for _, name := range []string{"style-guide", "api-notes"} {
+		path := filepath.Join(dir, name+".md")
+		if err := os.WriteFile(path, []byte(sources[name]), 0644); err != nil {
+			t.Fatalf("failed to write source: %v", err)
+		}
+		_, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+			Name:       name,
+			SourceType: "file",
+			Path:       path,
+			Content:    sources[name],
+			Hash:       storage.ComputeHash(sources[name]),
+			Enabled:    0,
+			Options:    parser.SourceOptions{}.Encode(),
+		})
+		if err != nil {
+			t.Fatalf("failed to create source: %v", err)
+		}
+	}
+
+	preset, err := store.Queries().CreatePreset(ctx, dbgen.CreatePresetParams{
+		Name:        "backend",
+		Description: sql.NullString{String: "API work", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create preset: %v", err)
+	}
+	apiNotes, err := store.Queries().GetSourceByName(ctx, "api-notes")
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	if err := store.Queries().AddSourceToPreset(ctx, dbgen.AddSourceToPresetParams{
+		PresetID: preset.ID,
+		SourceID: apiNotes.ID,
+	}); err != nil {
+		t.Fatalf("failed to add source to preset: %v", err)
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+	server := mcpserver.NewServer(
+		store,
+		generator.NewGenerator(store, p, logger),
+		importer.NewImporter(store, p, logger),
+		logger,
+		"test",
+	)
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run(ctx, &mcp.IOTransport{Reader: serverReader, Writer: serverWriter})
+	}()
+	t.Cleanup(func() {
+		clientWriter.Close()
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("server did not shut down")
+		}
+	})
+
+	lines := bufio.NewScanner(clientReader)
+	lines.Buffer(make([]byte, 1024*1024), 1024*1024)
+	c := &client{t: t, w: clientWriter, lines: lines}
+
+	var init struct {
+		ServerInfo struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	c.result("initialize", map[string]any{
+		"protocolVersion": "2025-06-18",
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "test-client", "version": "1.0"},
+	}, &init)
+	if init.ServerInfo.Name != "context-vacuum" {
+		t.Fatalf("unexpected server info: %+v", init)
+	}
+	for _, capability := range []string{"resources", "prompts", "tools"} {
+		if _, ok := init.Capabilities[capability]; !ok {
+			t.Errorf("expected %s capability, got %v", capability, init.Capabilities)
+		}
+	}
+	c.send(map[string]any{"method": "notifications/initialized", "params": map[string]any{}})
+
+	return c, store
+}
+
+type toolResult struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent"`
+	IsError           bool            `json:"isError"`
+}
+
+func TestServer_Resources(t *testing.T) {
+	c, store := setupTestServer(t)
+
+	var list struct {
+		Resources []struct {
+			URI  string `json:"uri"`
+			Name string `json:"name"`
+		} `json:"resources"`
+	}
+	c.result("resources/list", map[string]any{}, &list)
+
+	uris := make(map[string]string)
+	for _, r := range list.Resources {
+		uris[r.Name] = r.URI
+	}
+	if len(uris) != 2 || uris["style-guide"] == "" {
+		t.Fatalf("expected a resource per source, got %+v", list.Resources)
+	}
+
+	var read struct {
+		Contents []struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"contents"`
+	}
+	c.result("resources/read", map[string]any{"uri": uris["style-guide"]}, &read)
+	if len(read.Contents) != 1 || read.Contents[0].Text != "Prefer composition over inheritance." {
+		t.Errorf("unexpected resource contents: %+v", read.Contents)
+	}
+
+	// Sources added after startup show up without restarting
+	_, err := store.Queries().CreateSource(context.Background(), dbgen.CreateSourceParams{
+		Name:       "late-addition",
+		SourceType: "file",
+		Path:       "/late.md",
+		Content:    "late",
+		Hash:       storage.ComputeHash("late"),
+		Options:    "{}",
+	})
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	c.result("resources/list", map[string]any{}, &list)
+	if len(list.Resources) != 3 {
+		t.Errorf("expected new source to be listed, got %+v", list.Resources)
+	}
+
+	if resp := c.call("resources/read", map[string]any{"uri": "context-vacuum://sources/999"}); resp.Error == nil {
+		t.Error("expected error reading unknown resource")
+	}
+}
+
+func TestServer_Tools(t *testing.T) {
+	c, store := setupTestServer(t)
+
+	var tools struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	c.result("tools/list", map[string]any{}, &tools)
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "enable_source,generate_context,search_sources" {
+		t.Errorf("unexpected tools: %v", names)
+	}
+
+	// Search matches content and returns a snippet
+	var search toolResult
+	c.result("tools/call", map[string]any{
+		"name":      "search_sources",
+		"arguments": map[string]any{"query": "COMPOSITION"},
+	}, &search)
+	var matches mcpserver.SearchSourcesOutput
+	if err := json.Unmarshal(search.StructuredContent, &matches); err != nil {
+		t.Fatalf("failed to decode search output: %v", err)
+	}
+	if len(matches.Matches) != 1 || matches.Matches[0].Name != "style-guide" ||
+		!strings.Contains(matches.Matches[0].Snippet, "composition") {
+		t.Errorf("unexpected search results: %+v", matches)
+	}
+
+	// Nothing is enabled yet
+	var generated toolResult
+	c.result("tools/call", map[string]any{"name": "generate_context", "arguments": map[string]any{}}, &generated)
+	if len(generated.Content) != 1 || !strings.Contains(generated.Content[0].Text, "No enabled sources") {
+		t.Errorf("expected hint about enabling sources, got %+v", generated)
+	}
+
+	var enabled toolResult
+	c.result("tools/call", map[string]any{
+		"name":      "enable_source",
+		"arguments": map[string]any{"name": "style-guide"},
+	}, &enabled)
+	if enabled.IsError {
+		t.Fatalf("enable_source failed: %+v", enabled)
+	}
+
+	c.result("tools/call", map[string]any{
+		"name":      "generate_context",
+		"arguments": map[string]any{"format": "claude"},
+	}, &generated)
+	if len(generated.Content) != 1 || !strings.Contains(generated.Content[0].Text, "Prefer composition") ||
+		strings.Contains(generated.Content[0].Text, "orders endpoint") {
+		t.Errorf("expected only the enabled source, got %+v", generated)
+	}
+
+	// Only the generation with sources is recorded
+	history, err := store.Queries().ListHistory(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("failed to list history: %v", err)
+	}
+	if len(history) != 1 || history[0].SourceCount != 1 || history[0].Format != "claude" {
+		t.Errorf("expected one generation recorded, got %+v", history)
+	}
+
+	// Tool errors are reported to the model rather than failing the request
+	var missing toolResult
+	c.result("tools/call", map[string]any{
+		"name":      "enable_source",
+		"arguments": map[string]any{"name": "no-such-source"},
+	}, &missing)
+	if !missing.IsError {
+		t.Error("expected error result for unknown source")
+	}
+}
+
+func TestServer_SearchNonASCII(t *testing.T) {
+	c, store := setupTestServer(t)
+
+	// Ⱥ takes two bytes but its lower case takes three, so offsets in the
+	// lowered content don't line up with the original
+	content := strings.Repeat("Ⱥ", 100) + " SECRET handshake"
+	if _, err := store.Queries().CreateSource(context.Background(), dbgen.CreateSourceParams{
+		Name:       "runes",
+		SourceType: "file",
+		Path:       "/path/to/runes.md",
+		Content:    content,
+		Hash:       storage.ComputeHash(content),
+		Options:    parser.SourceOptions{}.Encode(),
+	}); err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	for _, query := range []string{"secret", "ⱥⱥ secret"} {
+		var search toolResult
+		c.result("tools/call", map[string]any{
+			"name":      "search_sources",
+			"arguments": map[string]any{"query": query},
+		}, &search)
+		var matches mcpserver.SearchSourcesOutput
+		if err := json.Unmarshal(search.StructuredContent, &matches); err != nil {
+			t.Fatalf("failed to decode search output for %q: %v", query, err)
+		}
+		if len(matches.Matches) != 1 || !strings.Contains(matches.Matches[0].Snippet, "SECRET handshake") {
+			t.Errorf("unexpected search results for %q: %+v", query, matches)
+		}
+	}
+}
+
+func TestServer_Prompts(t *testing.T) {
+	c, _ := setupTestServer(t)
+
+	var list struct {
+		Prompts []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"prompts"`
+	}
+	c.result("prompts/list", map[string]any{}, &list)
+	if len(list.Prompts) != 1 || list.Prompts[0].Name != "backend" || list.Prompts[0].Description != "API work" {
+		t.Fatalf("expected a prompt per preset, got %+v", list.Prompts)
+	}
+
+	var prompt struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	c.result("prompts/get", map[string]any{
+		"name":      "backend",
+		"arguments": map[string]any{"format": "default"},
+	}, &prompt)
+
+	if len(prompt.Messages) != 1 || prompt.Messages[0].Role != "user" {
+		t.Fatalf("unexpected prompt: %+v", prompt)
+	}
+	text := prompt.Messages[0].Content.Text
+	if !strings.Contains(text, "orders endpoint") || strings.Contains(text, "composition") {
+		t.Errorf("expected the preset's sources only, got %q", text)
+	}
+
+	if resp := c.call("prompts/get", map[string]any{"name": "missing"}); resp.Error == nil {
+		t.Errorf("expected error for unknown prompt, got %s", resp.Result)
+	}
+}
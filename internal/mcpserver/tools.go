@@ -0,0 +1,219 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// snippetRadius is the number of bytes shown around a content match
+const snippetRadius = 80
+
+// SearchSourcesInput is the input of the search_sources tool
+type SearchSourcesInput struct {
+	Query       string `json:"query" jsonschema:"case-insensitive text to find in source names, paths and content"`
+	EnabledOnly bool   `json:"enabled_only,omitempty" jsonschema:"only search enabled sources"`
+}
+
+// SourceMatch is a source found by search_sources
+type SourceMatch struct {
+	Name    string `json:"name"`
+	URI     string `json:"uri"`
+	Type    string `json:"type"`
+	Path    string `json:"path"`
+	Enabled bool   `json:"enabled"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// SearchSourcesOutput is the output of the search_sources tool
+type SearchSourcesOutput struct {
+	Matches []SourceMatch `json:"matches"`
+}
+
+// EnableSourceInput is the input of the enable_source tool
+type EnableSourceInput struct {
+	Name    string `json:"name" jsonschema:"name of the source"`
+	Disable bool   `json:"disable,omitempty" jsonschema:"disable the source instead of enabling it"`
+}
+
+// EnableSourceOutput is the output of the enable_source tool
+type EnableSourceOutput struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// GenerateContextInput is the input of the generate_context tool
+type GenerateContextInput struct {
+	Format string `json:"format,omitempty" jsonschema:"output format: claude (default), cursor or default"`
+	Preset string `json:"preset,omitempty" jsonschema:"generate from this preset's sources instead of the enabled sources"`
+}
+
+func (s *Server) addTools() {
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "search_sources",
+		Description: "Search cached sources by name, path and content",
+	}, s.searchSources)
+
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "enable_source",
+		Description: "Enable a source so it is included in generated context, or disable it",
+	}, s.enableSource)
+
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "generate_context",
+		Description: "Combine the enabled sources, or a preset's sources, into one context document",
+	}, s.generateContext)
+}
+
+func (s *Server) searchSources(ctx context.Context, req *mcp.CallToolRequest, in SearchSourcesInput) (*mcp.CallToolResult, SearchSourcesOutput, error) {
+	query := strings.ToLower(strings.TrimSpace(in.Query))
+	if query == "" {
+		return nil, SearchSourcesOutput{}, fmt.Errorf("query must not be empty")
+	}
+
+	sources, err := s.store.Queries().ListSources(ctx)
+	if err != nil {
+		return nil, SearchSourcesOutput{}, fmt.Errorf("failed to list sources: %w", err)
+	}
+
+	out := SearchSourcesOutput{Matches: []SourceMatch{}}
+	for _, source := range sources {
+		if in.EnabledOnly && source.Enabled != 1 {
+			continue
+		}
+
+		snippet, found := contentSnippet(source.Content, query)
+		if !found &&
+			!strings.Contains(strings.ToLower(source.Name), query) &&
+			!strings.Contains(strings.ToLower(source.Path), query) {
+			continue
+		}
+
+		out.Matches = append(out.Matches, SourceMatch{
+			Name:    source.Name,
+			URI:     sourceResource(source).URI,
+			Type:    source.SourceType,
+			Path:    source.Path,
+			Enabled: source.Enabled == 1,
+			Snippet: snippet,
+		})
+	}
+
+	return nil, out, nil
+}
+
+func (s *Server) enableSource(ctx context.Context, req *mcp.CallToolRequest, in EnableSourceInput) (*mcp.CallToolResult, EnableSourceOutput, error) {
+	source, err := s.store.Queries().GetSourceByName(ctx, in.Name)
+	if err != nil {
+		return nil, EnableSourceOutput{}, fmt.Errorf("source %q not found", in.Name)
+	}
+
+	enabled := int64(1)
+	if in.Disable {
+		enabled = 0
+	}
+
+	// Lazily imported bookmarks are fetched the first time they're enabled
+	if enabled == 1 {
+		if _, err := s.importer.Fetch(ctx, source); err != nil {
+			return nil, EnableSourceOutput{}, err
+		}
+	}
+
+	if err := s.store.Queries().UpdateSourceEnabled(ctx, dbgen.UpdateSourceEnabledParams{
+		Enabled: enabled,
+		Name:    source.Name,
+	}); err != nil {
+		return nil, EnableSourceOutput{}, fmt.Errorf("failed to update source: %w", err)
+	}
+
+	return nil, EnableSourceOutput{Name: source.Name, Enabled: enabled == 1}, nil
+}
+
+func (s *Server) generateContext(ctx context.Context, req *mcp.CallToolRequest, in GenerateContextInput) (*mcp.CallToolResult, any, error) {
+	opts := generator.GenerateOptions{
+		Format:     in.Format,
+		PresetName: in.Preset,
+	}
+	result, err := s.generator.Render(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(result.Sources) > 0 {
+		if _, err := s.generator.Record(ctx, opts, result); err != nil {
+			s.logger.WarnContext(ctx, "failed to record history", "error", err)
+		}
+	}
+
+	content := result.Content
+	if content == "" {
+		content = "No enabled sources. Use search_sources and enable_source to select some."
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: content}},
+	}, nil, nil
+}
+
+// contentSnippet returns the text around the first case-insensitive match
+// of query in content
+func contentSnippet(content, query string) (string, bool) {
+	index, length := indexFold(content, query)
+	if index < 0 {
+		return "", false
+	}
+
+	start := max(index-snippetRadius, 0)
+	end := min(index+length+snippetRadius, len(content))
+
+	// Avoid cutting multi-byte characters in half
+	for start > 0 && !isRuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !isRuneStart(content[end]) {
+		end++
+	}
+
+	snippet := strings.Join(strings.Fields(content[start:end]), " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(content) {
+		snippet += "…"
+	}
+	return snippet, true
+}
+
+// indexFold returns the byte offset and length in s of the first match of
+// substr under Unicode case folding, or -1. The offsets are into s itself,
+// since lowercasing can change how many bytes a rune takes.
+func indexFold(s, substr string) (int, int) {
+	if substr == "" {
+		return 0, 0
+	}
+	for i := range s {
+		rest, query := s[i:], substr
+		for query != "" && rest != "" {
+			r, n := utf8.DecodeRuneInString(rest)
+			q, m := utf8.DecodeRuneInString(query)
+			if !strings.EqualFold(string(r), string(q)) {
+				break
+			}
+			rest, query = rest[n:], query[m:]
+		}
+		if query == "" {
+			return i, len(s) - i - len(rest)
+		}
+	}
+	return -1, 0
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
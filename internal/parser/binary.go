@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder for image stubs
+	_ "image/jpeg" // register JPEG decoder for image stubs
+	_ "image/png"  // register PNG decoder for image stubs
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrBinaryContent is returned when a source contains binary data that cannot
+// be included in a text context. Callers walking many files can check for it
+// with errors.Is and skip the file.
+var ErrBinaryContent = errors.New("binary content is not supported")
+
+// nullByteScanLimit is how far into the content to look for NUL bytes, the
+// same heuristic git uses to decide whether a file is binary
+const nullByteScanLimit = 8000
+
+// sniffContent detects the MIME type of content and reports whether it is binary
+func sniffContent(content []byte) (mimeType string, binary bool) {
+	mimeType = http.DetectContentType(content)
+
+	// UTF-16 text legitimately contains NUL bytes
+	if strings.Contains(mimeType, "utf-16") {
+		return mimeType, false
+	}
+
+	head := content
+	if len(head) > nullByteScanLimit {
+		head = head[:nullByteScanLimit]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return mimeType, true
+	}
+
+	// Image signatures are short, e.g. any text starting with "BM" sniffs as
+	// a bitmap, so text that doesn't decode as an image stays text
+	if strings.HasPrefix(mimeType, "image/") && utf8.Valid(content) {
+		if _, _, err := image.DecodeConfig(bytes.NewReader(content)); err != nil {
+			return "text/plain; charset=utf-8", false
+		}
+	}
+
+	if strings.HasPrefix(mimeType, "text/") || mimeType == "application/octet-stream" {
+		return mimeType, false
+	}
+
+	return mimeType, true
+}
+
+// binaryContent returns the text to use in place of binary content: a metadata
+// stub for images, or ErrBinaryContent for anything else
+func binaryContent(name string, content []byte, mimeType string) (string, error) {
+	if strings.HasPrefix(mimeType, "image/") {
+		return imageStub(name, content, mimeType), nil
+	}
+
+	return "", fmt.Errorf("%w: %s (%s)", ErrBinaryContent, name, mimeType)
+}
+
+// imageStub describes an image without including its data
+func imageStub(name string, content []byte, mimeType string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("[image: %s]\n", name))
+	sb.WriteString(fmt.Sprintf("Type: %s\n", mimeType))
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(content)); err == nil {
+		sb.WriteString(fmt.Sprintf("Dimensions: %dx%d\n", cfg.Width, cfg.Height))
+	}
+	sb.WriteString(fmt.Sprintf("Size: %s\n", formatSize(int64(len(content)))))
+
+	return sb.String()
+}
+
+// formatSize formats a byte count for humans
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,112 @@
+package parser_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestParser_ParseFile_Binary(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "a.out")
+	content := append([]byte("\x7fELF\x02\x01\x01\x00"), make([]byte, 64)...)
+
+	if err := os.WriteFile(testFile, content, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	_, err := p.ParseFile(testFile)
+	if err == nil {
+		t.Fatal("expected error for binary file, got nil")
+	}
+
+	if !errors.Is(err, parser.ErrBinaryContent) {
+		t.Errorf("expected ErrBinaryContent, got: %v", err)
+	}
+}
+
+func TestParser_ParseFile_ImageStub(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "logo.png")
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 32, 16))); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+	if err := os.WriteFile(testFile, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to parse image: %v", err)
+	}
+
+	for _, want := range []string{"[image: ", "Type: image/png", "Dimensions: 32x16", "Size: "} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected stub to contain %q, got %q", want, content)
+		}
+	}
+
+	if strings.Contains(content, "PNG") {
+		t.Error("expected image data to be excluded from stub")
+	}
+}
+
+func TestParser_ParseFile_TextWithImageSignature(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "cars.txt")
+
+	// "BM" is the bitmap signature
+	text := "BMW service notes\nOil change every 15000 km.\n"
+	if err := os.WriteFile(testFile, []byte(text), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("expected text to be accepted, got: %v", err)
+	}
+	if content != text {
+		t.Errorf("expected the text kept, got %q", content)
+	}
+}
+
+func TestParser_ParseFile_UTF16(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "notes.txt")
+
+	encoder := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder()
+	raw, err := encoder.Bytes([]byte("hello utf-16\n"))
+	if err != nil {
+		t.Fatalf("failed to encode test content: %v", err)
+	}
+	if err := os.WriteFile(testFile, raw, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("expected UTF-16 text to be accepted, got: %v", err)
+	}
+
+	if content != "hello utf-16\n" {
+		t.Errorf("expected decoded text, got %q", content)
+	}
+}
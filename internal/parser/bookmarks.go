@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// chromeRootNames maps Chrome's root keys to the names shown in the browser
+var chromeRootNames = map[string]string{
+	"bookmark_bar": "Bookmarks bar",
+	"other":        "Other bookmarks",
+	"synced":       "Mobile bookmarks",
+}
+
+// firefoxRootNames maps Firefox's root identifiers to the names shown in the browser
+var firefoxRootNames = map[string]string{
+	"bookmarksMenuFolder":    "Bookmarks Menu",
+	"toolbarFolder":          "Bookmarks Toolbar",
+	"unfiledBookmarksFolder": "Other Bookmarks",
+	"mobileFolder":           "Mobile Bookmarks",
+}
+
+// ParseBookmarks parses a bookmark file in any supported format: the
+// Netscape HTML export, Chrome's Bookmarks JSON file or a Firefox JSON backup
+func (p *Parser) ParseBookmarks(path string) ([]Bookmark, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmark file: %w", err)
+	}
+
+	if bytes.HasPrefix(content, []byte("mozLz40\x00")) {
+		return nil, fmt.Errorf("compressed Firefox backups (.jsonlz4) are not supported; export a .json backup instead")
+	}
+
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return p.ParseBookmarkHTML(path)
+	}
+
+	return ParseBookmarkJSON(trimmed)
+}
+
+// ParseBookmarkJSON parses Chrome's Bookmarks file or a Firefox JSON backup
+func ParseBookmarkJSON(content []byte) ([]Bookmark, error) {
+	var probe struct {
+		Roots json.RawMessage `json:"roots"`
+		Type  string          `json:"type"`
+	}
+	if err := json.Unmarshal(content, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse bookmark JSON: %w", err)
+	}
+
+	switch {
+	case probe.Roots != nil:
+		return parseChromeBookmarks(content)
+	case probe.Type == "text/x-moz-place-container":
+		return parseFirefoxBookmarks(content)
+	default:
+		return nil, fmt.Errorf("unrecognized bookmark JSON: expected a Chrome Bookmarks file or Firefox backup")
+	}
+}
+
+type chromeNode struct {
+	Type     string       `json:"type"`
+	Name     string       `json:"name"`
+	URL      string       `json:"url"`
+	Children []chromeNode `json:"children"`
+}
+
+func parseChromeBookmarks(content []byte) ([]Bookmark, error) {
+	var file struct {
+		Roots map[string]json.RawMessage `json:"roots"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse Chrome bookmarks: %w", err)
+	}
+
+	var bookmarks []Bookmark
+	var walk func(chromeNode, []string)
+	walk = func(n chromeNode, folders []string) {
+		switch n.Type {
+		case "url":
+			if n.URL != "" && n.Name != "" {
+				bookmarks = append(bookmarks, Bookmark{
+					Title:  n.Name,
+					URL:    n.URL,
+					Folder: joinFolder(folders),
+				})
+			}
+		case "folder":
+			folders = append(folders[:len(folders):len(folders)], n.Name)
+			for _, child := range n.Children {
+				walk(child, folders)
+			}
+		}
+	}
+
+	// Walk the roots in the order Chrome displays them
+	for _, key := range []string{"bookmark_bar", "other", "synced"} {
+		raw, ok := file.Roots[key]
+		if !ok {
+			continue
+		}
+
+		var root chromeNode
+		if err := json.Unmarshal(raw, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse Chrome bookmark root %q: %w", key, err)
+		}
+		if root.Name == "" {
+			root.Name = chromeRootNames[key]
+		}
+		walk(root, nil)
+	}
+
+	return bookmarks, nil
+}
+
+type firefoxNode struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	URI      string        `json:"uri"`
+	Root     string        `json:"root"`
+	Children []firefoxNode `json:"children"`
+}
+
+func parseFirefoxBookmarks(content []byte) ([]Bookmark, error) {
+	var root firefoxNode
+	if err := json.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse Firefox bookmarks: %w", err)
+	}
+
+	var bookmarks []Bookmark
+	var walk func(firefoxNode, []string)
+	walk = func(n firefoxNode, folders []string) {
+		switch n.Type {
+		case "text/x-moz-place":
+			// Skip smart bookmarks (place: queries) and bookmarklets
+			if IsURL(n.URI) && n.Title != "" {
+				bookmarks = append(bookmarks, Bookmark{
+					Title:  n.Title,
+					URL:    n.URI,
+					Folder: joinFolder(folders),
+				})
+			}
+		case "text/x-moz-place-container":
+			// The places root is unnamed and not a folder users see
+			if n.Root != "placesRoot" {
+				name := n.Title
+				if display, ok := firefoxRootNames[n.Root]; ok {
+					name = display
+				}
+				folders = append(folders[:len(folders):len(folders)], name)
+			}
+			for _, child := range n.Children {
+				walk(child, folders)
+			}
+		}
+	}
+
+	walk(root, nil)
+	return bookmarks, nil
+}
+
+// FilterBookmarksByFolder returns the bookmarks in the given folder or any of
+// its subfolders. The folder matches any run of path segments, so "Dev/Go"
+// selects "Bookmarks bar/Dev/Go" and "Bookmarks bar/Dev/Go/Testing".
+// Segments are compared case-insensitively.
+func FilterBookmarksByFolder(bookmarks []Bookmark, folder string) []Bookmark {
+	want := splitFolder(folder)
+	if len(want) == 0 {
+		return bookmarks
+	}
+
+	var filtered []Bookmark
+	for _, b := range bookmarks {
+		if folderContains(splitFolder(b.Folder), want) {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered
+}
+
+func folderContains(path, want []string) bool {
+	for start := 0; start+len(want) <= len(path); start++ {
+		matched := true
+		for i, segment := range want {
+			if !strings.EqualFold(path[start+i], segment) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func splitFolder(folder string) []string {
+	var segments []string
+	for _, segment := range strings.Split(folder, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+func joinFolder(folders []string) string {
+	return strings.Join(folders, "/")
+}
+
+// nodeText returns the concatenated text of an HTML node and its descendants
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		text.WriteString(nodeText(c))
+	}
+	return text.String()
+}
@@ -0,0 +1,177 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+)
+
+const testBookmarksHTML = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000">Dev</H3>
+    <DL><p>
+        <DT><H3>Go</H3>
+        <DL><p>
+            <DT><A HREF="https://go.dev/doc/effective_go">Effective Go</A>
+        </DL><p>
+        <DT><A HREF="https://www.rust-lang.org">Rust</A>
+    </DL><p>
+    <DT><A HREF="https://news.ycombinator.com">Hacker News</A>
+</DL><p>`
+
+const testChromeBookmarks = `{
+  "checksum": "abc",
+  "roots": {
+    "bookmark_bar": {
+      "type": "folder", "name": "Bookmarks bar",
+      "children": [
+        {"type": "folder", "name": "Dev", "children": [
+          {"type": "folder", "name": "Go", "children": [
+            {"type": "url", "name": "Effective Go", "url": "https://go.dev/doc/effective_go"}
+          ]},
+          {"type": "url", "name": "Rust", "url": "https://www.rust-lang.org"}
+        ]}
+      ]
+    },
+    "other": {"type": "folder", "name": "Other bookmarks", "children": [
+      {"type": "url", "name": "Hacker News", "url": "https://news.ycombinator.com"}
+    ]},
+    "synced": {"type": "folder", "name": "Mobile bookmarks", "children": []}
+  },
+  "version": 1
+}`
+
+const testFirefoxBookmarks = `{
+  "guid": "root________", "title": "", "type": "text/x-moz-place-container", "root": "placesRoot",
+  "children": [
+    {"guid": "menu________", "title": "menu", "type": "text/x-moz-place-container", "root": "bookmarksMenuFolder", "children": [
+      {"title": "Most Visited", "type": "text/x-moz-place", "uri": "place:sort=8&maxResults=10"}
+    ]},
+    {"guid": "toolbar_____", "title": "toolbar", "type": "text/x-moz-place-container", "root": "toolbarFolder", "children": [
+      {"title": "Dev", "type": "text/x-moz-place-container", "children": [
+        {"title": "Go", "type": "text/x-moz-place-container", "children": [
+          {"title": "Effective Go", "type": "text/x-moz-place", "uri": "https://go.dev/doc/effective_go"}
+        ]},
+        {"type": "text/x-moz-place-separator"},
+        {"title": "Rust", "type": "text/x-moz-place", "uri": "https://www.rust-lang.org"}
+      ]}
+    ]}
+  ]
+}`
+
+func writeBookmarks(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create bookmark file: %v", err)
+	}
+	return path
+}
+
+func TestParser_ParseBookmarks(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected []parser.Bookmark
+	}{
+		{
+			name:    "netscape html",
+			file:    "bookmarks.html",
+			content: testBookmarksHTML,
+			expected: []parser.Bookmark{
+				{Title: "Effective Go", URL: "https://go.dev/doc/effective_go", Folder: "Dev/Go"},
+				{Title: "Rust", URL: "https://www.rust-lang.org", Folder: "Dev"},
+				{Title: "Hacker News", URL: "https://news.ycombinator.com", Folder: ""},
+			},
+		},
+		{
+			name:    "chrome json",
+			file:    "Bookmarks",
+			content: testChromeBookmarks,
+			expected: []parser.Bookmark{
+				{Title: "Effective Go", URL: "https://go.dev/doc/effective_go", Folder: "Bookmarks bar/Dev/Go"},
+				{Title: "Rust", URL: "https://www.rust-lang.org", Folder: "Bookmarks bar/Dev"},
+				{Title: "Hacker News", URL: "https://news.ycombinator.com", Folder: "Other bookmarks"},
+			},
+		},
+		{
+			name:    "firefox json backup",
+			file:    "bookmarks-2025-01-01.json",
+			content: testFirefoxBookmarks,
+			expected: []parser.Bookmark{
+				{Title: "Effective Go", URL: "https://go.dev/doc/effective_go", Folder: "Bookmarks Toolbar/Dev/Go"},
+				{Title: "Rust", URL: "https://www.rust-lang.org", Folder: "Bookmarks Toolbar/Dev"},
+			},
+		},
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookmarks, err := p.ParseBookmarks(writeBookmarks(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("failed to parse bookmarks: %v", err)
+			}
+
+			if !reflect.DeepEqual(bookmarks, tt.expected) {
+				t.Errorf("ParseBookmarks() = %+v, want %+v", bookmarks, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParser_ParseBookmarks_Unrecognized(t *testing.T) {
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	if _, err := p.ParseBookmarks(writeBookmarks(t, "data.json", `{"items": []}`)); err == nil {
+		t.Error("expected error for unrecognized JSON, got nil")
+	}
+
+	if _, err := p.ParseBookmarks(writeBookmarks(t, "backup.jsonlz4", "mozLz40\x00\x01\x02")); err == nil {
+		t.Error("expected error for compressed Firefox backup, got nil")
+	}
+}
+
+func TestFilterBookmarksByFolder(t *testing.T) {
+	bookmarks := []parser.Bookmark{
+		{Title: "Effective Go", Folder: "Bookmarks bar/Dev/Go"},
+		{Title: "Go Testing", Folder: "Bookmarks bar/Dev/Go/Testing"},
+		{Title: "Rust", Folder: "Bookmarks bar/Dev"},
+		{Title: "Gopher Toys", Folder: "Bookmarks bar/Fun/Gophers"},
+		{Title: "Hacker News", Folder: ""},
+	}
+
+	tests := []struct {
+		folder   string
+		expected []string
+	}{
+		{folder: "Dev/Go", expected: []string{"Effective Go", "Go Testing"}},
+		{folder: "dev/go/", expected: []string{"Effective Go", "Go Testing"}},
+		{folder: "Dev", expected: []string{"Effective Go", "Go Testing", "Rust"}},
+		{folder: "Bookmarks bar/Fun", expected: []string{"Gopher Toys"}},
+		{folder: "Go/Testing", expected: []string{"Go Testing"}},
+		{folder: "Nope", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.folder, func(t *testing.T) {
+			var titles []string
+			for _, b := range parser.FilterBookmarksByFolder(bookmarks, tt.folder) {
+				titles = append(titles, b.Title)
+			}
+
+			if !reflect.DeepEqual(titles, tt.expected) {
+				t.Errorf("FilterBookmarksByFolder(%q) = %v, want %v", tt.folder, titles, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// byteOrderMark is stripped from decoded content so that the same text hashes
+// identically whether or not it was saved with a BOM
+const byteOrderMark = "\ufeff"
+
+// decodeToUTF8 converts raw content to UTF-8 text.
+//
+// The encoding is taken from, in order of precedence: a byte order mark, the
+// charset parameter of contentType, and a <meta charset> declaration in the
+// first 1024 bytes. Declarations from meta tags are only trusted when the
+// content is not already valid UTF-8, since pages are often re-encoded
+// without their meta tags being updated. Undeclared content that is not
+// valid UTF-8 is decoded as windows-1252, the superset of Latin-1 that
+// browsers use for legacy pages.
+func decodeToUTF8(content []byte, contentType string) (string, error) {
+	enc, name, certain := charset.DetermineEncoding(content, contentType)
+
+	if !certain && utf8.Valid(content) {
+		enc = encoding.Nop
+	}
+
+	if enc != encoding.Nop {
+		decoded, err := enc.NewDecoder().Bytes(content)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s content: %w", name, err)
+		}
+		content = decoded
+	}
+
+	text := strings.TrimPrefix(string(content), byteOrderMark)
+
+	// Replace any remaining invalid sequences so the result is always valid UTF-8
+	return strings.ToValidUTF8(text, "\ufffd"), nil
+}
@@ -0,0 +1,104 @@
+package parser_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestParser_ParseFile_Charset(t *testing.T) {
+	latin1 := func(s string) []byte {
+		b, err := charmap.ISO8859_1.NewEncoder().Bytes([]byte(s))
+		if err != nil {
+			t.Fatalf("failed to encode test content: %v", err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "utf-8 passes through",
+			raw:      []byte("café ☕\n"),
+			expected: "café ☕\n",
+		},
+		{
+			name:     "utf-8 byte order mark is stripped",
+			raw:      append([]byte{0xEF, 0xBB, 0xBF}, []byte("café\n")...),
+			expected: "café\n",
+		},
+		{
+			name:     "undeclared latin-1",
+			raw:      latin1("naïve café\n"),
+			expected: "naïve café\n",
+		},
+		{
+			name:     "meta charset declaration",
+			raw:      latin1(`<html><head><meta charset="iso-8859-1"></head><body>Grüße</body></html>`),
+			expected: `<html><head><meta charset="iso-8859-1"></head><body>Grüße</body></html>`,
+		},
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), "test.txt")
+			if err := os.WriteFile(testFile, tt.raw, 0644); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			first, err := p.ParseFile(testFile)
+			if err != nil {
+				t.Fatalf("failed to parse file: %v", err)
+			}
+
+			if first != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, first)
+			}
+
+			second, err := p.ParseFile(testFile)
+			if err != nil {
+				t.Fatalf("failed to parse file: %v", err)
+			}
+
+			if first != second {
+				t.Error("expected identical output across runs")
+			}
+		})
+	}
+}
+
+func TestParser_ParseURL_Charset(t *testing.T) {
+	body, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("<html><body><main><p>こんにちは世界</p></main></body></html>"))
+	if err != nil {
+		t.Fatalf("failed to encode test content: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParseURL(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+
+	if !strings.Contains(content, "こんにちは世界") {
+		t.Errorf("expected decoded Japanese text, got %q", content)
+	}
+}
@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LLMsTxt represents a parsed llms.txt file (https://llmstxt.org)
+type LLMsTxt struct {
+	Title   string
+	Summary string
+	Links   []LLMsLink
+}
+
+// LLMsLink represents a single link listed in an llms.txt file
+type LLMsLink struct {
+	Title       string
+	URL         string
+	Description string
+	Section     string
+	// Optional is set for links in the "Optional" section, which the spec
+	// marks as safe to skip when a shorter context is needed
+	Optional bool
+}
+
+// LLMsTxtDiscovery holds the llms.txt files published by a site
+type LLMsTxtDiscovery struct {
+	IndexURL string // URL of llms.txt, empty if not published
+	FullURL  string // URL of llms-full.txt, empty if not published
+}
+
+// Found reports whether the site publishes any llms.txt file
+func (d LLMsTxtDiscovery) Found() bool {
+	return d.IndexURL != "" || d.FullURL != ""
+}
+
+// Preferred returns the most useful file to use in place of the page itself.
+// llms-full.txt is preferred because it already contains the full documentation.
+func (d LLMsTxtDiscovery) Preferred() string {
+	if d.FullURL != "" {
+		return d.FullURL
+	}
+	return d.IndexURL
+}
+
+// IsLLMsTxtURL reports whether the URL points at an llms.txt or llms-full.txt file
+func IsLLMsTxtURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(u.Path, "/llms.txt") || strings.HasSuffix(u.Path, "/llms-full.txt")
+}
+
+// DiscoverLLMsTxt checks the site root of a URL for llms.txt and llms-full.txt.
+// The checks are abandoned when ctx is cancelled.
+func (p *Parser) DiscoverLLMsTxt(ctx context.Context, rawURL string) (LLMsTxtDiscovery, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return LLMsTxtDiscovery{}, fmt.Errorf("failed to parse URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return LLMsTxtDiscovery{}, fmt.Errorf("unsupported URL scheme: %s", base.Scheme)
+	}
+
+	root := &url.URL{Scheme: base.Scheme, Host: base.Host}
+
+	var discovery LLMsTxtDiscovery
+	if candidate := root.JoinPath("llms-full.txt").String(); p.llmsTxtExists(ctx, candidate) {
+		discovery.FullURL = candidate
+	}
+	if candidate := root.JoinPath("llms.txt").String(); p.llmsTxtExists(ctx, candidate) {
+		discovery.IndexURL = candidate
+	}
+
+	// A cancelled check looks like a missing file, so don't report it as one
+	if err := ctx.Err(); err != nil {
+		return LLMsTxtDiscovery{}, err
+	}
+	return discovery, nil
+}
+
+// llmsTxtExists reports whether a candidate llms.txt URL serves a plain text file.
+// Single page apps often answer every path with their HTML shell, so HTML
+// responses are not treated as a published llms.txt.
+func (p *Parser) llmsTxtExists(ctx context.Context, candidate string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, candidate, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	return !strings.Contains(resp.Header.Get("Content-Type"), "text/html")
+}
+
+// FetchLLMsTxt fetches and parses an llms.txt file, resolving relative links
+func (p *Parser) FetchLLMsTxt(ctx context.Context, rawURL string) (*LLMsTxt, error) {
+	content, err := p.ParseURLContext(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseLLMsTxt(content, rawURL)
+}
+
+// ParseLLMsTxt parses llms.txt markdown content. Relative links are resolved
+// against baseURL.
+func ParseLLMsTxt(content, baseURL string) (*LLMsTxt, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+
+	doc := &LLMsTxt{}
+	var summary []string
+	var section string
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "# ") && doc.Title == "":
+			doc.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		case strings.HasPrefix(line, "## "):
+			section = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+		case strings.HasPrefix(line, ">") && section == "":
+			summary = append(summary, strings.TrimSpace(strings.TrimPrefix(line, ">")))
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			link, ok := parseLLMsLink(line[2:])
+			if !ok {
+				continue
+			}
+			ref, err := url.Parse(link.URL)
+			if err != nil {
+				continue
+			}
+			link.URL = base.ResolveReference(ref).String()
+			link.Section = section
+			link.Optional = strings.EqualFold(section, "Optional")
+			doc.Links = append(doc.Links, link)
+		}
+	}
+
+	doc.Summary = strings.Join(summary, " ")
+	return doc, nil
+}
+
+// parseLLMsLink parses a list item of the form "[title](url): description"
+func parseLLMsLink(item string) (LLMsLink, bool) {
+	item = strings.TrimSpace(item)
+	if !strings.HasPrefix(item, "[") {
+		return LLMsLink{}, false
+	}
+
+	titleEnd := strings.Index(item, "](")
+	if titleEnd < 0 {
+		return LLMsLink{}, false
+	}
+	urlEnd := strings.Index(item[titleEnd+2:], ")")
+	if urlEnd < 0 {
+		return LLMsLink{}, false
+	}
+	urlEnd += titleEnd + 2
+
+	link := LLMsLink{
+		Title: strings.TrimSpace(item[1:titleEnd]),
+		URL:   strings.TrimSpace(item[titleEnd+2 : urlEnd]),
+	}
+	if link.URL == "" {
+		return LLMsLink{}, false
+	}
+
+	rest := strings.TrimSpace(item[urlEnd+1:])
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+	link.Description = rest
+
+	return link, true
+}
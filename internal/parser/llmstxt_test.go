@@ -0,0 +1,181 @@
+package parser_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+)
+
+func TestParseLLMsTxt(t *testing.T) {
+	content := `# Example Docs
+
+> Example is a library for doing examples.
+
+Some extra notes.
+
+## Docs
+
+- [Quick start](/docs/quickstart.md): Getting started in five minutes
+- [API reference](https://example.com/docs/api.md)
+
+## Optional
+
+- [Changelog](changelog.md)
+`
+
+	doc, err := parser.ParseLLMsTxt(content, "https://example.com/llms.txt")
+	if err != nil {
+		t.Fatalf("failed to parse llms.txt: %v", err)
+	}
+
+	if doc.Title != "Example Docs" {
+		t.Errorf("expected title 'Example Docs', got %q", doc.Title)
+	}
+
+	if doc.Summary != "Example is a library for doing examples." {
+		t.Errorf("unexpected summary %q", doc.Summary)
+	}
+
+	expected := []parser.LLMsLink{
+		{
+			Title:       "Quick start",
+			URL:         "https://example.com/docs/quickstart.md",
+			Description: "Getting started in five minutes",
+			Section:     "Docs",
+		},
+		{
+			Title:   "API reference",
+			URL:     "https://example.com/docs/api.md",
+			Section: "Docs",
+		},
+		{
+			Title:    "Changelog",
+			URL:      "https://example.com/changelog.md",
+			Section:  "Optional",
+			Optional: true,
+		},
+	}
+
+	if len(doc.Links) != len(expected) {
+		t.Fatalf("expected %d links, got %d: %+v", len(expected), len(doc.Links), doc.Links)
+	}
+
+	for i, want := range expected {
+		if doc.Links[i] != want {
+			t.Errorf("link %d: expected %+v, got %+v", i, want, doc.Links[i])
+		}
+	}
+}
+
+func TestParser_DiscoverLLMsTxt(t *testing.T) {
+	tests := []struct {
+		name      string
+		routes    map[string]string // path -> content type
+		wantIndex bool
+		wantFull  bool
+	}{
+		{
+			name:      "both files published",
+			routes:    map[string]string{"/llms.txt": "text/plain", "/llms-full.txt": "text/plain"},
+			wantIndex: true,
+			wantFull:  true,
+		},
+		{
+			name:      "index only",
+			routes:    map[string]string{"/llms.txt": "text/markdown"},
+			wantIndex: true,
+		},
+		{
+			name:   "html fallback is ignored",
+			routes: map[string]string{"/llms.txt": "text/html; charset=utf-8"},
+		},
+		{
+			name:   "not published",
+			routes: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType, ok := tt.routes[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", contentType)
+				w.Write([]byte("# Docs\n"))
+			}))
+			defer server.Close()
+
+			p := parser.NewParser(10 * 1024 * 1024)
+
+			discovery, err := p.DiscoverLLMsTxt(context.Background(), server.URL+"/docs/getting-started")
+			if err != nil {
+				t.Fatalf("failed to discover llms.txt: %v", err)
+			}
+
+			if got := discovery.IndexURL != ""; got != tt.wantIndex {
+				t.Errorf("expected index found=%v, got %q", tt.wantIndex, discovery.IndexURL)
+			}
+			if got := discovery.FullURL != ""; got != tt.wantFull {
+				t.Errorf("expected full found=%v, got %q", tt.wantFull, discovery.FullURL)
+			}
+
+			if tt.wantFull && discovery.Preferred() != server.URL+"/llms-full.txt" {
+				t.Errorf("expected llms-full.txt to be preferred, got %q", discovery.Preferred())
+			}
+		})
+	}
+}
+
+func TestParser_DiscoverLLMsTxtCancel(t *testing.T) {
+	// The site never answers, so only cancelling ends the discovery
+	arrived := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	p := parser.NewParser(10 * 1024 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.DiscoverLLMsTxt(ctx, server.URL+"/docs")
+		done <- err
+	}()
+
+	<-arrived
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("discovery did not stop when cancelled")
+	}
+}
+
+func TestParser_FetchLLMsTxtCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# Docs\n\n- [Guide](/guide.md)\n"))
+	}))
+	defer server.Close()
+
+	p := parser.NewParser(10 * 1024 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := p.FetchLLMsTxt(ctx, server.URL+"/llms.txt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
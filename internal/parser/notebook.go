@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultNotebookOutputLimit is the number of characters kept from each cell
+// output when outputs are included and no limit is set
+const DefaultNotebookOutputLimit = 1000
+
+// notebook is the subset of the Jupyter nbformat 4 schema that we render
+type notebook struct {
+	NBFormat int `json:"nbformat"`
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	Cells []notebookCell `json:"cells"`
+}
+
+type notebookCell struct {
+	CellType string           `json:"cell_type"`
+	Source   multilineString  `json:"source"`
+	Outputs  []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                     `json:"output_type"`
+	Text       multilineString            `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	EName      string                     `json:"ename"`
+	EValue     string                     `json:"evalue"`
+}
+
+// multilineString is a notebook text field, stored either as a string or as
+// a list of lines
+type multilineString string
+
+func (m *multilineString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = multilineString(s)
+		return nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*m = multilineString(strings.Join(lines, ""))
+	return nil
+}
+
+// ParseNotebook renders a Jupyter notebook as markdown with fenced code cells.
+// Outputs are only included when enabled in opts, are truncated, and never
+// include images or other binary data.
+func (p *Parser) ParseNotebook(path string, opts SourceOptions) (string, error) {
+	content, err := p.readFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var nb notebook
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return "", fmt.Errorf("failed to parse notebook: %w", err)
+	}
+
+	if nb.NBFormat < 4 {
+		return "", fmt.Errorf("unsupported notebook format version %d", nb.NBFormat)
+	}
+
+	language := nb.Metadata.LanguageInfo.Name
+	if language == "" {
+		language = nb.Metadata.KernelSpec.Language
+	}
+
+	limit := opts.NotebookOutputLimit
+	if limit <= 0 {
+		limit = DefaultNotebookOutputLimit
+	}
+
+	var sb strings.Builder
+	for _, cell := range nb.Cells {
+		source := strings.TrimSpace(string(cell.Source))
+		if source == "" {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n\n")
+		}
+
+		switch cell.CellType {
+		case "markdown":
+			sb.WriteString(source)
+		case "code":
+			sb.WriteString("```" + language + "\n")
+			sb.WriteString(source)
+			sb.WriteString("\n```")
+
+			if opts.NotebookOutputs {
+				writeNotebookOutputs(&sb, cell.Outputs, limit)
+			}
+		default:
+			// Raw cells are passed through unrendered
+			sb.WriteString("```\n")
+			sb.WriteString(source)
+			sb.WriteString("\n```")
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// writeNotebookOutputs appends the text outputs of a code cell
+func writeNotebookOutputs(sb *strings.Builder, outputs []notebookOutput, limit int) {
+	for _, output := range outputs {
+		var text string
+
+		switch output.OutputType {
+		case "stream":
+			text = string(output.Text)
+		case "execute_result", "display_data":
+			// Only plain text representations are kept; images, HTML and
+			// other rich data are dropped
+			raw, ok := output.Data["text/plain"]
+			if !ok {
+				continue
+			}
+			var plain multilineString
+			if err := json.Unmarshal(raw, &plain); err != nil {
+				continue
+			}
+			text = string(plain)
+		case "error":
+			text = fmt.Sprintf("%s: %s", output.EName, output.EValue)
+		}
+
+		text = strings.TrimRight(text, "\n")
+		if text == "" {
+			continue
+		}
+
+		sb.WriteString("\n\nOutput:\n```\n")
+		sb.WriteString(truncateOutput(text, limit))
+		sb.WriteString("\n```")
+	}
+}
+
+// truncateOutput shortens text to limit characters, noting how much was cut
+func truncateOutput(text string, limit int) string {
+	count := utf8.RuneCountInString(text)
+	if count <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+	return fmt.Sprintf("%s\n... (%d more characters truncated)", string(runes[:limit]), count-limit)
+}
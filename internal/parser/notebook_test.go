@@ -0,0 +1,106 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+)
+
+const testNotebook = `{
+  "nbformat": 4,
+  "nbformat_minor": 5,
+  "metadata": {
+    "kernelspec": {"name": "python3", "language": "python"},
+    "language_info": {"name": "python"}
+  },
+  "cells": [
+    {"cell_type": "markdown", "metadata": {}, "source": ["# Loading data\n", "Read the CSV first."]},
+    {
+      "cell_type": "code", "metadata": {}, "execution_count": 1,
+      "source": "import pandas as pd\ndf = pd.read_csv('data.csv')\nprint(len(df))",
+      "outputs": [
+        {"output_type": "stream", "name": "stdout", "text": ["12345678901234567890\n"]}
+      ]
+    },
+    {
+      "cell_type": "code", "metadata": {}, "execution_count": 2,
+      "source": ["df.plot()"],
+      "outputs": [
+        {
+          "output_type": "display_data", "metadata": {},
+          "data": {"image/png": "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk", "text/plain": ["<Figure size 640x480>"]}
+        }
+      ]
+    },
+    {"cell_type": "code", "metadata": {}, "source": [], "outputs": []}
+  ]
+}`
+
+func writeTestNotebook(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "analysis.ipynb")
+	if err := os.WriteFile(path, []byte(testNotebook), 0644); err != nil {
+		t.Fatalf("failed to create test notebook: %v", err)
+	}
+	return path
+}
+
+func TestParser_ParseNotebook(t *testing.T) {
+	path := writeTestNotebook(t)
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParseNotebook(path, parser.SourceOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse notebook: %v", err)
+	}
+
+	expected := "# Loading data\nRead the CSV first.\n\n" +
+		"```python\nimport pandas as pd\ndf = pd.read_csv('data.csv')\nprint(len(df))\n```\n\n" +
+		"```python\ndf.plot()\n```"
+
+	if content != expected {
+		t.Errorf("unexpected notebook rendering:\n%s\nwant:\n%s", content, expected)
+	}
+}
+
+func TestParser_ParseNotebook_Outputs(t *testing.T) {
+	path := writeTestNotebook(t)
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParseNotebook(path, parser.SourceOptions{
+		NotebookOutputs:     true,
+		NotebookOutputLimit: 10,
+	})
+	if err != nil {
+		t.Fatalf("failed to parse notebook: %v", err)
+	}
+
+	if !strings.Contains(content, "Output:\n```\n1234567890\n... (10 more characters truncated)\n```") {
+		t.Errorf("expected truncated stream output, got:\n%s", content)
+	}
+
+	if !strings.Contains(content, "<Figure si") {
+		t.Errorf("expected plain text representation of display data, got:\n%s", content)
+	}
+
+	if strings.Contains(content, "iVBORw0KGgo") {
+		t.Error("expected embedded images to be dropped")
+	}
+}
+
+func TestParser_ParseNotebook_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.ipynb")
+	if err := os.WriteFile(path, []byte(`{"nbformat": 3, "worksheets": []}`), 0644); err != nil {
+		t.Fatalf("failed to create test notebook: %v", err)
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	if _, err := p.ParseNotebook(path, parser.SourceOptions{}); err == nil {
+		t.Error("expected error for unsupported notebook format, got nil")
+	}
+}
@@ -0,0 +1,520 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMethods lists HTTP methods in the order operations are rendered
+var openAPIMethods = []string{"get", "put", "post", "patch", "delete", "head", "options", "trace"}
+
+// maxSchemaDepth limits how deeply inline schemas are expanded
+const maxSchemaDepth = 3
+
+// ParseOpenAPI condenses an OpenAPI 3 or Swagger 2 document, from a local file
+// or URL, into an endpoint-by-endpoint markdown summary. Operations can be
+// filtered by tag and path prefix through opts.
+func (p *Parser) ParseOpenAPI(path string, opts SourceOptions) (string, error) {
+	return p.parseOpenAPI(context.Background(), path, opts)
+}
+
+func (p *Parser) parseOpenAPI(ctx context.Context, path string, opts SourceOptions) (string, error) {
+	var content []byte
+	if IsURL(path) {
+		body, _, err := p.fetchURL(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		content = body
+	} else {
+		body, err := p.readFile(path)
+		if err != nil {
+			return "", err
+		}
+		content = body
+	}
+
+	// YAML is a superset of JSON, so one decoder handles both encodings
+	var raw any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return "", fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+
+	doc, ok := normalizeYAML(raw).(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("failed to parse OpenAPI document: expected an object")
+	}
+
+	if stringField(doc, "openapi") == "" && stringField(doc, "swagger") == "" {
+		return "", fmt.Errorf("not an OpenAPI document: missing openapi or swagger version")
+	}
+
+	r := &openAPIRenderer{doc: doc, referenced: make(map[string]bool)}
+	return r.render(opts), nil
+}
+
+// isOpenAPIFileName reports whether a file name looks like an OpenAPI or
+// Swagger document, e.g. openapi.yaml or swagger.json
+func isOpenAPIFileName(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(base)
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return false
+	}
+	return strings.HasPrefix(base, "openapi") || strings.HasPrefix(base, "swagger")
+}
+
+// normalizeYAML converts map[any]any values produced for non-string keys,
+// such as numeric response codes, into map[string]any
+func normalizeYAML(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			v[key] = normalizeYAML(val)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = normalizeYAML(val)
+		}
+		return m
+	case []any:
+		for i, val := range v {
+			v[i] = normalizeYAML(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// openAPIRenderer renders a decoded OpenAPI document as markdown
+type openAPIRenderer struct {
+	doc map[string]any
+	// referenced tracks named schemas used by rendered operations, so only
+	// those are listed in the schemas section
+	referenced map[string]bool
+}
+
+func (r *openAPIRenderer) render(opts SourceOptions) string {
+	var sb strings.Builder
+
+	info := mapField(r.doc, "info")
+	title := stringField(info, "title")
+	if title == "" {
+		title = "API"
+	}
+	if version := stringField(info, "version"); version != "" {
+		title += " " + version
+	}
+	sb.WriteString("# " + title + "\n")
+
+	if description := firstParagraph(stringField(info, "description")); description != "" {
+		sb.WriteString("\n" + description + "\n")
+	}
+
+	if baseURL := r.baseURL(); baseURL != "" {
+		sb.WriteString("\nBase URL: " + baseURL + "\n")
+	}
+
+	paths := mapField(r.doc, "paths")
+	for _, path := range sortedKeys(paths) {
+		if !matchesPathPrefix(path, opts.OpenAPIPathPrefixes) {
+			continue
+		}
+
+		item := r.resolve(paths[path])
+		pathParams := sliceField(item, "parameters")
+
+		for _, method := range openAPIMethods {
+			op, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			if !matchesTags(op, opts.OpenAPITags) {
+				continue
+			}
+
+			r.renderOperation(&sb, method, path, op, pathParams)
+		}
+	}
+
+	r.renderSchemas(&sb)
+
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// baseURL returns the first server URL (OpenAPI 3) or host and basePath (Swagger 2)
+func (r *openAPIRenderer) baseURL() string {
+	if servers := sliceField(r.doc, "servers"); len(servers) > 0 {
+		if server, ok := servers[0].(map[string]any); ok {
+			return stringField(server, "url")
+		}
+	}
+
+	host := stringField(r.doc, "host")
+	if host == "" {
+		return stringField(r.doc, "basePath")
+	}
+
+	scheme := "https"
+	if schemes := sliceField(r.doc, "schemes"); len(schemes) > 0 {
+		scheme = fmt.Sprint(schemes[0])
+	}
+	return scheme + "://" + host + stringField(r.doc, "basePath")
+}
+
+func (r *openAPIRenderer) renderOperation(sb *strings.Builder, method, path string, op map[string]any, pathParams []any) {
+	sb.WriteString(fmt.Sprintf("\n## %s %s\n", strings.ToUpper(method), path))
+
+	if summary := stringField(op, "summary"); summary != "" {
+		sb.WriteString(summary + "\n")
+	}
+	if description := firstParagraph(stringField(op, "description")); description != "" && description != stringField(op, "summary") {
+		sb.WriteString(description + "\n")
+	}
+	if tags := sliceField(op, "tags"); len(tags) > 0 {
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = fmt.Sprint(tag)
+		}
+		sb.WriteString("Tags: " + strings.Join(names, ", ") + "\n")
+	}
+	if deprecated, _ := op["deprecated"].(bool); deprecated {
+		sb.WriteString("Deprecated\n")
+	}
+
+	// Operation parameters override path-level parameters with the same name and location
+	var params []map[string]any
+	seen := make(map[string]bool)
+	for _, list := range [][]any{sliceField(op, "parameters"), pathParams} {
+		for _, raw := range list {
+			param := r.resolve(raw)
+			key := stringField(param, "in") + ":" + stringField(param, "name")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			params = append(params, param)
+		}
+	}
+
+	var bodySchema string
+	if len(params) > 0 {
+		var lines []string
+		for _, param := range params {
+			in := stringField(param, "in")
+			if in == "body" {
+				// Swagger 2 request bodies are parameters
+				bodySchema = r.schema(param["schema"], 0)
+				continue
+			}
+			lines = append(lines, r.parameter(param))
+		}
+		if len(lines) > 0 {
+			sb.WriteString("Parameters:\n")
+			for _, line := range lines {
+				sb.WriteString(line + "\n")
+			}
+		}
+	}
+
+	if requestBody := r.resolve(op["requestBody"]); len(requestBody) > 0 {
+		mediaType, schema := r.content(requestBody)
+		required := ""
+		if isRequired, _ := requestBody["required"].(bool); isRequired {
+			required = ", required"
+		}
+		sb.WriteString(fmt.Sprintf("Request body (%s%s): %s\n", mediaType, required, schema))
+	} else if bodySchema != "" {
+		sb.WriteString("Request body: " + bodySchema + "\n")
+	}
+
+	responses := mapField(op, "responses")
+	if len(responses) > 0 {
+		sb.WriteString("Responses:\n")
+		for _, code := range sortedKeys(responses) {
+			response := r.resolve(responses[code])
+
+			line := "- " + code
+			if description := firstLine(stringField(response, "description")); description != "" {
+				line += " " + description
+			}
+
+			// OpenAPI 3 nests schemas under content, Swagger 2 puts them on the response
+			schema := ""
+			if _, ok := response["content"]; ok {
+				_, schema = r.content(response)
+			} else if raw, ok := response["schema"]; ok {
+				schema = r.schema(raw, 0)
+			}
+			if schema != "" {
+				line += ": " + schema
+			}
+
+			sb.WriteString(line + "\n")
+		}
+	}
+}
+
+// parameter renders a single parameter as a list item
+func (r *openAPIRenderer) parameter(param map[string]any) string {
+	// OpenAPI 3 parameters have a schema, Swagger 2 parameters are inline schemas
+	schemaType := ""
+	if raw, ok := param["schema"]; ok {
+		schemaType = r.schema(raw, maxSchemaDepth)
+	} else {
+		schemaType = r.schema(param, maxSchemaDepth)
+	}
+
+	details := []string{stringField(param, "in")}
+	if schemaType != "" {
+		details = append(details, schemaType)
+	}
+	if required, _ := param["required"].(bool); required {
+		details = append(details, "required")
+	}
+
+	line := fmt.Sprintf("- `%s` (%s)", stringField(param, "name"), strings.Join(details, ", "))
+	if description := firstLine(stringField(param, "description")); description != "" {
+		line += ": " + description
+	}
+	return line
+}
+
+// content returns the preferred media type and its schema from an OpenAPI 3
+// request body or response
+func (r *openAPIRenderer) content(obj map[string]any) (string, string) {
+	content := mapField(obj, "content")
+	if len(content) == 0 {
+		return "", ""
+	}
+
+	mediaTypes := sortedKeys(content)
+	mediaType := mediaTypes[0]
+	for _, candidate := range mediaTypes {
+		if strings.Contains(candidate, "json") {
+			mediaType = candidate
+			break
+		}
+	}
+
+	media, _ := content[mediaType].(map[string]any)
+	return mediaType, r.schema(media["schema"], 0)
+}
+
+// schema renders a schema as a compact type expression
+func (r *openAPIRenderer) schema(raw any, depth int) string {
+	schema, ok := raw.(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	if ref := stringField(schema, "$ref"); ref != "" {
+		name := ref[strings.LastIndex(ref, "/")+1:]
+		r.referenced[name] = true
+		return name
+	}
+
+	for _, combinator := range []struct {
+		key string
+		sep string
+	}{{"allOf", " & "}, {"oneOf", " | "}, {"anyOf", " | "}} {
+		if variants := sliceField(schema, combinator.key); len(variants) > 0 {
+			parts := make([]string, 0, len(variants))
+			for _, variant := range variants {
+				parts = append(parts, r.schema(variant, depth))
+			}
+			return strings.Join(parts, combinator.sep)
+		}
+	}
+
+	schemaType := stringField(schema, "type")
+	if _, ok := schema["properties"]; ok && schemaType == "" {
+		schemaType = "object"
+	}
+
+	switch schemaType {
+	case "array":
+		return "[]" + r.schema(schema["items"], depth)
+	case "object":
+		properties := mapField(schema, "properties")
+		if len(properties) == 0 {
+			if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				return "map[string]" + r.schema(additional, depth)
+			}
+			return "object"
+		}
+		if depth >= maxSchemaDepth {
+			return "{...}"
+		}
+
+		required := make(map[string]bool)
+		for _, name := range sliceField(schema, "required") {
+			required[fmt.Sprint(name)] = true
+		}
+
+		fields := make([]string, 0, len(properties))
+		for _, name := range sortedKeys(properties) {
+			optional := "?"
+			if required[name] {
+				optional = ""
+			}
+			fields = append(fields, fmt.Sprintf("%s%s: %s", name, optional, r.schema(properties[name], depth+1)))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	default:
+		if schemaType == "" {
+			schemaType = "any"
+		}
+		if format := stringField(schema, "format"); format != "" {
+			schemaType += "(" + format + ")"
+		}
+		if enum := sliceField(schema, "enum"); len(enum) > 0 {
+			values := make([]string, len(enum))
+			for i, value := range enum {
+				values[i] = fmt.Sprint(value)
+			}
+			schemaType += " enum: " + strings.Join(values, "|")
+		}
+		return schemaType
+	}
+}
+
+// renderSchemas lists the named schemas referenced by rendered operations,
+// including schemas they reference in turn
+func (r *openAPIRenderer) renderSchemas(sb *strings.Builder) {
+	definitions := mapField(mapField(r.doc, "components"), "schemas")
+	if len(definitions) == 0 {
+		definitions = mapField(r.doc, "definitions")
+	}
+
+	rendered := make(map[string]string)
+	for {
+		pending := false
+		for _, name := range sortedKeys(r.referenced) {
+			if _, done := rendered[name]; done {
+				continue
+			}
+			pending = true
+			rendered[name] = r.schema(definitions[name], 0)
+		}
+		if !pending {
+			break
+		}
+	}
+
+	if len(rendered) == 0 {
+		return
+	}
+
+	sb.WriteString("\n## Schemas\n")
+	for _, name := range sortedKeys(rendered) {
+		if rendered[name] == "" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", name, rendered[name]))
+	}
+}
+
+// resolve follows a local $ref such as #/components/parameters/Limit
+func (r *openAPIRenderer) resolve(raw any) map[string]any {
+	obj, _ := raw.(map[string]any)
+	for range 10 {
+		ref := stringField(obj, "$ref")
+		if !strings.HasPrefix(ref, "#/") {
+			return obj
+		}
+
+		var current any = r.doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			m, _ := current.(map[string]any)
+			current = m[part]
+		}
+		obj, _ = current.(map[string]any)
+	}
+	return obj
+}
+
+// matchesTags reports whether an operation has one of the given tags
+func matchesTags(op map[string]any, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, opTag := range sliceField(op, "tags") {
+		for _, tag := range tags {
+			if strings.EqualFold(fmt.Sprint(opTag), tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesPathPrefix reports whether a path starts with one of the given prefixes
+func matchesPathPrefix(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func mapField(obj map[string]any, key string) map[string]any {
+	m, _ := obj[key].(map[string]any)
+	return m
+}
+
+func sliceField(obj map[string]any, key string) []any {
+	s, _ := obj[key].([]any)
+	return s
+}
+
+func stringField(obj map[string]any, key string) string {
+	switch v := obj[key].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// firstParagraph returns text up to the first blank line, on one line
+func firstParagraph(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.Index(text, "\n\n"); i >= 0 {
+		text = text[:i]
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// firstLine returns the first line of text
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
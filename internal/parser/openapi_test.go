@@ -0,0 +1,248 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+)
+
+const testOpenAPI3 = `openapi: 3.0.3
+info:
+  title: Pet Store
+  version: 1.2.0
+  description: |
+    A sample pet store.
+
+    Long details that should be dropped.
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /pets:
+    get:
+      summary: List pets
+      tags: [pets]
+      parameters:
+        - $ref: '#/components/parameters/Limit'
+      responses:
+        200:
+          description: A list of pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+    post:
+      summary: Create a pet
+      tags: [pets]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewPet'
+      responses:
+        '201':
+          description: Created
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+          format: uuid
+    delete:
+      summary: Delete a user
+      tags: [users]
+      responses:
+        '204':
+          description: Deleted
+components:
+  parameters:
+    Limit:
+      name: limit
+      in: query
+      description: Maximum number of results
+      schema:
+        type: integer
+  schemas:
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/NewPet'
+        - type: object
+          required: [id]
+          properties:
+            id:
+              type: integer
+              format: int64
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        status:
+          type: string
+          enum: [available, sold]
+`
+
+const testSwagger2 = `{
+  "swagger": "2.0",
+  "info": {"title": "Legacy API", "version": "0.9"},
+  "host": "legacy.example.com",
+  "basePath": "/api",
+  "schemes": ["https"],
+  "paths": {
+    "/orders": {
+      "post": {
+        "summary": "Place an order",
+        "tags": ["orders"],
+        "parameters": [
+          {"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Order"}},
+          {"name": "dry_run", "in": "query", "type": "boolean"}
+        ],
+        "responses": {
+          "200": {"description": "OK", "schema": {"$ref": "#/definitions/Order"}}
+        }
+      }
+    }
+  },
+  "definitions": {
+    "Order": {"type": "object", "properties": {"quantity": {"type": "integer"}}}
+  }
+}`
+
+func writeSpec(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create test spec: %v", err)
+	}
+	return path
+}
+
+func TestParser_ParseOpenAPI_V3(t *testing.T) {
+	path := writeSpec(t, "openapi.yaml", testOpenAPI3)
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParseOpenAPI(path, parser.SourceOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse OpenAPI spec: %v", err)
+	}
+
+	for _, want := range []string{
+		"# Pet Store 1.2.0",
+		"A sample pet store.",
+		"Base URL: https://api.example.com/v1",
+		"## GET /pets\nList pets\nTags: pets\n",
+		"- `limit` (query, integer): Maximum number of results",
+		"- 200 A list of pets: []Pet",
+		"## POST /pets",
+		"Request body (application/json, required): NewPet",
+		"## DELETE /users/{id}",
+		"- `id` (path, string(uuid), required)",
+		"## Schemas",
+		"- NewPet: {name: string, status?: string enum: available|sold}",
+		"- Pet: NewPet & {id: integer(int64)}",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	if strings.Contains(content, "Long details") {
+		t.Error("expected only the first paragraph of the description")
+	}
+
+	// Output must be stable so refreshes don't see spurious changes
+	again, err := p.ParseOpenAPI(path, parser.SourceOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse OpenAPI spec: %v", err)
+	}
+	if again != content {
+		t.Error("expected identical output across runs")
+	}
+}
+
+func TestParser_ParseOpenAPI_Filters(t *testing.T) {
+	path := writeSpec(t, "openapi.yaml", testOpenAPI3)
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	tests := []struct {
+		name     string
+		opts     parser.SourceOptions
+		included []string
+		excluded []string
+	}{
+		{
+			name:     "by tag",
+			opts:     parser.SourceOptions{OpenAPITags: []string{"Users"}},
+			included: []string{"## DELETE /users/{id}"},
+			excluded: []string{"## GET /pets", "## Schemas"},
+		},
+		{
+			name:     "by path prefix",
+			opts:     parser.SourceOptions{OpenAPIPathPrefixes: []string{"/pets"}},
+			included: []string{"## GET /pets", "## POST /pets", "- Pet:"},
+			excluded: []string{"/users"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := p.ParseOpenAPI(path, tt.opts)
+			if err != nil {
+				t.Fatalf("failed to parse OpenAPI spec: %v", err)
+			}
+
+			for _, want := range tt.included {
+				if !strings.Contains(content, want) {
+					t.Errorf("expected %q in:\n%s", want, content)
+				}
+			}
+			for _, unwanted := range tt.excluded {
+				if strings.Contains(content, unwanted) {
+					t.Errorf("expected %q to be filtered out of:\n%s", unwanted, content)
+				}
+			}
+		})
+	}
+}
+
+func TestParser_ParseOpenAPI_Swagger2(t *testing.T) {
+	path := writeSpec(t, "swagger.json", testSwagger2)
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParseOpenAPI(path, parser.SourceOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse Swagger spec: %v", err)
+	}
+
+	for _, want := range []string{
+		"# Legacy API 0.9",
+		"Base URL: https://legacy.example.com/api",
+		"## POST /orders",
+		"- `dry_run` (query, boolean)",
+		"Request body: Order",
+		"- 200 OK: Order",
+		"- Order: {quantity?: integer}",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestParser_ParseOpenAPI_NotASpec(t *testing.T) {
+	path := writeSpec(t, "config.yaml", "name: not a spec\n")
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	if _, err := p.ParseOpenAPI(path, parser.SourceOptions{}); err == nil {
+		t.Error("expected error for document without openapi version, got nil")
+	}
+}
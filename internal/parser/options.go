@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SourceOptions holds per-source parsing options. They are stored as JSON
+// with each source so that refreshes parse content the same way as the
+// original add.
+type SourceOptions struct {
+	// NotebookOutputs includes code cell outputs in notebook sources
+	NotebookOutputs bool `json:"notebook_outputs,omitempty"`
+	// NotebookOutputLimit truncates each cell output to this many characters
+	NotebookOutputLimit int `json:"notebook_output_limit,omitempty"`
+	// OpenAPITags limits OpenAPI sources to operations with one of these tags
+	OpenAPITags []string `json:"openapi_tags,omitempty"`
+	// OpenAPIPathPrefixes limits OpenAPI sources to paths with one of these prefixes
+	OpenAPIPathPrefixes []string `json:"openapi_path_prefixes,omitempty"`
+	// RedactAllow exempts content from secret redaction: rule names or
+	// regular expressions matching the text to keep
+	RedactAllow []string `json:"redact_allow,omitempty"`
+}
+
+// DecodeOptions parses stored source options. Empty input yields the defaults.
+func DecodeOptions(raw string) (SourceOptions, error) {
+	var opts SourceOptions
+	if raw == "" {
+		return opts, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &opts); err != nil {
+		return opts, fmt.Errorf("failed to parse source options: %w", err)
+	}
+
+	return opts, nil
+}
+
+// Encode serializes options for storage
+func (o SourceOptions) Encode() string {
+	data, err := json.Marshal(o)
+	if err != nil {
+		// Marshalling a struct of plain fields cannot fail
+		return "{}"
+	}
+	return string(data)
+}
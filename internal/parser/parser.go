@@ -1,10 +1,13 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -27,62 +30,194 @@ func NewParser(maxFileSize int64) *Parser {
 	}
 }
 
+// IsURL reports whether a source path is an HTTP(S) URL rather than a local path
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// DetectSourceType infers the source type for a path or URL from its file name
+func DetectSourceType(path string) string {
+	name := path
+	if IsURL(path) {
+		if u, err := neturl.Parse(path); err == nil {
+			name = u.Path
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+
+	if isOpenAPIFileName(name) {
+		return "openapi"
+	}
+
+	if IsURL(path) {
+		if ext == ".pdf" {
+			return "pdf"
+		}
+		return "url"
+	}
+
+	switch ext {
+	case ".pdf":
+		return "pdf"
+	case ".ipynb":
+		return "notebook"
+	default:
+		return "file"
+	}
+}
+
+// ParseSource extracts the content of a path or URL as the given source type
+func (p *Parser) ParseSource(sourceType, path string, opts SourceOptions) (string, error) {
+	return p.ParseSourceContext(context.Background(), sourceType, path, opts)
+}
+
+// ParseSourceContext is ParseSource with a context that aborts downloads
+func (p *Parser) ParseSourceContext(ctx context.Context, sourceType, path string, opts SourceOptions) (string, error) {
+	switch sourceType {
+	case "url", "bookmark":
+		content, err := p.ParseURLContext(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse URL: %w", err)
+		}
+		return content, nil
+	case "file":
+		content, err := p.ParseFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse file: %w", err)
+		}
+		return content, nil
+	case "pdf":
+		content, err := p.parsePDF(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse PDF: %w", err)
+		}
+		return content, nil
+	case "notebook":
+		content, err := p.ParseNotebook(path, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse notebook: %w", err)
+		}
+		return content, nil
+	case "openapi":
+		content, err := p.parseOpenAPI(ctx, path, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+		}
+		return content, nil
+	default:
+		return "", fmt.Errorf("unsupported source type: %s", sourceType)
+	}
+}
+
 // ParseFile reads and returns content from a local file
 func (p *Parser) ParseFile(path string) (string, error) {
+	content, err := p.readFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if isPDF(content, "") {
+		return extractPDFText(content)
+	}
+
+	// Reject binaries rather than dumping them into a markdown fence
+	if mimeType, binary := sniffContent(content); binary {
+		return binaryContent(path, content, mimeType)
+	}
+
+	return decodeToUTF8(content, "")
+}
+
+// readFile reads a local file, enforcing the maximum file size
+func (p *Parser) readFile(path string) ([]byte, error) {
 	// Check file size
 	info, err := os.Stat(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to stat file: %w", err)
+		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
 	if info.Size() > p.maxFileSize {
-		return "", fmt.Errorf("file size %d exceeds max size %d", info.Size(), p.maxFileSize)
+		return nil, fmt.Errorf("file size %d exceeds max size %d", info.Size(), p.maxFileSize)
 	}
 
 	// Read file content
 	content, err := os.ReadFile(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	return string(content), nil
+	return content, nil
 }
 
 // ParseURL fetches and extracts text content from a URL
 func (p *Parser) ParseURL(url string) (string, error) {
-	resp, err := p.httpClient.Get(url)
+	return p.ParseURLContext(context.Background(), url)
+}
+
+// ParseURLContext is ParseURL with a context that aborts the download
+func (p *Parser) ParseURLContext(ctx context.Context, url string) (string, error) {
+	body, contentType, err := p.fetchURL(ctx, url)
+	if err != nil {
+		return "", err
+	}
+
+	// PDFs are converted to text page by page
+	if isPDF(body, contentType) {
+		return extractPDFText(body)
+	}
+
+	if mimeType, binary := sniffContent(body); binary {
+		return binaryContent(url, body, mimeType)
+	}
+
+	// Normalize to UTF-8 before any further processing
+	text, err := decodeToUTF8(body, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	// If it's HTML, extract text content
+	if strings.Contains(contentType, "text/html") {
+		return p.extractTextFromHTML(text)
+	}
+
+	return text, nil
+}
+
+// fetchURL downloads a URL and returns its body and Content-Type header
+func (p *Parser) fetchURL(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, "", fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP error: %s", resp.Status)
+		return nil, "", fmt.Errorf("HTTP error: %s", resp.Status)
 	}
 
 	// Check content length
 	if resp.ContentLength > p.maxFileSize {
-		return "", fmt.Errorf("content length %d exceeds max size %d", resp.ContentLength, p.maxFileSize)
+		return nil, "", fmt.Errorf("content length %d exceeds max size %d", resp.ContentLength, p.maxFileSize)
 	}
 
 	// Read body with size limit
 	limitedReader := io.LimitReader(resp.Body, p.maxFileSize+1)
 	body, err := io.ReadAll(limitedReader)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if int64(len(body)) > p.maxFileSize {
-		return "", fmt.Errorf("response body exceeds max size %d", p.maxFileSize)
-	}
-
-	// If it's HTML, extract text content
-	contentType := resp.Header.Get("Content-Type")
-	if strings.Contains(contentType, "text/html") {
-		return p.extractTextFromHTML(string(body))
+		return nil, "", fmt.Errorf("response body exceeds max size %d", p.maxFileSize)
 	}
 
-	return string(body), nil
+	return body, resp.Header.Get("Content-Type"), nil
 }
 
 // extractTextFromHTML extracts readable text from HTML content with semantic structure
@@ -262,9 +397,9 @@ func (p *Parser) ParseBookmarkHTML(path string) ([]Bookmark, error) {
 	}
 
 	var bookmarks []Bookmark
-	var extract func(*html.Node)
+	var extract func(*html.Node, []string)
 
-	extract = func(n *html.Node) {
+	extract = func(n *html.Node, folders []string) {
 		if n.Type == html.ElementNode && n.Data == "a" {
 			var href, title string
 			for _, attr := range n.Attr {
@@ -278,18 +413,30 @@ func (p *Parser) ParseBookmarkHTML(path string) ([]Bookmark, error) {
 
 			if href != "" && title != "" {
 				bookmarks = append(bookmarks, Bookmark{
-					Title: title,
-					URL:   href,
+					Title:  title,
+					URL:    href,
+					Folder: joinFolder(folders),
 				})
 			}
 		}
 
+		// Folders are exported as <DT><H3>name</H3><DL>...</DL>, so the
+		// heading names everything nested under the same <DT>
+		if n.Type == html.ElementNode && n.Data == "dt" {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && c.Data == "h3" {
+					folders = append(folders[:len(folders):len(folders)], strings.TrimSpace(nodeText(c)))
+					break
+				}
+			}
+		}
+
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			extract(c)
+			extract(c, folders)
 		}
 	}
 
-	extract(doc)
+	extract(doc, nil)
 	return bookmarks, nil
 }
 
@@ -297,4 +444,6 @@ func (p *Parser) ParseBookmarkHTML(path string) ([]Bookmark, error) {
 type Bookmark struct {
 	Title string
 	URL   string
+	// Folder is the slash-separated folder path, e.g. "Bookmarks bar/Dev/Go"
+	Folder string
 }
@@ -106,5 +106,28 @@ func TestParser_ParseURL_HTML(t *testing.T) {
 	}
 }
 
+func TestDetectSourceType(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/docs/readme.md", "file"},
+		{"/docs/spec.PDF", "pdf"},
+		{"https://example.com/docs", "url"},
+		{"https://example.com/spec.pdf?download=1", "pdf"},
+		{"/notebooks/analysis.ipynb", "notebook"},
+		{"/specs/openapi.yaml", "openapi"},
+		{"https://example.com/swagger.json", "openapi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := parser.DetectSourceType(tt.path); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 // Note: extractTextFromHTML is tested indirectly through ParseURL
 // when fetching HTML pages
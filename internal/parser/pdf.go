@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// ParsePDF extracts text from a PDF, either a local file or a URL serving
+// application/pdf
+func (p *Parser) ParsePDF(path string) (string, error) {
+	return p.parsePDF(context.Background(), path)
+}
+
+func (p *Parser) parsePDF(ctx context.Context, path string) (string, error) {
+	var content []byte
+
+	if IsURL(path) {
+		body, contentType, err := p.fetchURL(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		if !isPDF(body, contentType) {
+			return "", fmt.Errorf("URL is not a PDF (Content-Type: %s)", contentType)
+		}
+		content = body
+	} else {
+		body, err := p.readFile(path)
+		if err != nil {
+			return "", err
+		}
+		content = body
+		if !isPDF(content, "") {
+			return "", fmt.Errorf("file is not a PDF: %s", path)
+		}
+	}
+
+	return extractPDFText(content)
+}
+
+// isPDF reports whether content is a PDF document, based on the Content-Type
+// header when there is one and the %PDF- magic number otherwise
+func isPDF(content []byte, contentType string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/pdf" {
+		return true
+	}
+	return bytes.HasPrefix(content, []byte("%PDF-"))
+}
+
+// extractPDFText extracts the text of each page, separated by page markers
+func extractPDFText(content []byte) (text string, err error) {
+	// The PDF reader panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			text = ""
+			err = fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse PDF: %w", err)
+	}
+
+	numPages := reader.NumPage()
+	fonts := make(map[string]*pdf.Font)
+
+	var sb strings.Builder
+	for i := 1; i <= numPages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+
+		// Cache fonts across pages so charmaps are only parsed once
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text from page %d: %w", i, err)
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("--- Page %d of %d ---\n", i, numPages))
+		sb.WriteString(strings.TrimSpace(pageText))
+	}
+
+	return strings.ToValidUTF8(sb.String(), "\ufffd"), nil
+}
@@ -0,0 +1,132 @@
+package parser_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+)
+
+// buildPDF builds a minimal PDF with one line of Helvetica text per page
+func buildPDF(t *testing.T, pages []string) []byte {
+	t.Helper()
+
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		// Objects 1 and 2 are the catalog and page tree, 3 is the font,
+		// then each page is followed by its content stream
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+	for i, text := range pages {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+func TestParser_ParsePDF_File(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "spec.pdf")
+	if err := os.WriteFile(testFile, buildPDF(t, []string{"Hello from page one", "Second page text"}), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	content, err := p.ParsePDF(testFile)
+	if err != nil {
+		t.Fatalf("failed to parse PDF: %v", err)
+	}
+
+	for _, want := range []string{
+		"--- Page 1 of 2 ---",
+		"Hello from page one",
+		"--- Page 2 of 2 ---",
+		"Second page text",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected content to contain %q, got %q", want, content)
+		}
+	}
+
+	if strings.Index(content, "Hello from page one") > strings.Index(content, "Second page text") {
+		t.Error("expected pages in order")
+	}
+}
+
+func TestParser_ParsePDF_URL(t *testing.T) {
+	doc := buildPDF(t, []string{"Vendor API specification"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(doc)
+	}))
+	defer server.Close()
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	// Both ParsePDF and ParseURL extract text from PDF responses
+	for name, parse := range map[string]func(string) (string, error){
+		"ParsePDF": p.ParsePDF,
+		"ParseURL": p.ParseURL,
+	} {
+		content, err := parse(server.URL + "/download?id=42")
+		if err != nil {
+			t.Fatalf("%s: failed to parse PDF URL: %v", name, err)
+		}
+
+		if !strings.Contains(content, "Vendor API specification") {
+			t.Errorf("%s: expected extracted text, got %q", name, content)
+		}
+
+		if strings.Contains(content, "%PDF-") {
+			t.Errorf("%s: expected raw PDF bytes to be excluded", name)
+		}
+	}
+}
+
+func TestParser_ParsePDF_NotPDF(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "fake.pdf")
+	if err := os.WriteFile(testFile, []byte("just text"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	p := parser.NewParser(10 * 1024 * 1024)
+
+	if _, err := p.ParsePDF(testFile); err == nil {
+		t.Error("expected error for non-PDF file, got nil")
+	}
+}
@@ -0,0 +1,226 @@
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Mode controls what generation does with secrets found in source content
+type Mode string
+
+const (
+	// ModeOff leaves content untouched
+	ModeOff Mode = "off"
+	// ModeMask replaces each secret with a [REDACTED:<rule>] marker
+	ModeMask Mode = "mask"
+	// ModeFail refuses to generate while any secret remains
+	ModeFail Mode = "fail"
+)
+
+// ParseMode parses a --redact value. Empty input yields ModeMask.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(s))) {
+	case "", ModeMask:
+		return ModeMask, nil
+	case ModeOff:
+		return ModeOff, nil
+	case ModeFail:
+		return ModeFail, nil
+	default:
+		return "", fmt.Errorf("unknown redact mode %q (want off, mask or fail)", s)
+	}
+}
+
+// Finding is a secret detected in content
+type Finding struct {
+	// Rule names the pattern that matched, e.g. "aws-access-key"
+	Rule string
+	// Line is the 1-based line the secret starts on
+	Line int
+	// Start and End are the byte offsets of the secret
+	Start, End int
+}
+
+// rule detects one kind of secret. Group selects the submatch to mask,
+// so surrounding context like a variable name can be kept.
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	group   int
+}
+
+// rules are checked in order; when matches overlap, the earlier rule wins
+var rules = []rule{
+	{name: "private-key", pattern: regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "aws-access-key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "google-api-key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},
+	{name: "slack-token", pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{name: "stripe-key", pattern: regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{16,}\b`)},
+	{name: "api-key", pattern: regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{20,}`)},
+	{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	// .env style assignments to secret-looking names; only the value is masked
+	{name: "env-secret", group: 1, pattern: regexp.MustCompile(
+		`(?m)^[ \t]*(?:export[ \t]+)?[A-Z0-9_]*(?:KEY|SECRET|TOKEN|PASSWORD|PASSWD|CREDENTIALS?)[A-Z0-9_]*[ \t]*[=:][ \t]*(["']?[^\s"'$<{][^\s"']*["']?)[ \t]*$`)},
+}
+
+// HighEntropyRule names findings of random-looking strings
+const HighEntropyRule = "high-entropy"
+
+const (
+	// minEntropyLength is the shortest string checked for randomness
+	minEntropyLength = 24
+	// minEntropy is the Shannon entropy, in bits per character, above which
+	// a string looks random. Hex tops out at 4, so hashes don't qualify.
+	minEntropy = 4.2
+)
+
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_\-=]{24,}`)
+
+// Rules returns the names of all detection rules, for use in allowlists
+func Rules() []string {
+	names := make([]string, 0, len(rules)+1)
+	for _, r := range rules {
+		names = append(names, r.name)
+	}
+	return append(names, HighEntropyRule)
+}
+
+// Allowlist exempts matches from redaction. Entries naming a rule disable
+// that rule; any other entry is a regular expression, and findings whose
+// text it matches are kept.
+type Allowlist struct {
+	rules    map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// NewAllowlist compiles allowlist entries
+func NewAllowlist(entries []string) (*Allowlist, error) {
+	known := Rules()
+	a := &Allowlist{rules: make(map[string]bool)}
+	for _, entry := range entries {
+		if slices.Contains(known, entry) {
+			a.rules[entry] = true
+			continue
+		}
+
+		pattern, err := regexp.Compile(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist pattern %q: %w", entry, err)
+		}
+		a.patterns = append(a.patterns, pattern)
+	}
+	return a, nil
+}
+
+func (a *Allowlist) allows(ruleName, text string) bool {
+	if a == nil {
+		return false
+	}
+	if a.rules[ruleName] {
+		return true
+	}
+	for _, pattern := range a.patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan returns the secrets in content that the allowlist doesn't exempt,
+// ordered by position and without overlaps
+func Scan(content string, allow *Allowlist) []Finding {
+	var findings []Finding
+	for _, r := range rules {
+		for _, match := range r.pattern.FindAllStringSubmatchIndex(content, -1) {
+			start, end := match[2*r.group], match[2*r.group+1]
+			if start < 0 || allow.allows(r.name, content[start:end]) {
+				continue
+			}
+			findings = append(findings, Finding{Rule: r.name, Start: start, End: end})
+		}
+	}
+
+	for _, match := range entropyCandidate.FindAllStringIndex(content, -1) {
+		text := content[match[0]:match[1]]
+		if !looksRandom(text) || allow.allows(HighEntropyRule, text) {
+			continue
+		}
+		findings = append(findings, Finding{Rule: HighEntropyRule, Start: match[0], End: match[1]})
+	}
+
+	// Keep the first finding of any overlapping group; the stable sort
+	// preserves rule order for findings starting at the same offset
+	slices.SortStableFunc(findings, func(a, b Finding) int { return a.Start - b.Start })
+	kept := findings[:0]
+	end := 0
+	for _, f := range findings {
+		if f.Start < end {
+			continue
+		}
+		f.Line = strings.Count(content[:f.Start], "\n") + 1
+		kept = append(kept, f)
+		end = f.End
+	}
+
+	return kept
+}
+
+// Mask replaces each finding with a marker naming its rule
+func Mask(content string, findings []Finding) string {
+	var sb strings.Builder
+	last := 0
+	for _, f := range findings {
+		sb.WriteString(content[last:f.Start])
+		sb.WriteString("[REDACTED:" + f.Rule + "]")
+		last = f.End
+	}
+	sb.WriteString(content[last:])
+	return sb.String()
+}
+
+// looksRandom reports whether text mixes upper case, lower case and digits
+// with high entropy, like generated keys and unlike words, paths or hashes
+func looksRandom(text string) bool {
+	if len(text) < minEntropyLength {
+		return false
+	}
+
+	var upper, lower, digit bool
+	for _, r := range text {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+
+	return entropy(text) >= minEntropy
+}
+
+// entropy returns the Shannon entropy of text in bits per character
+func entropy(text string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range text {
+		counts[r]++
+	}
+
+	n := float64(len(text))
+	var bits float64
+	for _, count := range counts {
+		p := float64(count) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
@@ -0,0 +1,314 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/redact"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+)
+
+// defaultHistoryLimit is the number of history entries returned without ?limit
+const defaultHistoryLimit = 20
+
+// Preset is the API representation of a named group of sources
+type Preset struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	SourceIDs   []int64 `json:"source_ids"`
+	CreatedAt   int64   `json:"created_at"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+// CreatePresetRequest is the body of POST /api/presets
+type CreatePresetRequest struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	SourceIDs   []int64 `json:"source_ids"`
+}
+
+// GenerateRequest is the body of POST /api/generate
+type GenerateRequest struct {
+	// Format is claude (default), cursor or default
+	Format string `json:"format,omitempty"`
+	// Preset generates from the named preset instead of the enabled sources
+	Preset string `json:"preset,omitempty"`
+	// Budget caps the estimated tokens of the sources; zero means no limit
+	Budget int `json:"budget,omitempty"`
+	// Redact is off, mask (default) or fail
+	Redact string `json:"redact,omitempty"`
+}
+
+// GenerateResponse is the generated context and what went into it
+type GenerateResponse struct {
+	Content string   `json:"content"`
+	Tokens  int      `json:"tokens"`
+	Sources []string `json:"sources"`
+	// Omitted lists sources left out to stay within the budget
+	Omitted []string `json:"omitted"`
+	// Redactions lists the secrets that were masked
+	Redactions []generator.Redaction `json:"redactions"`
+}
+
+// History is the API representation of a past generation
+type History struct {
+	ID          int64  `json:"id"`
+	PresetName  string `json:"preset_name,omitempty"`
+	OutputPath  string `json:"output_path"`
+	SourceCount int64  `json:"source_count"`
+	Format      string `json:"format"`
+	ContentHash string `json:"content_hash"`
+	GeneratedAt int64  `json:"generated_at"`
+}
+
+func (s *Server) listPresets(w http.ResponseWriter, r *http.Request) {
+	presets, err := s.store.Queries().ListPresets(r.Context())
+	if err != nil {
+		s.writeStoreError(w, r, "presets", err)
+		return
+	}
+
+	out := make([]Preset, 0, len(presets))
+	for _, preset := range presets {
+		p, err := s.newPreset(r.Context(), preset)
+		if err != nil {
+			s.writeStoreError(w, r, "preset", err)
+			return
+		}
+		out = append(out, p)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getPreset(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	preset, err := s.store.Queries().GetPreset(r.Context(), id)
+	if err != nil {
+		s.writeStoreError(w, r, "preset", err)
+		return
+	}
+
+	p, err := s.newPreset(r.Context(), preset)
+	if err != nil {
+		s.writeStoreError(w, r, "preset", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) createPreset(w http.ResponseWriter, r *http.Request) {
+	var req CreatePresetRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := s.store.Queries().GetPresetByName(ctx, req.Name); err == nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("preset %q already exists", req.Name))
+		return
+	}
+	for _, id := range req.SourceIDs {
+		if _, err := s.store.Queries().GetSource(ctx, id); err != nil {
+			s.writeStoreError(w, r, fmt.Sprintf("source %d", id), err)
+			return
+		}
+	}
+
+	// Create the preset and its memberships together
+	tx, err := s.store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		s.writeStoreError(w, r, "preset", fmt.Errorf("failed to begin transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	q := s.store.Queries().WithTx(tx)
+	preset, err := q.CreatePreset(ctx, dbgen.CreatePresetParams{
+		Name:        req.Name,
+		Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
+	})
+	if err != nil {
+		s.writeStoreError(w, r, "preset", fmt.Errorf("failed to create preset: %w", err))
+		return
+	}
+	for _, id := range req.SourceIDs {
+		if err := q.AddSourceToPreset(ctx, dbgen.AddSourceToPresetParams{
+			PresetID: preset.ID,
+			SourceID: id,
+		}); err != nil {
+			s.writeStoreError(w, r, "preset", fmt.Errorf("failed to add source to preset: %w", err))
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.writeStoreError(w, r, "preset", fmt.Errorf("failed to commit preset: %w", err))
+		return
+	}
+
+	p, err := s.newPreset(ctx, preset)
+	if err != nil {
+		s.writeStoreError(w, r, "preset", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (s *Server) deletePreset(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := s.store.Queries().GetPreset(ctx, id); err != nil {
+		s.writeStoreError(w, r, "preset", err)
+		return
+	}
+
+	if err := s.store.Queries().DeletePreset(ctx, id); err != nil {
+		s.writeStoreError(w, r, "preset", fmt.Errorf("failed to delete preset: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newPreset converts a stored preset, looking up its sources
+func (s *Server) newPreset(ctx context.Context, preset dbgen.Preset) (Preset, error) {
+	sources, err := s.store.Queries().GetPresetSources(ctx, preset.ID)
+	if err != nil {
+		return Preset{}, fmt.Errorf("failed to get preset sources: %w", err)
+	}
+
+	ids := make([]int64, 0, len(sources))
+	for _, source := range sources {
+		ids = append(ids, source.ID)
+	}
+
+	return Preset{
+		ID:          preset.ID,
+		Name:        preset.Name,
+		Description: preset.Description.String,
+		SourceIDs:   ids,
+		CreatedAt:   preset.CreatedAt,
+		UpdatedAt:   preset.UpdatedAt,
+	}, nil
+}
+
+func (s *Server) generate(w http.ResponseWriter, r *http.Request) {
+	var req GenerateRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.Budget < 0 {
+		writeError(w, http.StatusBadRequest, "budget must not be negative")
+		return
+	}
+
+	mode, err := redact.ParseMode(req.Redact)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if req.Preset != "" {
+		if _, err := s.store.Queries().GetPresetByName(ctx, req.Preset); err != nil {
+			s.writeStoreError(w, r, "preset", err)
+			return
+		}
+	}
+
+	opts := generator.GenerateOptions{
+		Format:      req.Format,
+		PresetName:  req.Preset,
+		TokenBudget: req.Budget,
+		Redact:      mode,
+	}
+	result, err := s.generator.Render(ctx, opts)
+	if errors.Is(err, generator.ErrSecretsFound) {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		s.writeStoreError(w, r, "context", err)
+		return
+	}
+
+	if len(result.Sources) > 0 {
+		if _, err := s.generator.Record(ctx, opts, result); err != nil {
+			s.logger.WarnContext(ctx, "failed to record history", "error", err)
+		}
+	}
+
+	redactions := result.Redactions
+	if redactions == nil {
+		redactions = []generator.Redaction{}
+	}
+
+	writeJSON(w, http.StatusOK, GenerateResponse{
+		Content:    result.Content,
+		Tokens:     result.Tokens,
+		Sources:    sourceNames(result.Sources),
+		Omitted:    sourceNames(result.Omitted),
+		Redactions: redactions,
+	})
+}
+
+func (s *Server) listHistory(w http.ResponseWriter, r *http.Request) {
+	limit := int64(defaultHistoryLimit)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := s.store.Queries().ListHistory(r.Context(), limit)
+	if err != nil {
+		s.writeStoreError(w, r, "history", err)
+		return
+	}
+
+	out := make([]History, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, History{
+			ID:          entry.ID,
+			PresetName:  entry.PresetName.String,
+			OutputPath:  entry.OutputPath,
+			SourceCount: entry.SourceCount,
+			Format:      entry.Format,
+			ContentHash: entry.ContentHash,
+			GeneratedAt: entry.GeneratedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func sourceNames(sources []dbgen.Source) []string {
+	names := make([]string, 0, len(sources))
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	return names
+}
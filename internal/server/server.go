@@ -0,0 +1,164 @@
+package server
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/importer"
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+)
+
+// maxBodySize limits the size of JSON request bodies
+const maxBodySize = 1 << 20
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// webFiles holds the static web UI, which talks to the API from the browser
+//
+//go:embed web
+var webFiles embed.FS
+
+// Server serves sources, presets, generation and history as a JSON REST API
+type Server struct {
+	store     *storage.Store
+	generator *generator.Generator
+	parser    *parser.Parser
+	importer  *importer.Importer
+	logger    *slog.Logger
+	token     string
+}
+
+// NewServer creates a new Server with explicit dependencies. Every request
+// except the OpenAPI description must carry token as a bearer token.
+func NewServer(store *storage.Store, gen *generator.Generator, p *parser.Parser, imp *importer.Importer, logger *slog.Logger, token string) *Server {
+	return &Server{
+		store:     store,
+		generator: gen,
+		parser:    p,
+		importer:  imp,
+		logger:    logger,
+		token:     token,
+	}
+}
+
+// Handler returns the HTTP handler for the API and the web UI
+func (s *Server) Handler() http.Handler {
+	api := http.NewServeMux()
+
+	api.HandleFunc("GET /api/sources", s.listSources)
+	api.HandleFunc("POST /api/sources", s.createSource)
+	api.HandleFunc("GET /api/sources/{id}", s.getSource)
+	api.HandleFunc("PATCH /api/sources/{id}", s.updateSource)
+	api.HandleFunc("DELETE /api/sources/{id}", s.deleteSource)
+	api.HandleFunc("POST /api/sources/{id}/toggle", s.toggleSource)
+
+	api.HandleFunc("GET /api/presets", s.listPresets)
+	api.HandleFunc("POST /api/presets", s.createPreset)
+	api.HandleFunc("GET /api/presets/{id}", s.getPreset)
+	api.HandleFunc("DELETE /api/presets/{id}", s.deletePreset)
+
+	api.HandleFunc("POST /api/generate", s.generate)
+	api.HandleFunc("GET /api/history", s.listHistory)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+	mux.Handle("/api/", s.requireToken(api))
+
+	// The UI itself is public; it asks for the token before calling the API
+	web, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err) // the embedded directory always exists
+	}
+	mux.Handle("/", http.FileServerFS(web))
+
+	return s.logRequests(mux)
+}
+
+// requireToken rejects requests without the server's bearer token
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="context-vacuum"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.logger.DebugContext(r.Context(), "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+		)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiError is the body of every error response
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, apiError{Error: message})
+}
+
+// writeStoreError reports a failed store call, mapping missing rows to 404
+func (s *Server) writeStoreError(w http.ResponseWriter, r *http.Request, what string, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, what+" not found")
+		return
+	}
+
+	s.logger.ErrorContext(r.Context(), "request failed",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"error", err,
+	)
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+// decodeBody parses a JSON request body into v, rejecting unknown fields
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// pathID parses the {id} path parameter
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,471 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/importer"
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/server"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+)
+
+const testToken = "secret-token"
+
+type testAPI struct {
+	t      *testing.T
+	url    string
+	store  *storage.Store
+	dir    string
+	client *http.Client
+}
+
+func setupTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelError, // Quiet during tests
+	}))
+
+	store, err := storage.NewStore(dbPath, logger)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	p := parser.NewParser(10 * 1024 * 1024)
+	srv := server.NewServer(
+		store,
+		generator.NewGenerator(store, p, logger),
+		p,
+		importer.NewImporter(store, p, logger),
+		logger,
+		testToken,
+	)
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	return &testAPI{t: t, url: ts.URL, store: store, dir: t.TempDir(), client: ts.Client()}
+}
+
+// do sends an authenticated request and decodes a JSON response into out
+func (a *testAPI) do(method, path string, body any, out any) int {
+	a.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatalf("failed to encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, a.url+path, reader)
+	if err != nil {
+		a.t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			a.t.Fatalf("failed to decode %s %s response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// writeFile creates a file in the test directory and returns its path
+func (a *testAPI) writeFile(name, content string) string {
+	a.t.Helper()
+
+	path := filepath.Join(a.dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		a.t.Fatalf("failed to write file: %v", err)
+	}
+	return path
+}
+
+func (a *testAPI) createSource(name, content string) server.Source {
+	a.t.Helper()
+
+	var source server.Source
+	status := a.do("POST", "/api/sources", server.CreateSourceRequest{
+		Name: name,
+		Path: a.writeFile(name+".md", content),
+	}, &source)
+	if status != http.StatusCreated {
+		a.t.Fatalf("expected 201 creating %s, got %d", name, status)
+	}
+	return source
+}
+
+func TestServer_Auth(t *testing.T) {
+	api := setupTestAPI(t)
+
+	for _, header := range []string{"", "Bearer wrong", testToken} {
+		req, _ := http.NewRequest("GET", api.url+"/api/sources", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := api.client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected 401, got %d", header, resp.StatusCode)
+		}
+	}
+
+	// The API description is public so tools can discover how to authenticate
+	resp, err := api.client.Get(api.url + "/api/openapi.json")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("failed to decode spec: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("expected OpenAPI 3 document, got %d %+v", resp.StatusCode, spec)
+	}
+}
+
+func TestServer_Sources(t *testing.T) {
+	api := setupTestAPI(t)
+
+	created := api.createSource("notes", "Use table-driven tests.")
+	if !created.Enabled || created.Type != "file" || created.Content != "Use table-driven tests." || created.Tokens == 0 {
+		t.Errorf("unexpected created source: %+v", created)
+	}
+
+	var conflict map[string]string
+	status := api.do("POST", "/api/sources", server.CreateSourceRequest{
+		Name: "notes",
+		Path: api.writeFile("other.md", "other"),
+	}, &conflict)
+	if status != http.StatusConflict || conflict["error"] == "" {
+		t.Errorf("expected 409 with error for duplicate name, got %d %v", status, conflict)
+	}
+
+	status = api.do("POST", "/api/sources", server.CreateSourceRequest{
+		Name: "missing",
+		Path: filepath.Join(api.dir, "does-not-exist.md"),
+	}, nil)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for unreadable file, got %d", status)
+	}
+
+	var list []server.Source
+	api.do("GET", "/api/sources", nil, &list)
+	if len(list) != 1 || list[0].Name != "notes" || list[0].Content != "" {
+		t.Errorf("expected one source without content, got %+v", list)
+	}
+
+	path := fmt.Sprintf("/api/sources/%d", created.ID)
+	disabled := false
+	rename := "testing-notes"
+	var updated server.Source
+	if status := api.do("PATCH", path, server.UpdateSourceRequest{Name: &rename, Enabled: &disabled}, &updated); status != http.StatusOK {
+		t.Fatalf("expected 200 updating source, got %d", status)
+	}
+	if updated.Name != "testing-notes" || updated.Enabled {
+		t.Errorf("expected renamed disabled source, got %+v", updated)
+	}
+
+	tags := []string{"go", " testing ", "go", ""}
+	var tagged server.Source
+	api.do("PATCH", path, server.UpdateSourceRequest{Tags: &tags}, &tagged)
+	if strings.Join(tagged.Tags, ",") != "go,testing" {
+		t.Errorf("expected trimmed, deduplicated tags, got %q", tagged.Tags)
+	}
+
+	api.do("GET", "/api/sources", nil, &list)
+	if len(list) != 1 || strings.Join(list[0].Tags, ",") != "go,testing" {
+		t.Errorf("expected tags in source list, got %+v", list)
+	}
+
+	var toggled server.Source
+	api.do("POST", path+"/toggle", nil, &toggled)
+	if !toggled.Enabled {
+		t.Errorf("expected toggle to enable source, got %+v", toggled)
+	}
+
+	var fetched server.Source
+	api.do("GET", path, nil, &fetched)
+	if fetched.Content != "Use table-driven tests." {
+		t.Errorf("expected content for single source, got %+v", fetched)
+	}
+
+	if status := api.do("DELETE", path, nil, nil); status != http.StatusNoContent {
+		t.Errorf("expected 204 deleting source, got %d", status)
+	}
+	if status := api.do("GET", path, nil, nil); status != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", status)
+	}
+	if status := api.do("GET", "/api/sources/abc", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid id, got %d", status)
+	}
+}
+
+func TestServer_CreateSourceCancel(t *testing.T) {
+	api := setupTestAPI(t)
+
+	// The site never answers, so only the client going away ends the fetch
+	arrived := make(chan struct{}, 1)
+	stopped := make(chan struct{}, 1)
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-r.Context().Done()
+		stopped <- struct{}{}
+	}))
+	defer site.Close()
+
+	data, err := json.Marshal(server.CreateSourceRequest{Name: "slow", Type: "url", Path: site.URL})
+	if err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "POST", api.url+"/api/sources", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	go func() {
+		<-arrived
+		cancel()
+	}()
+	if resp, err := api.client.Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the request to be cancelled")
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fetch did not stop when the request was cancelled")
+	}
+}
+
+func TestServer_PresetsAndGenerate(t *testing.T) {
+	api := setupTestAPI(t)
+
+	style := api.createSource("style", strings.Repeat("Prefer small interfaces. ", 40))
+	api.createSource("api", "Orders are paginated.")
+
+	var preset server.Preset
+	status := api.do("POST", "/api/presets", server.CreatePresetRequest{
+		Name:        "backend",
+		Description: "API work",
+		SourceIDs:   []int64{style.ID},
+	}, &preset)
+	if status != http.StatusCreated || len(preset.SourceIDs) != 1 || preset.SourceIDs[0] != style.ID {
+		t.Fatalf("expected created preset with one source, got %d %+v", status, preset)
+	}
+
+	if status := api.do("POST", "/api/presets", server.CreatePresetRequest{Name: "broken", SourceIDs: []int64{999}}, nil); status != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown preset source, got %d", status)
+	}
+
+	var presets []server.Preset
+	api.do("GET", "/api/presets", nil, &presets)
+	if len(presets) != 1 || presets[0].Description != "API work" {
+		t.Errorf("unexpected presets: %+v", presets)
+	}
+
+	// All enabled sources
+	var all server.GenerateResponse
+	api.do("POST", "/api/generate", server.GenerateRequest{Format: "cursor"}, &all)
+	if len(all.Sources) != 2 || !strings.Contains(all.Content, "Orders are paginated.") || all.Tokens == 0 {
+		t.Errorf("expected both sources, got %+v", all)
+	}
+
+	// The budget only fits the small source
+	var budgeted server.GenerateResponse
+	api.do("POST", "/api/generate", server.GenerateRequest{Budget: 50}, &budgeted)
+	if len(budgeted.Sources) != 1 || budgeted.Sources[0] != "api" ||
+		len(budgeted.Omitted) != 1 || budgeted.Omitted[0] != "style" {
+		t.Errorf("expected style to be omitted, got %+v", budgeted)
+	}
+
+	var fromPreset server.GenerateResponse
+	api.do("POST", "/api/generate", server.GenerateRequest{Preset: "backend"}, &fromPreset)
+	if len(fromPreset.Sources) != 1 || fromPreset.Sources[0] != "style" {
+		t.Errorf("expected only the preset's source, got %+v", fromPreset)
+	}
+
+	if status := api.do("POST", "/api/generate", server.GenerateRequest{Preset: "missing"}, nil); status != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown preset, got %d", status)
+	}
+	if status := api.do("POST", "/api/generate", map[string]any{"formatt": "claude"}, nil); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown field, got %d", status)
+	}
+
+	path := fmt.Sprintf("/api/presets/%d", preset.ID)
+	if status := api.do("DELETE", path, nil, nil); status != http.StatusNoContent {
+		t.Errorf("expected 204 deleting preset, got %d", status)
+	}
+	if status := api.do("GET", path, nil, nil); status != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", status)
+	}
+}
+
+func TestServer_GenerateRedaction(t *testing.T) {
+	api := setupTestAPI(t)
+	api.createSource("env", "DEBUG=true\nDATABASE_PASSWORD=hunter2")
+
+	var masked server.GenerateResponse
+	api.do("POST", "/api/generate", server.GenerateRequest{}, &masked)
+	if strings.Contains(masked.Content, "hunter2") || len(masked.Redactions) != 1 ||
+		masked.Redactions[0].Rule != "env-secret" || masked.Redactions[0].Line != 2 {
+		t.Errorf("expected the password to be masked, got %+v", masked)
+	}
+
+	var raw server.GenerateResponse
+	api.do("POST", "/api/generate", server.GenerateRequest{Redact: "off"}, &raw)
+	if !strings.Contains(raw.Content, "hunter2") || len(raw.Redactions) != 0 {
+		t.Errorf("expected content untouched, got %+v", raw)
+	}
+
+	if status := api.do("POST", "/api/generate", server.GenerateRequest{Redact: "fail"}, nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 when secrets are found, got %d", status)
+	}
+	if status := api.do("POST", "/api/generate", server.GenerateRequest{Redact: "loud"}, nil); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown redact mode, got %d", status)
+	}
+}
+
+func TestServer_History(t *testing.T) {
+	api := setupTestAPI(t)
+	ctx := context.Background()
+
+	for i := range 3 {
+		_, err := api.store.Queries().CreateHistory(ctx, dbgen.CreateHistoryParams{
+			PresetName:  sql.NullString{String: "backend", Valid: i == 0},
+			OutputPath:  fmt.Sprintf("/out/%d.md", i),
+			SourceCount: int64(i),
+		})
+		if err != nil {
+			t.Fatalf("failed to create history: %v", err)
+		}
+	}
+
+	var history []server.History
+	api.do("GET", "/api/history?limit=2", nil, &history)
+	if len(history) != 2 {
+		t.Errorf("expected limit to apply, got %+v", history)
+	}
+
+	if status := api.do("GET", "/api/history?limit=0", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid limit, got %d", status)
+	}
+
+	// Generating through the API is recorded like the CLI
+	api.createSource("api", "Orders are paginated.")
+	var generated server.GenerateResponse
+	api.do("POST", "/api/generate", server.GenerateRequest{Format: "cursor"}, &generated)
+
+	api.do("GET", "/api/history?limit=1", nil, &history)
+	if len(history) != 1 || history[0].SourceCount != 1 || history[0].Format != "cursor" ||
+		history[0].ContentHash != storage.ComputeHash(generated.Content) {
+		t.Errorf("expected the generation recorded, got %+v", history)
+	}
+}
+
+func TestServer_WebUI(t *testing.T) {
+	api := setupTestAPI(t)
+
+	// The UI is served without a token and authenticates its API calls itself
+	for path, want := range map[string]string{
+		"/":          "<title>context-vacuum</title>",
+		"/app.js":    "/api",
+		"/style.css": "#source-list",
+	} {
+		resp, err := api.client.Get(api.url + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", path, err)
+		}
+
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("GET %s: expected 200 containing %q, got %d", path, want, resp.StatusCode)
+		}
+	}
+}
+
+// TestServer_OpenAPIPaths checks that every documented operation is routed
+func TestServer_OpenAPIPaths(t *testing.T) {
+	api := setupTestAPI(t)
+	source := api.createSource("notes", "content")
+
+	resp, err := api.client.Get(api.url + "/api/openapi.json")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("failed to decode spec: %v", err)
+	}
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+
+			url := strings.ReplaceAll(path, "{id}", fmt.Sprint(source.ID))
+			req, _ := http.NewRequest(strings.ToUpper(method), api.url+url, strings.NewReader("{}"))
+			req.Header.Set("Authorization", "Bearer "+testToken)
+			resp, err := api.client.Do(req)
+			if err != nil {
+				t.Fatalf("%s %s failed: %v", method, path, err)
+			}
+			resp.Body.Close()
+
+			// Unrouted requests get the mux's plain text 404 or 405
+			if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") && resp.StatusCode != http.StatusNoContent {
+				t.Errorf("%s %s is not routed: %d %s", strings.ToUpper(method), path, resp.StatusCode, ct)
+			}
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+)
+
+// Source is the API representation of a cached source
+type Source struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Path    string `json:"path"`
+	Enabled bool   `json:"enabled"`
+	// Pending is true for lazily imported bookmarks that haven't been fetched
+	Pending   bool                 `json:"pending"`
+	Tokens    int                  `json:"tokens"`
+	Tags      []string             `json:"tags"`
+	Options   parser.SourceOptions `json:"options"`
+	Content   string               `json:"content,omitempty"`
+	CreatedAt int64                `json:"created_at"`
+	UpdatedAt int64                `json:"updated_at"`
+}
+
+// CreateSourceRequest is the body of POST /api/sources
+type CreateSourceRequest struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Type is detected from the path when empty
+	Type    string               `json:"type,omitempty"`
+	Enabled *bool                `json:"enabled,omitempty"`
+	Tags    []string             `json:"tags,omitempty"`
+	Options parser.SourceOptions `json:"options"`
+}
+
+// UpdateSourceRequest is the body of PATCH /api/sources/{id}; omitted fields are unchanged
+type UpdateSourceRequest struct {
+	Name    *string `json:"name,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+	// Tags replaces the source's tags
+	Tags *[]string `json:"tags,omitempty"`
+}
+
+// newSource converts a stored source, including its content if requested
+func newSource(source dbgen.Source, tags []string, withContent bool) Source {
+	// Options were validated when stored, so a decode error only loses display detail
+	opts, _ := parser.DecodeOptions(source.Options)
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	s := Source{
+		ID:        source.ID,
+		Name:      source.Name,
+		Type:      source.SourceType,
+		Path:      source.Path,
+		Enabled:   source.Enabled == 1,
+		Pending:   source.Pending == 1,
+		Tokens:    generator.EstimateTokens(source.Content),
+		Tags:      tags,
+		Options:   opts,
+		CreatedAt: source.CreatedAt,
+		UpdatedAt: source.UpdatedAt,
+	}
+	if withContent {
+		s.Content = source.Content
+	}
+	return s
+}
+
+func (s *Server) listSources(w http.ResponseWriter, r *http.Request) {
+	sources, err := s.store.Queries().ListSources(r.Context())
+	if err != nil {
+		s.writeStoreError(w, r, "sources", err)
+		return
+	}
+
+	tags, err := s.store.Queries().ListAllSourceTags(r.Context())
+	if err != nil {
+		s.writeStoreError(w, r, "tags", err)
+		return
+	}
+	tagsBySource := make(map[int64][]string)
+	for _, tag := range tags {
+		tagsBySource[tag.SourceID] = append(tagsBySource[tag.SourceID], tag.Tag)
+	}
+
+	out := make([]Source, 0, len(sources))
+	for _, source := range sources {
+		out = append(out, newSource(source, tagsBySource[source.ID], false))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getSource(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	s.writeSource(w, r, id, http.StatusOK, true)
+}
+
+func (s *Server) createSource(w http.ResponseWriter, r *http.Request) {
+	var req CreateSourceRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Path = strings.TrimSpace(req.Path)
+	if req.Name == "" || req.Path == "" {
+		writeError(w, http.StatusBadRequest, "name and path are required")
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := s.store.Queries().GetSourceByName(ctx, req.Name); err == nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("source %q already exists", req.Name))
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = parser.DetectSourceType(req.Path)
+	}
+	if !parser.IsURL(req.Path) {
+		absPath, err := filepath.Abs(req.Path)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to resolve path: %v", err))
+			return
+		}
+		req.Path = absPath
+	}
+
+	content, err := s.parser.ParseSourceContext(ctx, req.Type, req.Path, req.Options)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	enabled := int64(1)
+	if req.Enabled != nil && !*req.Enabled {
+		enabled = 0
+	}
+
+	tx, err := s.store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		s.writeStoreError(w, r, "source", fmt.Errorf("failed to begin transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	q := s.store.Queries().WithTx(tx)
+	created, err := q.CreateSource(ctx, dbgen.CreateSourceParams{
+		Name:       req.Name,
+		SourceType: req.Type,
+		Path:       req.Path,
+		Content:    content,
+		Hash:       storage.ComputeHash(content),
+		Enabled:    enabled,
+		Options:    req.Options.Encode(),
+	})
+	if err != nil {
+		s.writeStoreError(w, r, "source", fmt.Errorf("failed to create source: %w", err))
+		return
+	}
+	if err := setTags(ctx, q, created.ID, req.Tags); err != nil {
+		s.writeStoreError(w, r, "source", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		s.writeStoreError(w, r, "source", fmt.Errorf("failed to commit source: %w", err))
+		return
+	}
+
+	s.logger.InfoContext(ctx, "source added",
+		"name", created.Name,
+		"type", created.SourceType,
+		"enabled", enabled == 1,
+	)
+
+	s.writeSource(w, r, created.ID, http.StatusCreated, true)
+}
+
+func (s *Server) updateSource(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateSourceRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	ctx := r.Context()
+	source, err := s.store.Queries().GetSource(ctx, id)
+	if err != nil {
+		s.writeStoreError(w, r, "source", err)
+		return
+	}
+
+	if req.Name != nil && strings.TrimSpace(*req.Name) != source.Name {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			writeError(w, http.StatusBadRequest, "name must not be empty")
+			return
+		}
+		if _, err := s.store.Queries().GetSourceByName(ctx, name); err == nil {
+			writeError(w, http.StatusConflict, fmt.Sprintf("source %q already exists", name))
+			return
+		}
+		if err := s.store.Queries().UpdateSourceName(ctx, dbgen.UpdateSourceNameParams{
+			Name: name,
+			ID:   id,
+		}); err != nil {
+			s.writeStoreError(w, r, "source", fmt.Errorf("failed to rename source: %w", err))
+			return
+		}
+		source.Name = name
+	}
+
+	if req.Tags != nil {
+		if err := setTags(ctx, s.store.Queries(), id, *req.Tags); err != nil {
+			s.writeStoreError(w, r, "source", err)
+			return
+		}
+	}
+
+	if req.Enabled != nil {
+		if err := s.setEnabled(ctx, source, *req.Enabled); err != nil {
+			s.writeStoreError(w, r, "source", err)
+			return
+		}
+	}
+
+	s.writeSource(w, r, id, http.StatusOK, false)
+}
+
+func (s *Server) toggleSource(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	source, err := s.store.Queries().GetSource(r.Context(), id)
+	if err != nil {
+		s.writeStoreError(w, r, "source", err)
+		return
+	}
+
+	if err := s.setEnabled(r.Context(), source, source.Enabled == 0); err != nil {
+		s.writeStoreError(w, r, "source", err)
+		return
+	}
+
+	s.writeSource(w, r, id, http.StatusOK, false)
+}
+
+func (s *Server) deleteSource(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := s.store.Queries().GetSource(ctx, id); err != nil {
+		s.writeStoreError(w, r, "source", err)
+		return
+	}
+
+	if err := s.store.Queries().DeleteSourceByID(ctx, id); err != nil {
+		s.writeStoreError(w, r, "source", fmt.Errorf("failed to delete source: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setEnabled enables or disables a source. Lazily imported bookmarks are
+// fetched when enabled; like toggle-on, a failed fetch doesn't block enabling
+// since generation retries it.
+func (s *Server) setEnabled(ctx context.Context, source dbgen.Source, enabled bool) error {
+	value := int64(0)
+	if enabled {
+		value = 1
+		if _, err := s.importer.Fetch(ctx, source); err != nil {
+			s.logger.WarnContext(ctx, "failed to fetch pending source", "name", source.Name, "error", err)
+		}
+	}
+
+	if err := s.store.Queries().UpdateSourceEnabled(ctx, dbgen.UpdateSourceEnabledParams{
+		Enabled: value,
+		Name:    source.Name,
+	}); err != nil {
+		return fmt.Errorf("failed to update source: %w", err)
+	}
+	return nil
+}
+
+// setTags replaces the tags of a source, ignoring blanks and duplicates
+func setTags(ctx context.Context, q *dbgen.Queries, sourceID int64, tags []string) error {
+	if err := q.DeleteSourceTags(ctx, sourceID); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if err := q.AddSourceTag(ctx, dbgen.AddSourceTagParams{
+			SourceID: sourceID,
+			Tag:      tag,
+		}); err != nil {
+			return fmt.Errorf("failed to add tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeSource responds with the current state of a source
+func (s *Server) writeSource(w http.ResponseWriter, r *http.Request, id int64, status int, withContent bool) {
+	source, err := s.store.Queries().GetSource(r.Context(), id)
+	if err != nil {
+		s.writeStoreError(w, r, "source", err)
+		return
+	}
+
+	tags, err := s.store.Queries().ListSourceTags(r.Context(), id)
+	if err != nil {
+		s.writeStoreError(w, r, "tags", err)
+		return
+	}
+
+	writeJSON(w, status, newSource(source, tags, withContent))
+}
@@ -0,0 +1,320 @@
+// Web UI for context-vacuum, backed by the REST API under /api.
+"use strict";
+
+const TOKEN_KEY = "context-vacuum-token";
+
+const state = {
+  sources: [],
+  presets: [],
+  selected: null, // ID of the previewed source
+};
+
+const $ = (id) => document.getElementById(id);
+
+// The serve command prints a URL with the token in the fragment, which is
+// never sent to the server; keep it and tidy the address bar.
+function takeTokenFromURL() {
+  const match = location.hash.match(/token=([^&]+)/);
+  if (match) {
+    localStorage.setItem(TOKEN_KEY, decodeURIComponent(match[1]));
+    history.replaceState(null, "", location.pathname);
+  }
+}
+
+class Unauthorized extends Error {}
+
+async function api(method, path, body) {
+  const response = await fetch("/api" + path, {
+    method,
+    headers: {
+      Authorization: "Bearer " + (localStorage.getItem(TOKEN_KEY) || ""),
+      "Content-Type": "application/json",
+    },
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+
+  if (response.status === 401) {
+    throw new Unauthorized("missing or invalid token");
+  }
+  if (response.status === 204) {
+    return null;
+  }
+
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.error || response.statusText);
+  }
+  return data;
+}
+
+function showLogin() {
+  $("app").hidden = true;
+  $("login").hidden = false;
+  $("token").focus();
+}
+
+function toast(message, isError) {
+  const el = $("toast");
+  el.textContent = message;
+  el.className = isError ? "error" : "";
+  el.hidden = false;
+  clearTimeout(toast.timer);
+  toast.timer = setTimeout(() => (el.hidden = true), 3000);
+}
+
+function handleError(err) {
+  if (err instanceof Unauthorized) {
+    showLogin();
+    return;
+  }
+  toast(err.message, true);
+}
+
+function formatTokens(n) {
+  return n >= 1000 ? (n / 1000).toFixed(1) + "k tokens" : n + " tokens";
+}
+
+function currentPreset() {
+  const name = $("preset").value;
+  return state.presets.find((p) => p.name === name) || null;
+}
+
+// The sources generation would use: the preset's, or the enabled ones
+function selectedSources() {
+  const preset = currentPreset();
+  if (preset) {
+    return state.sources.filter((s) => preset.source_ids.includes(s.id));
+  }
+  return state.sources.filter((s) => s.enabled);
+}
+
+function matchesFilter(source, filter) {
+  if (!filter) {
+    return true;
+  }
+  const haystack = [source.name, source.path, ...source.tags].join(" ").toLowerCase();
+  return haystack.includes(filter);
+}
+
+function renderSources() {
+  const list = $("source-list");
+  const filter = $("filter").value.trim().toLowerCase();
+  const preset = currentPreset();
+  list.replaceChildren();
+
+  for (const source of state.sources) {
+    if (!matchesFilter(source, filter)) {
+      continue;
+    }
+
+    const li = document.createElement("li");
+    li.classList.toggle("selected", source.id === state.selected);
+    li.classList.toggle("in-preset", !!preset && preset.source_ids.includes(source.id));
+    li.addEventListener("click", () => preview(source.id));
+
+    const toggle = document.createElement("input");
+    toggle.type = "checkbox";
+    toggle.checked = source.enabled;
+    toggle.title = source.enabled ? "Disable" : "Enable";
+    toggle.addEventListener("click", (e) => e.stopPropagation());
+    toggle.addEventListener("change", () => toggleSource(source.id));
+
+    const name = document.createElement("span");
+    name.className = "name";
+    name.textContent = source.name;
+    name.title = source.path;
+
+    const type = document.createElement("span");
+    type.className = "type";
+    type.textContent = source.type;
+
+    li.append(toggle, name, type);
+
+    for (const tag of source.tags) {
+      const chip = document.createElement("span");
+      chip.className = "tag";
+      chip.textContent = tag;
+      li.append(chip);
+    }
+
+    const tokens = document.createElement("span");
+    if (source.pending) {
+      tokens.className = "pending";
+      tokens.textContent = "pending";
+    } else {
+      tokens.className = "tokens";
+      tokens.textContent = formatTokens(source.tokens);
+    }
+    li.append(tokens);
+
+    list.append(li);
+  }
+
+  $("empty").hidden = state.sources.length > 0;
+
+  const selected = selectedSources();
+  const total = selected.reduce((sum, s) => sum + s.tokens, 0);
+  $("selection-tokens").textContent = `${selected.length} sources, ~${formatTokens(total)}`;
+}
+
+function renderPresets() {
+  const select = $("preset");
+  const current = select.value;
+  select.replaceChildren(select.options[0]);
+
+  for (const preset of state.presets) {
+    const option = document.createElement("option");
+    option.value = preset.name;
+    option.textContent = preset.name;
+    option.title = preset.description;
+    select.append(option);
+  }
+  select.value = state.presets.some((p) => p.name === current) ? current : "";
+}
+
+async function load() {
+  try {
+    [state.sources, state.presets] = await Promise.all([api("GET", "/sources"), api("GET", "/presets")]);
+  } catch (err) {
+    handleError(err);
+    return;
+  }
+
+  $("login").hidden = true;
+  $("app").hidden = false;
+  renderPresets();
+  renderSources();
+}
+
+async function toggleSource(id) {
+  try {
+    const updated = await api("POST", `/sources/${id}/toggle`);
+    replaceSource(updated);
+  } catch (err) {
+    handleError(err);
+  }
+  renderSources();
+}
+
+function replaceSource(updated) {
+  const index = state.sources.findIndex((s) => s.id === updated.id);
+  if (index >= 0) {
+    state.sources[index] = { ...updated, content: undefined };
+  }
+}
+
+async function preview(id) {
+  let source;
+  try {
+    source = await api("GET", `/sources/${id}`);
+  } catch (err) {
+    handleError(err);
+    return;
+  }
+
+  state.selected = id;
+  $("preview-name").textContent = source.name;
+  $("preview-path").textContent = `${source.type} · ${source.path} · ${formatTokens(source.tokens)}`;
+  $("tags").value = source.tags.join(", ");
+  $("preview-content").textContent = source.pending
+    ? "Not fetched yet. Enable this source to fetch it."
+    : source.content;
+
+  $("placeholder").hidden = true;
+  $("output").hidden = true;
+  $("preview").hidden = false;
+  renderSources();
+}
+
+async function saveTags(event) {
+  event.preventDefault();
+  if (state.selected === null) {
+    return;
+  }
+
+  const tags = $("tags")
+    .value.split(",")
+    .map((t) => t.trim())
+    .filter(Boolean);
+
+  try {
+    replaceSource(await api("PATCH", `/sources/${state.selected}`, { tags }));
+    toast("Tags saved");
+  } catch (err) {
+    handleError(err);
+  }
+  renderSources();
+}
+
+async function copyText(text) {
+  if (navigator.clipboard && window.isSecureContext) {
+    await navigator.clipboard.writeText(text);
+    return;
+  }
+
+  // Fallback for plain HTTP on a non-localhost address
+  const area = document.createElement("textarea");
+  area.value = text;
+  document.body.append(area);
+  area.select();
+  document.execCommand("copy");
+  area.remove();
+}
+
+async function generate(event) {
+  event.preventDefault();
+
+  const button = $("generate");
+  button.disabled = true;
+  try {
+    const result = await api("POST", "/generate", {
+      format: $("format").value,
+      preset: $("preset").value || undefined,
+      budget: Number($("budget").value) || 0,
+    });
+
+    if (result.sources.length === 0 && result.omitted.length === 0) {
+      toast("No sources selected. Enable some or pick a preset.", true);
+      return;
+    }
+
+    let summary = `${result.sources.length} sources, ~${formatTokens(result.tokens)}`;
+    if (result.omitted.length > 0) {
+      summary += `. Over budget, left out: ${result.omitted.join(", ")}`;
+    }
+    if (result.redactions.length > 0) {
+      const where = result.redactions.map((r) => `${r.source}:${r.line} (${r.rule})`);
+      summary += `. Masked ${result.redactions.length} secret(s): ${where.join(", ")}`;
+    }
+    $("output-summary").textContent = summary;
+    $("output-content").textContent = result.content;
+    $("placeholder").hidden = true;
+    $("preview").hidden = true;
+    $("output").hidden = false;
+
+    await copyText(result.content);
+    toast("Copied to clipboard");
+  } catch (err) {
+    handleError(err);
+  } finally {
+    button.disabled = false;
+  }
+}
+
+function init() {
+  takeTokenFromURL();
+
+  $("login-form").addEventListener("submit", (event) => {
+    event.preventDefault();
+    localStorage.setItem(TOKEN_KEY, $("token").value.trim());
+    load();
+  });
+  $("generate-form").addEventListener("submit", generate);
+  $("tags-form").addEventListener("submit", saveTags);
+  $("filter").addEventListener("input", renderSources);
+  $("preset").addEventListener("change", renderSources);
+
+  load();
+}
+
+init();
@@ -8,12 +8,43 @@ import (
 	"database/sql"
 )
 
+type ContentVersion struct {
+	Hash    string `json:"hash"`
+	Content string `json:"content"`
+}
+
 type History struct {
 	ID          int64          `json:"id"`
 	PresetName  sql.NullString `json:"preset_name"`
 	OutputPath  string         `json:"output_path"`
 	SourceCount int64          `json:"source_count"`
 	GeneratedAt int64          `json:"generated_at"`
+	Format      string         `json:"format"`
+	ContentHash string         `json:"content_hash"`
+}
+
+type HistorySource struct {
+	HistoryID  int64  `json:"history_id"`
+	Position   int64  `json:"position"`
+	SourceID   int64  `json:"source_id"`
+	Name       string `json:"name"`
+	SourceType string `json:"source_type"`
+	Path       string `json:"path"`
+	Hash       string `json:"hash"`
+}
+
+type ImportFailure struct {
+	ID       int64  `json:"id"`
+	Url      string `json:"url"`
+	Title    string `json:"title"`
+	Folder   string `json:"folder"`
+	Error    string `json:"error"`
+	FailedAt int64  `json:"failed_at"`
+}
+
+type Maintenance struct {
+	Task      string `json:"task"`
+	LastRunAt int64  `json:"last_run_at"`
 }
 
 type Preset struct {
@@ -30,13 +61,22 @@ type PresetSource struct {
 }
 
 type Source struct {
-	ID         int64  `json:"id"`
-	Name       string `json:"name"`
-	SourceType string `json:"source_type"`
-	Path       string `json:"path"`
-	Content    string `json:"content"`
-	Hash       string `json:"hash"`
-	Enabled    int64  `json:"enabled"`
-	CreatedAt  int64  `json:"created_at"`
-	UpdatedAt  int64  `json:"updated_at"`
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	SourceType    string `json:"source_type"`
+	Path          string `json:"path"`
+	Content       string `json:"content"`
+	Hash          string `json:"hash"`
+	Enabled       int64  `json:"enabled"`
+	CreatedAt     int64  `json:"created_at"`
+	UpdatedAt     int64  `json:"updated_at"`
+	Options       string `json:"options"`
+	Pending       int64  `json:"pending"`
+	Priority      int64  `json:"priority"`
+	RefreshPolicy string `json:"refresh_policy"`
+}
+
+type SourceTag struct {
+	SourceID int64  `json:"source_id"`
+	Tag      string `json:"tag"`
 }
@@ -9,31 +9,55 @@ import (
 )
 
 type Querier interface {
+	AddHistorySource(ctx context.Context, arg AddHistorySourceParams) error
+	AddSourceTag(ctx context.Context, arg AddSourceTagParams) error
 	AddSourceToPreset(ctx context.Context, arg AddSourceToPresetParams) error
+	ApplyPreset(ctx context.Context, presetID int64) (int64, error)
+	ClearPresetSources(ctx context.Context, presetID int64) error
 	CountEnabledSources(ctx context.Context) (int64, error)
 	CountSources(ctx context.Context) (int64, error)
+	CreateContentVersion(ctx context.Context, arg CreateContentVersionParams) error
 	// History
 	CreateHistory(ctx context.Context, arg CreateHistoryParams) (History, error)
 	// Presets
 	CreatePreset(ctx context.Context, arg CreatePresetParams) (Preset, error)
 	CreateSource(ctx context.Context, arg CreateSourceParams) (Source, error)
-	DeleteOldHistory(ctx context.Context, dollar_1 interface{}) error
+	DeleteImportFailure(ctx context.Context, url string) error
+	DeleteOldHistory(ctx context.Context, dollar_1 interface{}) (int64, error)
+	DeleteOldHistorySources(ctx context.Context, dollar_1 interface{}) error
 	DeletePreset(ctx context.Context, id int64) error
 	DeleteSource(ctx context.Context, name string) error
 	DeleteSourceByID(ctx context.Context, id int64) error
+	DeleteSourceTags(ctx context.Context, sourceID int64) error
+	DeleteUnusedContentVersions(ctx context.Context) error
+	GetContentVersion(ctx context.Context, hash string) (string, error)
+	GetHistory(ctx context.Context, id int64) (History, error)
+	GetMaintenanceRun(ctx context.Context, task string) (int64, error)
 	GetPreset(ctx context.Context, id int64) (Preset, error)
 	GetPresetByName(ctx context.Context, name string) (Preset, error)
 	GetPresetSources(ctx context.Context, presetID int64) ([]Source, error)
 	GetSource(ctx context.Context, id int64) (Source, error)
 	GetSourceByHash(ctx context.Context, hash string) (Source, error)
 	GetSourceByName(ctx context.Context, name string) (Source, error)
+	ListAllSourceTags(ctx context.Context) ([]SourceTag, error)
 	ListEnabledSources(ctx context.Context) ([]Source, error)
 	ListHistory(ctx context.Context, limit int64) ([]History, error)
+	ListHistorySources(ctx context.Context, historyID int64) ([]HistorySource, error)
+	ListImportFailures(ctx context.Context) ([]ImportFailure, error)
+	ListLatestIncludedHashes(ctx context.Context) ([]ListLatestIncludedHashesRow, error)
 	ListPresets(ctx context.Context) ([]Preset, error)
+	ListSourceTags(ctx context.Context, sourceID int64) ([]string, error)
 	ListSources(ctx context.Context) ([]Source, error)
 	RemoveSourceFromPreset(ctx context.Context, arg RemoveSourceFromPresetParams) error
+	RenamePreset(ctx context.Context, arg RenamePresetParams) error
+	SetMaintenanceRun(ctx context.Context, arg SetMaintenanceRunParams) error
 	UpdateSourceContent(ctx context.Context, arg UpdateSourceContentParams) error
 	UpdateSourceEnabled(ctx context.Context, arg UpdateSourceEnabledParams) error
+	UpdateSourceName(ctx context.Context, arg UpdateSourceNameParams) error
+	UpdateSourceOptions(ctx context.Context, arg UpdateSourceOptionsParams) error
+	UpdateSourcePath(ctx context.Context, arg UpdateSourcePathParams) error
+	UpdateSourceSettings(ctx context.Context, arg UpdateSourceSettingsParams) error
+	UpsertImportFailure(ctx context.Context, arg UpsertImportFailureParams) error
 }
 
 var _ Querier = (*Queries)(nil)
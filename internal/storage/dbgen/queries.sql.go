@@ -10,6 +10,49 @@ import (
 	"database/sql"
 )
 
+const addHistorySource = `-- name: AddHistorySource :exec
+INSERT INTO history_sources (history_id, position, source_id, name, source_type, path, hash)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type AddHistorySourceParams struct {
+	HistoryID  int64  `json:"history_id"`
+	Position   int64  `json:"position"`
+	SourceID   int64  `json:"source_id"`
+	Name       string `json:"name"`
+	SourceType string `json:"source_type"`
+	Path       string `json:"path"`
+	Hash       string `json:"hash"`
+}
+
+func (q *Queries) AddHistorySource(ctx context.Context, arg AddHistorySourceParams) error {
+	_, err := q.db.ExecContext(ctx, addHistorySource,
+		arg.HistoryID,
+		arg.Position,
+		arg.SourceID,
+		arg.Name,
+		arg.SourceType,
+		arg.Path,
+		arg.Hash,
+	)
+	return err
+}
+
+const addSourceTag = `-- name: AddSourceTag :exec
+INSERT OR IGNORE INTO source_tags (source_id, tag)
+VALUES (?, ?)
+`
+
+type AddSourceTagParams struct {
+	SourceID int64  `json:"source_id"`
+	Tag      string `json:"tag"`
+}
+
+func (q *Queries) AddSourceTag(ctx context.Context, arg AddSourceTagParams) error {
+	_, err := q.db.ExecContext(ctx, addSourceTag, arg.SourceID, arg.Tag)
+	return err
+}
+
 const addSourceToPreset = `-- name: AddSourceToPreset :exec
 INSERT INTO preset_sources (preset_id, source_id)
 VALUES (?, ?)
@@ -25,6 +68,31 @@ func (q *Queries) AddSourceToPreset(ctx context.Context, arg AddSourceToPresetPa
 	return err
 }
 
+const applyPreset = `-- name: ApplyPreset :execrows
+UPDATE sources
+SET enabled = (id IN (SELECT source_id FROM preset_sources WHERE preset_id = ?1)),
+    updated_at = strftime('%s', 'now')
+WHERE enabled != (id IN (SELECT source_id FROM preset_sources WHERE preset_id = ?1))
+`
+
+func (q *Queries) ApplyPreset(ctx context.Context, presetID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, applyPreset, presetID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const clearPresetSources = `-- name: ClearPresetSources :exec
+DELETE FROM preset_sources
+WHERE preset_id = ?
+`
+
+func (q *Queries) ClearPresetSources(ctx context.Context, presetID int64) error {
+	_, err := q.db.ExecContext(ctx, clearPresetSources, presetID)
+	return err
+}
+
 const countEnabledSources = `-- name: CountEnabledSources :one
 SELECT COUNT(*) FROM sources
 WHERE enabled = 1
@@ -48,22 +116,47 @@ func (q *Queries) CountSources(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const createContentVersion = `-- name: CreateContentVersion :exec
+INSERT OR IGNORE INTO content_versions (hash, content)
+VALUES (?, ?)
+`
+
+type CreateContentVersionParams struct {
+	Hash    string `json:"hash"`
+	Content string `json:"content"`
+}
+
+func (q *Queries) CreateContentVersion(ctx context.Context, arg CreateContentVersionParams) error {
+	_, err := q.db.ExecContext(ctx, createContentVersion, arg.Hash, arg.Content)
+	return err
+}
+
 const createHistory = `-- name: CreateHistory :one
 
-INSERT INTO history (preset_name, output_path, source_count)
-VALUES (?, ?, ?)
-RETURNING id, preset_name, output_path, source_count, generated_at
+INSERT INTO history (preset_name, output_path, source_count, format, content_hash, generated_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, preset_name, output_path, source_count, generated_at, format, content_hash
 `
 
 type CreateHistoryParams struct {
 	PresetName  sql.NullString `json:"preset_name"`
 	OutputPath  string         `json:"output_path"`
 	SourceCount int64          `json:"source_count"`
+	Format      string         `json:"format"`
+	ContentHash string         `json:"content_hash"`
+	GeneratedAt int64          `json:"generated_at"`
 }
 
 // History
 func (q *Queries) CreateHistory(ctx context.Context, arg CreateHistoryParams) (History, error) {
-	row := q.db.QueryRowContext(ctx, createHistory, arg.PresetName, arg.OutputPath, arg.SourceCount)
+	row := q.db.QueryRowContext(ctx, createHistory,
+		arg.PresetName,
+		arg.OutputPath,
+		arg.SourceCount,
+		arg.Format,
+		arg.ContentHash,
+		arg.GeneratedAt,
+	)
 	var i History
 	err := row.Scan(
 		&i.ID,
@@ -71,6 +164,8 @@ func (q *Queries) CreateHistory(ctx context.Context, arg CreateHistoryParams) (H
 		&i.OutputPath,
 		&i.SourceCount,
 		&i.GeneratedAt,
+		&i.Format,
+		&i.ContentHash,
 	)
 	return i, err
 }
@@ -102,9 +197,9 @@ func (q *Queries) CreatePreset(ctx context.Context, arg CreatePresetParams) (Pre
 }
 
 const createSource = `-- name: CreateSource :one
-INSERT INTO sources (name, source_type, path, content, hash, enabled)
-VALUES (?, ?, ?, ?, ?, ?)
-RETURNING id, name, source_type, path, content, hash, enabled, created_at, updated_at
+INSERT INTO sources (name, source_type, path, content, hash, enabled, options, pending)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, name, source_type, path, content, hash, enabled, created_at, updated_at, options, pending, priority, refresh_policy
 `
 
 type CreateSourceParams struct {
@@ -114,6 +209,8 @@ type CreateSourceParams struct {
 	Content    string `json:"content"`
 	Hash       string `json:"hash"`
 	Enabled    int64  `json:"enabled"`
+	Options    string `json:"options"`
+	Pending    int64  `json:"pending"`
 }
 
 func (q *Queries) CreateSource(ctx context.Context, arg CreateSourceParams) (Source, error) {
@@ -124,6 +221,8 @@ func (q *Queries) CreateSource(ctx context.Context, arg CreateSourceParams) (Sou
 		arg.Content,
 		arg.Hash,
 		arg.Enabled,
+		arg.Options,
+		arg.Pending,
 	)
 	var i Source
 	err := row.Scan(
@@ -136,17 +235,47 @@ func (q *Queries) CreateSource(ctx context.Context, arg CreateSourceParams) (Sou
 		&i.Enabled,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Options,
+		&i.Pending,
+		&i.Priority,
+		&i.RefreshPolicy,
 	)
 	return i, err
 }
 
-const deleteOldHistory = `-- name: DeleteOldHistory :exec
+const deleteImportFailure = `-- name: DeleteImportFailure :exec
+DELETE FROM import_failures
+WHERE url = ?
+`
+
+func (q *Queries) DeleteImportFailure(ctx context.Context, url string) error {
+	_, err := q.db.ExecContext(ctx, deleteImportFailure, url)
+	return err
+}
+
+const deleteOldHistory = `-- name: DeleteOldHistory :execrows
 DELETE FROM history
 WHERE generated_at < strftime('%s', 'now') - ?
 `
 
-func (q *Queries) DeleteOldHistory(ctx context.Context, dollar_1 interface{}) error {
-	_, err := q.db.ExecContext(ctx, deleteOldHistory, dollar_1)
+func (q *Queries) DeleteOldHistory(ctx context.Context, dollar_1 interface{}) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldHistory, dollar_1)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteOldHistorySources = `-- name: DeleteOldHistorySources :exec
+DELETE FROM history_sources
+WHERE history_id IN (
+    SELECT id FROM history
+    WHERE generated_at < strftime('%s', 'now') - ?
+)
+`
+
+func (q *Queries) DeleteOldHistorySources(ctx context.Context, dollar_1 interface{}) error {
+	_, err := q.db.ExecContext(ctx, deleteOldHistorySources, dollar_1)
 	return err
 }
 
@@ -180,6 +309,70 @@ func (q *Queries) DeleteSourceByID(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteSourceTags = `-- name: DeleteSourceTags :exec
+DELETE FROM source_tags
+WHERE source_id = ?
+`
+
+func (q *Queries) DeleteSourceTags(ctx context.Context, sourceID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSourceTags, sourceID)
+	return err
+}
+
+const deleteUnusedContentVersions = `-- name: DeleteUnusedContentVersions :exec
+DELETE FROM content_versions
+WHERE hash NOT IN (SELECT hash FROM history_sources)
+`
+
+func (q *Queries) DeleteUnusedContentVersions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteUnusedContentVersions)
+	return err
+}
+
+const getContentVersion = `-- name: GetContentVersion :one
+SELECT content FROM content_versions
+WHERE hash = ?
+`
+
+func (q *Queries) GetContentVersion(ctx context.Context, hash string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getContentVersion, hash)
+	var content string
+	err := row.Scan(&content)
+	return content, err
+}
+
+const getHistory = `-- name: GetHistory :one
+SELECT id, preset_name, output_path, source_count, generated_at, format, content_hash FROM history
+WHERE id = ?
+`
+
+func (q *Queries) GetHistory(ctx context.Context, id int64) (History, error) {
+	row := q.db.QueryRowContext(ctx, getHistory, id)
+	var i History
+	err := row.Scan(
+		&i.ID,
+		&i.PresetName,
+		&i.OutputPath,
+		&i.SourceCount,
+		&i.GeneratedAt,
+		&i.Format,
+		&i.ContentHash,
+	)
+	return i, err
+}
+
+const getMaintenanceRun = `-- name: GetMaintenanceRun :one
+SELECT last_run_at FROM maintenance
+WHERE task = ?
+`
+
+func (q *Queries) GetMaintenanceRun(ctx context.Context, task string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getMaintenanceRun, task)
+	var last_run_at int64
+	err := row.Scan(&last_run_at)
+	return last_run_at, err
+}
+
 const getPreset = `-- name: GetPreset :one
 SELECT id, name, description, created_at, updated_at FROM presets
 WHERE id = ?
@@ -219,10 +412,10 @@ func (q *Queries) GetPresetByName(ctx context.Context, name string) (Preset, err
 }
 
 const getPresetSources = `-- name: GetPresetSources :many
-SELECT s.id, s.name, s.source_type, s.path, s.content, s.hash, s.enabled, s.created_at, s.updated_at FROM sources s
+SELECT s.id, s.name, s.source_type, s.path, s.content, s.hash, s.enabled, s.created_at, s.updated_at, s.options, s.pending, s.priority, s.refresh_policy FROM sources s
 INNER JOIN preset_sources ps ON s.id = ps.source_id
 WHERE ps.preset_id = ?
-ORDER BY s.created_at ASC
+ORDER BY s.priority DESC, s.created_at ASC
 `
 
 func (q *Queries) GetPresetSources(ctx context.Context, presetID int64) ([]Source, error) {
@@ -244,6 +437,10 @@ func (q *Queries) GetPresetSources(ctx context.Context, presetID int64) ([]Sourc
 			&i.Enabled,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Options,
+			&i.Pending,
+			&i.Priority,
+			&i.RefreshPolicy,
 		); err != nil {
 			return nil, err
 		}
@@ -259,7 +456,7 @@ func (q *Queries) GetPresetSources(ctx context.Context, presetID int64) ([]Sourc
 }
 
 const getSource = `-- name: GetSource :one
-SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at FROM sources
+SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at, options, pending, priority, refresh_policy FROM sources
 WHERE id = ?
 LIMIT 1
 `
@@ -277,13 +474,17 @@ func (q *Queries) GetSource(ctx context.Context, id int64) (Source, error) {
 		&i.Enabled,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Options,
+		&i.Pending,
+		&i.Priority,
+		&i.RefreshPolicy,
 	)
 	return i, err
 }
 
 const getSourceByHash = `-- name: GetSourceByHash :one
-SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at FROM sources
-WHERE hash = ?
+SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at, options, pending, priority, refresh_policy FROM sources
+WHERE hash = ? AND pending = 0
 LIMIT 1
 `
 
@@ -300,12 +501,16 @@ func (q *Queries) GetSourceByHash(ctx context.Context, hash string) (Source, err
 		&i.Enabled,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Options,
+		&i.Pending,
+		&i.Priority,
+		&i.RefreshPolicy,
 	)
 	return i, err
 }
 
 const getSourceByName = `-- name: GetSourceByName :one
-SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at FROM sources
+SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at, options, pending, priority, refresh_policy FROM sources
 WHERE name = ?
 LIMIT 1
 `
@@ -323,14 +528,46 @@ func (q *Queries) GetSourceByName(ctx context.Context, name string) (Source, err
 		&i.Enabled,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Options,
+		&i.Pending,
+		&i.Priority,
+		&i.RefreshPolicy,
 	)
 	return i, err
 }
 
+const listAllSourceTags = `-- name: ListAllSourceTags :many
+SELECT source_id, tag FROM source_tags
+ORDER BY source_id ASC, tag ASC
+`
+
+func (q *Queries) ListAllSourceTags(ctx context.Context) ([]SourceTag, error) {
+	rows, err := q.db.QueryContext(ctx, listAllSourceTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SourceTag
+	for rows.Next() {
+		var i SourceTag
+		if err := rows.Scan(&i.SourceID, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEnabledSources = `-- name: ListEnabledSources :many
-SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at FROM sources
+SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at, options, pending, priority, refresh_policy FROM sources
 WHERE enabled = 1
-ORDER BY created_at ASC
+ORDER BY priority DESC, created_at ASC
 `
 
 func (q *Queries) ListEnabledSources(ctx context.Context) ([]Source, error) {
@@ -352,6 +589,10 @@ func (q *Queries) ListEnabledSources(ctx context.Context) ([]Source, error) {
 			&i.Enabled,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Options,
+			&i.Pending,
+			&i.Priority,
+			&i.RefreshPolicy,
 		); err != nil {
 			return nil, err
 		}
@@ -367,8 +608,8 @@ func (q *Queries) ListEnabledSources(ctx context.Context) ([]Source, error) {
 }
 
 const listHistory = `-- name: ListHistory :many
-SELECT id, preset_name, output_path, source_count, generated_at FROM history
-ORDER BY generated_at DESC
+SELECT id, preset_name, output_path, source_count, generated_at, format, content_hash FROM history
+ORDER BY generated_at DESC, id DESC
 LIMIT ?
 `
 
@@ -387,6 +628,8 @@ func (q *Queries) ListHistory(ctx context.Context, limit int64) ([]History, erro
 			&i.OutputPath,
 			&i.SourceCount,
 			&i.GeneratedAt,
+			&i.Format,
+			&i.ContentHash,
 		); err != nil {
 			return nil, err
 		}
@@ -401,6 +644,116 @@ func (q *Queries) ListHistory(ctx context.Context, limit int64) ([]History, erro
 	return items, nil
 }
 
+const listHistorySources = `-- name: ListHistorySources :many
+SELECT history_id, position, source_id, name, source_type, path, hash FROM history_sources
+WHERE history_id = ?
+ORDER BY position ASC
+`
+
+func (q *Queries) ListHistorySources(ctx context.Context, historyID int64) ([]HistorySource, error) {
+	rows, err := q.db.QueryContext(ctx, listHistorySources, historyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HistorySource
+	for rows.Next() {
+		var i HistorySource
+		if err := rows.Scan(
+			&i.HistoryID,
+			&i.Position,
+			&i.SourceID,
+			&i.Name,
+			&i.SourceType,
+			&i.Path,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImportFailures = `-- name: ListImportFailures :many
+SELECT id, url, title, folder, error, failed_at FROM import_failures
+ORDER BY id ASC
+`
+
+func (q *Queries) ListImportFailures(ctx context.Context) ([]ImportFailure, error) {
+	rows, err := q.db.QueryContext(ctx, listImportFailures)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImportFailure
+	for rows.Next() {
+		var i ImportFailure
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Title,
+			&i.Folder,
+			&i.Error,
+			&i.FailedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLatestIncludedHashes = `-- name: ListLatestIncludedHashes :many
+SELECT hs.source_id, hs.hash
+FROM history_sources hs
+JOIN (
+    SELECT source_id, MAX(history_id) AS history_id
+    FROM history_sources
+    GROUP BY source_id
+) latest ON latest.source_id = hs.source_id AND latest.history_id = hs.history_id
+`
+
+type ListLatestIncludedHashesRow struct {
+	SourceID int64  `json:"source_id"`
+	Hash     string `json:"hash"`
+}
+
+func (q *Queries) ListLatestIncludedHashes(ctx context.Context) ([]ListLatestIncludedHashesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLatestIncludedHashes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLatestIncludedHashesRow
+	for rows.Next() {
+		var i ListLatestIncludedHashesRow
+		if err := rows.Scan(&i.SourceID, &i.Hash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPresets = `-- name: ListPresets :many
 SELECT id, name, description, created_at, updated_at FROM presets
 ORDER BY created_at DESC
@@ -435,8 +788,37 @@ func (q *Queries) ListPresets(ctx context.Context) ([]Preset, error) {
 	return items, nil
 }
 
+const listSourceTags = `-- name: ListSourceTags :many
+SELECT tag FROM source_tags
+WHERE source_id = ?
+ORDER BY tag ASC
+`
+
+func (q *Queries) ListSourceTags(ctx context.Context, sourceID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listSourceTags, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		items = append(items, tag)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSources = `-- name: ListSources :many
-SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at FROM sources
+SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at, options, pending, priority, refresh_policy FROM sources
 ORDER BY created_at DESC
 `
 
@@ -459,6 +841,10 @@ func (q *Queries) ListSources(ctx context.Context) ([]Source, error) {
 			&i.Enabled,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Options,
+			&i.Pending,
+			&i.Priority,
+			&i.RefreshPolicy,
 		); err != nil {
 			return nil, err
 		}
@@ -488,10 +874,44 @@ func (q *Queries) RemoveSourceFromPreset(ctx context.Context, arg RemoveSourceFr
 	return err
 }
 
+const renamePreset = `-- name: RenamePreset :exec
+UPDATE presets
+SET name = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?
+`
+
+type RenamePresetParams struct {
+	Name string `json:"name"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) RenamePreset(ctx context.Context, arg RenamePresetParams) error {
+	_, err := q.db.ExecContext(ctx, renamePreset, arg.Name, arg.ID)
+	return err
+}
+
+const setMaintenanceRun = `-- name: SetMaintenanceRun :exec
+INSERT INTO maintenance (task, last_run_at)
+VALUES (?, ?)
+ON CONFLICT (task) DO UPDATE SET last_run_at = excluded.last_run_at
+`
+
+type SetMaintenanceRunParams struct {
+	Task      string `json:"task"`
+	LastRunAt int64  `json:"last_run_at"`
+}
+
+func (q *Queries) SetMaintenanceRun(ctx context.Context, arg SetMaintenanceRunParams) error {
+	_, err := q.db.ExecContext(ctx, setMaintenanceRun, arg.Task, arg.LastRunAt)
+	return err
+}
+
 const updateSourceContent = `-- name: UpdateSourceContent :exec
 UPDATE sources
 SET content = ?,
     hash = ?,
+    pending = 0,
     updated_at = strftime('%s', 'now')
 WHERE id = ?
 `
@@ -523,3 +943,102 @@ func (q *Queries) UpdateSourceEnabled(ctx context.Context, arg UpdateSourceEnabl
 	_, err := q.db.ExecContext(ctx, updateSourceEnabled, arg.Enabled, arg.Name)
 	return err
 }
+
+const updateSourceName = `-- name: UpdateSourceName :exec
+UPDATE sources
+SET name = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?
+`
+
+type UpdateSourceNameParams struct {
+	Name string `json:"name"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSourceName(ctx context.Context, arg UpdateSourceNameParams) error {
+	_, err := q.db.ExecContext(ctx, updateSourceName, arg.Name, arg.ID)
+	return err
+}
+
+const updateSourceOptions = `-- name: UpdateSourceOptions :exec
+UPDATE sources
+SET options = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?
+`
+
+type UpdateSourceOptionsParams struct {
+	Options string `json:"options"`
+	ID      int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSourceOptions(ctx context.Context, arg UpdateSourceOptionsParams) error {
+	_, err := q.db.ExecContext(ctx, updateSourceOptions, arg.Options, arg.ID)
+	return err
+}
+
+const updateSourcePath = `-- name: UpdateSourcePath :exec
+UPDATE sources
+SET path = ?,
+    source_type = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?
+`
+
+type UpdateSourcePathParams struct {
+	Path       string `json:"path"`
+	SourceType string `json:"source_type"`
+	ID         int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSourcePath(ctx context.Context, arg UpdateSourcePathParams) error {
+	_, err := q.db.ExecContext(ctx, updateSourcePath, arg.Path, arg.SourceType, arg.ID)
+	return err
+}
+
+const updateSourceSettings = `-- name: UpdateSourceSettings :exec
+UPDATE sources
+SET priority = ?,
+    refresh_policy = ?,
+    updated_at = strftime('%s', 'now')
+WHERE id = ?
+`
+
+type UpdateSourceSettingsParams struct {
+	Priority      int64  `json:"priority"`
+	RefreshPolicy string `json:"refresh_policy"`
+	ID            int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSourceSettings(ctx context.Context, arg UpdateSourceSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, updateSourceSettings, arg.Priority, arg.RefreshPolicy, arg.ID)
+	return err
+}
+
+const upsertImportFailure = `-- name: UpsertImportFailure :exec
+INSERT INTO import_failures (url, title, folder, error)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (url) DO UPDATE SET
+    title = excluded.title,
+    folder = excluded.folder,
+    error = excluded.error,
+    failed_at = strftime('%s', 'now')
+`
+
+type UpsertImportFailureParams struct {
+	Url    string `json:"url"`
+	Title  string `json:"title"`
+	Folder string `json:"folder"`
+	Error  string `json:"error"`
+}
+
+func (q *Queries) UpsertImportFailure(ctx context.Context, arg UpsertImportFailureParams) error {
+	_, err := q.db.ExecContext(ctx, upsertImportFailure,
+		arg.Url,
+		arg.Title,
+		arg.Folder,
+		arg.Error,
+	)
+	return err
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"crypto/sha256"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 
 	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // Store manages all database operations for context-vacuum
@@ -40,7 +41,11 @@ func NewStore(dbPath string, logger *slog.Logger) (*Store, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
-	// Initialize schema
+	// Initialize schema, upgrading databases created by older versions first
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
 	if err := initSchema(db); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
@@ -74,6 +79,13 @@ func (s *Store) Queries() *dbgen.Queries {
 	return s.queries
 }
 
+// Refresh policies of a source: auto sources are re-read on every generation,
+// manual ones only when refreshed explicitly
+const (
+	RefreshAuto   = "auto"
+	RefreshManual = "manual"
+)
+
 // ComputeHash computes SHA256 hash of content
 func ComputeHash(content string) string {
 	h := sha256.New()
@@ -81,6 +93,194 @@ func ComputeHash(content string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// IsUniqueViolation reports whether err is from a UNIQUE constraint, like
+// the one on source names
+func IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// SaveSource updates the content and options of the source with the same
+// name, or creates it. The hash is computed from the content. It returns the
+// created source, or nil if an existing one was updated.
+func (s *Store) SaveSource(ctx context.Context, params dbgen.CreateSourceParams) (*dbgen.Source, error) {
+	params.Hash = ComputeHash(params.Content)
+
+	existing, err := s.queries.GetSourceByName(ctx, params.Name)
+	if err == nil {
+		s.logger.DebugContext(ctx, "updating existing source", "name", params.Name)
+		if err := s.queries.UpdateSourceContent(ctx, dbgen.UpdateSourceContentParams{
+			Content: params.Content,
+			Hash:    params.Hash,
+			ID:      existing.ID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update source: %w", err)
+		}
+		if err := s.queries.UpdateSourceOptions(ctx, dbgen.UpdateSourceOptionsParams{
+			Options: params.Options,
+			ID:      existing.ID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update source options: %w", err)
+		}
+		return nil, nil
+	}
+
+	created, err := s.queries.CreateSource(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create source: %w", err)
+	}
+	return &created, nil
+}
+
+// migrations upgrade databases created by older versions, in order. The
+// database's user_version records how many have been applied. New databases
+// get the latest schema from initSchema and skip them all, so schema.sql and
+// initSchema must always describe the result of applying every migration.
+//
+// SQLite cannot alter CHECK constraints in place, so changing the allowed
+// source types means rebuilding the sources table.
+var migrations = []string{
+	// 1: allow the pdf source type
+	`
+CREATE TABLE sources_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    source_type TEXT NOT NULL CHECK(source_type IN ('file', 'url', 'bookmark', 'pdf')),
+    path TEXT NOT NULL,
+    content TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 1 CHECK(enabled IN (0, 1)),
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+);
+INSERT INTO sources_new SELECT * FROM sources;
+DROP TABLE sources;
+ALTER TABLE sources_new RENAME TO sources;
+`,
+	// 2: allow the notebook source type and add per-source options
+	`
+CREATE TABLE sources_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    source_type TEXT NOT NULL CHECK(source_type IN ('file', 'url', 'bookmark', 'pdf', 'notebook')),
+    path TEXT NOT NULL,
+    content TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 1 CHECK(enabled IN (0, 1)),
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    options TEXT NOT NULL DEFAULT '{}'
+);
+INSERT INTO sources_new (id, name, source_type, path, content, hash, enabled, created_at, updated_at)
+SELECT id, name, source_type, path, content, hash, enabled, created_at, updated_at FROM sources;
+DROP TABLE sources;
+ALTER TABLE sources_new RENAME TO sources;
+`,
+	// 3: allow the openapi source type
+	`
+CREATE TABLE sources_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    source_type TEXT NOT NULL CHECK(source_type IN ('file', 'url', 'bookmark', 'pdf', 'notebook', 'openapi')),
+    path TEXT NOT NULL,
+    content TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 1 CHECK(enabled IN (0, 1)),
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    options TEXT NOT NULL DEFAULT '{}'
+);
+INSERT INTO sources_new SELECT * FROM sources;
+DROP TABLE sources;
+ALTER TABLE sources_new RENAME TO sources;
+`,
+	// 4: mark lazily imported placeholders
+	`
+ALTER TABLE sources ADD COLUMN pending INTEGER NOT NULL DEFAULT 0 CHECK(pending IN (0, 1));
+`,
+	// 5: record the format and content hash of each generation for replay.
+	// Databases from before history was added don't have the table yet.
+	`
+CREATE TABLE IF NOT EXISTS history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    preset_name TEXT,
+    output_path TEXT NOT NULL,
+    source_count INTEGER NOT NULL,
+    generated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+);
+ALTER TABLE history ADD COLUMN format TEXT NOT NULL DEFAULT '';
+ALTER TABLE history ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+`,
+	// 6: per-source priority and refresh policy
+	`
+ALTER TABLE sources ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sources ADD COLUMN refresh_policy TEXT NOT NULL DEFAULT 'auto' CHECK(refresh_policy IN ('auto', 'manual'));
+`,
+}
+
+// migrate applies pending migrations to an existing database
+func migrate(db *sql.DB) error {
+	ctx := context.Background()
+
+	// PRAGMAs are per connection, so pin one for the whole migration
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var tables int
+	if err := conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'sources'",
+	).Scan(&tables); err != nil {
+		return fmt.Errorf("failed to inspect schema: %w", err)
+	}
+
+	// A new database starts out at the latest version
+	if tables == 0 {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", len(migrations)))
+		return err
+	}
+
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if version >= len(migrations) {
+		return nil
+	}
+
+	// Rebuilding tables must not cascade deletes into preset_sources
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", i+1, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
 // initSchema initializes the database schema
 func initSchema(db *sql.DB) error {
 	schema := `
@@ -90,13 +290,21 @@ func initSchema(db *sql.DB) error {
 CREATE TABLE IF NOT EXISTS sources (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL UNIQUE,
-    source_type TEXT NOT NULL CHECK(source_type IN ('file', 'url', 'bookmark')),
+    source_type TEXT NOT NULL CHECK(source_type IN ('file', 'url', 'bookmark', 'pdf', 'notebook', 'openapi')),
     path TEXT NOT NULL,
     content TEXT NOT NULL,
     hash TEXT NOT NULL,
     enabled INTEGER NOT NULL DEFAULT 1 CHECK(enabled IN (0, 1)),
     created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
-    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    -- per-source parsing options as JSON, e.g. whether to include notebook outputs
+    options TEXT NOT NULL DEFAULT '{}',
+    -- 1 for placeholders whose content is fetched when first enabled or generated
+    pending INTEGER NOT NULL DEFAULT 0 CHECK(pending IN (0, 1)),
+    -- higher priorities come first in generated output and the token budget
+    priority INTEGER NOT NULL DEFAULT 0,
+    -- 'auto' re-reads the source on every generation, 'manual' only on refresh
+    refresh_policy TEXT NOT NULL DEFAULT 'auto' CHECK(refresh_policy IN ('auto', 'manual'))
 );
 
 -- Create index on enabled for fast filtering
@@ -132,11 +340,62 @@ CREATE TABLE IF NOT EXISTS history (
     preset_name TEXT,
     output_path TEXT NOT NULL,
     source_count INTEGER NOT NULL,
-    generated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+    generated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    -- output format, used to replay the generation
+    format TEXT NOT NULL DEFAULT '',
+    -- hash of the generated content, empty for entries recorded before snapshots
+    content_hash TEXT NOT NULL DEFAULT ''
 );
 
 -- Create index on generated_at for sorting
 CREATE INDEX IF NOT EXISTS idx_history_generated_at ON history(generated_at DESC);
+
+-- history_sources: the sources each generation included, in output order
+CREATE TABLE IF NOT EXISTS history_sources (
+    history_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    source_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    source_type TEXT NOT NULL,
+    path TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    PRIMARY KEY (history_id, position),
+    FOREIGN KEY (history_id) REFERENCES history(id) ON DELETE CASCADE,
+    FOREIGN KEY (hash) REFERENCES content_versions(hash)
+);
+
+-- Create index on hash for pruning unused content versions
+CREATE INDEX IF NOT EXISTS idx_history_sources_hash ON history_sources(hash);
+
+-- content_versions: source content as it was included in past generations, by hash
+CREATE TABLE IF NOT EXISTS content_versions (
+    hash TEXT PRIMARY KEY,
+    content TEXT NOT NULL
+);
+
+-- import_failures table: bookmarks that could not be fetched, retried by import-bookmarks --resume
+CREATE TABLE IF NOT EXISTS import_failures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    folder TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL,
+    failed_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+);
+
+-- source_tags: free-form labels for grouping and filtering sources
+CREATE TABLE IF NOT EXISTS source_tags (
+    source_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (source_id, tag),
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+-- maintenance: when periodic housekeeping, like pruning history, last ran
+CREATE TABLE IF NOT EXISTS maintenance (
+    task TEXT PRIMARY KEY,
+    last_run_at INTEGER NOT NULL
+);
 `
 
 	_, err := db.Exec(schema)
@@ -2,6 +2,7 @@ package storage_test
 
 import (
 	"context"
+	"database/sql"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -203,6 +204,109 @@ func TestStore_DeleteSource(t *testing.T) {
 	}
 }
 
+func TestStore_RenameToTakenName(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	var ids []int64
+	for _, name := range []string{"first", "second"} {
+		source, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+			Name:       name,
+			SourceType: "file",
+			Path:       "/path/to/" + name,
+			Content:    name,
+			Hash:       storage.ComputeHash(name),
+			Enabled:    1,
+		})
+		if err != nil {
+			t.Fatalf("failed to create source: %v", err)
+		}
+		ids = append(ids, source.ID)
+	}
+
+	err := store.Queries().UpdateSourceName(ctx, dbgen.UpdateSourceNameParams{
+		Name: "first",
+		ID:   ids[1],
+	})
+	if !storage.IsUniqueViolation(err) {
+		t.Errorf("expected a unique violation, got %v", err)
+	}
+
+	_, err = store.Queries().GetSourceByName(ctx, "missing")
+	if storage.IsUniqueViolation(err) {
+		t.Errorf("expected %v not to be a unique violation", err)
+	}
+}
+
+func TestStore_ApplyPreset(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// a and b start enabled, c disabled
+	ids := make(map[string]int64)
+	for _, name := range []string{"a", "b", "c"} {
+		enabled := int64(1)
+		if name == "c" {
+			enabled = 0
+		}
+		source, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+			Name:       name,
+			SourceType: "file",
+			Path:       "/path/to/" + name,
+			Content:    name,
+			Hash:       storage.ComputeHash(name),
+			Enabled:    enabled,
+		})
+		if err != nil {
+			t.Fatalf("failed to create source: %v", err)
+		}
+		ids[name] = source.ID
+	}
+
+	// The preset holds b and c
+	preset, err := store.Queries().CreatePreset(ctx, dbgen.CreatePresetParams{Name: "bc"})
+	if err != nil {
+		t.Fatalf("failed to create preset: %v", err)
+	}
+	for _, name := range []string{"b", "c"} {
+		if err := store.Queries().AddSourceToPreset(ctx, dbgen.AddSourceToPresetParams{
+			PresetID: preset.ID,
+			SourceID: ids[name],
+		}); err != nil {
+			t.Fatalf("failed to add source to preset: %v", err)
+		}
+	}
+
+	changed, err := store.Queries().ApplyPreset(ctx, preset.ID)
+	if err != nil {
+		t.Fatalf("failed to apply preset: %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("expected 2 sources to change, got %d", changed)
+	}
+
+	enabled, err := store.Queries().ListEnabledSources(ctx)
+	if err != nil {
+		t.Fatalf("failed to list enabled sources: %v", err)
+	}
+	if len(enabled) != 2 || enabled[0].Name != "b" || enabled[1].Name != "c" {
+		t.Errorf("expected b and c enabled, got %v", enabled)
+	}
+
+	// Applying it again changes nothing
+	changed, err = store.Queries().ApplyPreset(ctx, preset.ID)
+	if err != nil {
+		t.Fatalf("failed to apply preset: %v", err)
+	}
+	if changed != 0 {
+		t.Errorf("expected no changes, got %d", changed)
+	}
+}
+
 func TestComputeHash(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -230,3 +334,104 @@ func TestComputeHash(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_MigratesOldSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "old.db")
+
+	// Create a database with the original schema, which only allowed
+	// file, url and bookmark sources
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if _, err := db.Exec(`
+CREATE TABLE sources (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    source_type TEXT NOT NULL CHECK(source_type IN ('file', 'url', 'bookmark')),
+    path TEXT NOT NULL,
+    content TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 1 CHECK(enabled IN (0, 1)),
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+);
+CREATE TABLE presets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    updated_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+);
+CREATE TABLE preset_sources (
+    preset_id INTEGER NOT NULL,
+    source_id INTEGER NOT NULL,
+    PRIMARY KEY (preset_id, source_id),
+    FOREIGN KEY (preset_id) REFERENCES presets(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+INSERT INTO sources (name, source_type, path, content, hash) VALUES ('old-source', 'file', '/old', 'old', 'hash');
+INSERT INTO presets (name) VALUES ('old-preset');
+INSERT INTO preset_sources (preset_id, source_id) VALUES (1, 1);
+`); err != nil {
+		t.Fatalf("failed to create old schema: %v", err)
+	}
+	db.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelError, // Quiet during tests
+	}))
+
+	store, err := storage.NewStore(dbPath, logger)
+	if err != nil {
+		t.Fatalf("failed to open old database: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// Existing data survives the migration
+	old, err := store.Queries().GetSourceByName(ctx, "old-source")
+	if err != nil {
+		t.Fatalf("failed to get migrated source: %v", err)
+	}
+	if old.Options != "{}" || old.Pending != 0 || old.Priority != 0 || old.RefreshPolicy != storage.RefreshAuto {
+		t.Errorf("expected new columns to get their defaults, got %+v", old)
+	}
+
+	presetSources, err := store.Queries().GetPresetSources(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to get preset sources: %v", err)
+	}
+	if len(presetSources) != 1 || presetSources[0].ID != old.ID {
+		t.Errorf("expected preset to keep its source, got %+v", presetSources)
+	}
+
+	// New source types are accepted
+	if _, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+		Name:       "pdf-source",
+		SourceType: "pdf",
+		Path:       "/spec.pdf",
+		Content:    "text",
+		Hash:       storage.ComputeHash("text"),
+		Enabled:    1,
+	}); err != nil {
+		t.Errorf("failed to create pdf source after migration: %v", err)
+	}
+
+	// History records what it needs for replay
+	entry, err := store.Queries().CreateHistory(ctx, dbgen.CreateHistoryParams{
+		OutputPath:  "CLAUDE.md",
+		SourceCount: 1,
+		Format:      "claude",
+		ContentHash: storage.ComputeHash("output"),
+		GeneratedAt: 1700000000,
+	})
+	if err != nil {
+		t.Fatalf("failed to record history after migration: %v", err)
+	}
+	if entry.Format != "claude" || entry.GeneratedAt != 1700000000 {
+		t.Errorf("unexpected history entry: %+v", entry)
+	}
+}
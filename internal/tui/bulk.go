@@ -0,0 +1,393 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkPrompt is what the bulk input is asking for
+type bulkPrompt int
+
+const (
+	bulkPromptNone bulkPrompt = iota
+	bulkPromptTag
+	bulkPromptPreset
+)
+
+func newBulkInput() textinput.Model {
+	input := textinput.New()
+	input.CharLimit = 200
+	input.Width = 50
+	return input
+}
+
+// withTx runs fn in a transaction, so related changes apply together or
+// not at all
+func (m model) withTx(ctx context.Context, fn func(q *dbgen.Queries) error) error {
+	tx, err := m.store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(m.store.Queries().WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// selectedIDs returns the marked sources plus, in visual mode, the visible
+// sources between the anchor and the cursor
+func (m model) selectedIDs() map[int64]bool {
+	ids := make(map[int64]bool, len(m.marked))
+	for id := range m.marked {
+		ids[id] = true
+	}
+	if !m.visual || len(m.visible) == 0 {
+		return ids
+	}
+
+	// The anchor may have been filtered out, leaving just the cursor
+	anchor := m.cursor
+	for i, idx := range m.visible {
+		if m.sources[idx].ID == m.anchorID {
+			anchor = i
+		}
+	}
+	for i := min(anchor, m.cursor); i <= max(anchor, m.cursor); i++ {
+		ids[m.sources[m.visible[i]].ID] = true
+	}
+	return ids
+}
+
+// hasSelection reports whether bulk operations apply to a selection
+func (m model) hasSelection() bool {
+	return m.visual || len(m.marked) > 0
+}
+
+// targets returns the selected sources in list order, or the highlighted
+// source when nothing is selected
+func (m model) targets() []dbgen.Source {
+	if !m.hasSelection() {
+		if source, ok := m.selected(); ok {
+			return []dbgen.Source{source}
+		}
+		return nil
+	}
+
+	ids := m.selectedIDs()
+	var targets []dbgen.Source
+	for _, source := range m.sources {
+		if ids[source.ID] {
+			targets = append(targets, source)
+		}
+	}
+	return targets
+}
+
+// startVisual starts selecting a range from the highlighted source
+func (m *model) startVisual() {
+	if source, ok := m.selected(); ok {
+		m.visual = true
+		m.anchorID = source.ID
+	}
+}
+
+// endVisual keeps the visual range selected and leaves visual mode
+func (m *model) endVisual() {
+	m.marked = m.selectedIDs()
+	m.visual = false
+}
+
+func (m *model) clearSelection() {
+	m.marked = make(map[int64]bool)
+	m.visual = false
+}
+
+// toggleMark selects or deselects the highlighted source
+func (m *model) toggleMark() {
+	m.endVisual()
+	if source, ok := m.selected(); ok {
+		if m.marked[source.ID] {
+			delete(m.marked, source.ID)
+		} else {
+			m.marked[source.ID] = true
+		}
+	}
+}
+
+// selectAllVisible selects every visible source, or deselects them if
+// they are all selected already
+func (m *model) selectAllVisible() {
+	m.endVisual()
+
+	all := true
+	for _, idx := range m.visible {
+		if !m.marked[m.sources[idx].ID] {
+			all = false
+			break
+		}
+	}
+	for _, idx := range m.visible {
+		if all {
+			delete(m.marked, m.sources[idx].ID)
+		} else {
+			m.marked[m.sources[idx].ID] = true
+		}
+	}
+}
+
+// reloadSources reads the sources and presets again after a bulk change
+func (m *model) reloadSources(ctx context.Context) error {
+	sources, err := m.store.Queries().ListSources(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list sources: %w", err)
+	}
+	m.setSources(sources)
+	return m.loadPresets(ctx)
+}
+
+// bulkToggle enables all the targets if any is disabled and disables them
+// otherwise, returning a status message and a command fetching any pending
+// sources that were enabled
+func (m *model) bulkToggle(targets []dbgen.Source) (string, tea.Cmd) {
+	enable := int64(0)
+	for _, source := range targets {
+		if source.Enabled == 0 {
+			enable = 1
+			break
+		}
+	}
+
+	ctx := context.Background()
+	if err := m.withTx(ctx, func(q *dbgen.Queries) error {
+		for _, source := range targets {
+			if err := q.UpdateSourceEnabled(ctx, dbgen.UpdateSourceEnabledParams{
+				Enabled: enable,
+				Name:    source.Name,
+			}); err != nil {
+				return fmt.Errorf("failed to update %s: %w", source.Name, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+
+	m.clearSelection()
+	if err := m.reloadSources(ctx); err != nil {
+		return fmt.Sprintf("Error reloading: %v", err), nil
+	}
+
+	status := "Disabled"
+	var cmds []tea.Cmd
+	if enable == 1 {
+		status = "Enabled"
+		for _, source := range targets {
+			if _, busy := m.jobs.forSource(source.ID); source.Pending == 1 && !busy {
+				cmds = append(cmds, m.fetchPending(source))
+			}
+		}
+	}
+
+	msg := fmt.Sprintf("✓ %s %d sources", status, len(targets))
+	if len(cmds) > 0 {
+		msg += " • fetching in background"
+	}
+	return msg, tea.Batch(cmds...)
+}
+
+// bulkDelete deletes sources by ID
+func (m *model) bulkDelete(ids []int64) string {
+	ctx := context.Background()
+	if err := m.withTx(ctx, func(q *dbgen.Queries) error {
+		for _, id := range ids {
+			if err := q.DeleteSourceByID(ctx, id); err != nil {
+				return fmt.Errorf("failed to delete source: %w", err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Sprintf("Error deleting: %v", err)
+	}
+
+	m.clearSelection()
+	if err := m.reloadSources(ctx); err != nil {
+		return fmt.Sprintf("Error reloading: %v", err)
+	}
+	return fmt.Sprintf("✓ Deleted %d sources", len(ids))
+}
+
+// bulkTag adds comma-separated tags to the targets
+func (m *model) bulkTag(targets []dbgen.Source, input string) string {
+	var tags []string
+	for _, tag := range strings.Split(input, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return "Error: Tag cannot be empty"
+	}
+
+	ctx := context.Background()
+	if err := m.withTx(ctx, func(q *dbgen.Queries) error {
+		for _, source := range targets {
+			for _, tag := range tags {
+				if err := q.AddSourceTag(ctx, dbgen.AddSourceTagParams{
+					SourceID: source.ID,
+					Tag:      tag,
+				}); err != nil {
+					return fmt.Errorf("failed to tag %s: %w", source.Name, err)
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	m.clearSelection()
+	return fmt.Sprintf("✓ Tagged %d sources with %s", len(targets), strings.Join(tags, ", "))
+}
+
+// bulkAddToPreset adds the targets to a preset, creating it if needed
+func (m *model) bulkAddToPreset(targets []dbgen.Source, name string) string {
+	ctx := context.Background()
+	created := false
+	added := 0
+
+	if err := m.withTx(ctx, func(q *dbgen.Queries) error {
+		preset, err := q.GetPresetByName(ctx, name)
+		if err != nil {
+			preset, err = q.CreatePreset(ctx, dbgen.CreatePresetParams{Name: name})
+			if err != nil {
+				return fmt.Errorf("failed to create preset: %w", err)
+			}
+			created = true
+		}
+
+		members, err := q.GetPresetSources(ctx, preset.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get preset sources: %w", err)
+		}
+		existing := make(map[int64]bool, len(members))
+		for _, member := range members {
+			existing[member.ID] = true
+		}
+
+		for _, source := range targets {
+			if existing[source.ID] {
+				continue
+			}
+			if err := q.AddSourceToPreset(ctx, dbgen.AddSourceToPresetParams{
+				PresetID: preset.ID,
+				SourceID: source.ID,
+			}); err != nil {
+				return fmt.Errorf("failed to add source to preset: %w", err)
+			}
+			added++
+		}
+		return nil
+	}); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	m.clearSelection()
+	if err := m.loadPresets(ctx); err != nil {
+		return fmt.Sprintf("Error reloading presets: %v", err)
+	}
+
+	msg := fmt.Sprintf("✓ Added %d sources to preset %s", added, name)
+	if created {
+		msg += " (new)"
+	}
+	return msg
+}
+
+// openBulkPrompt asks for the tags or preset to apply to the targets
+func (m *model) openBulkPrompt(prompt bulkPrompt) tea.Cmd {
+	m.endVisual()
+	m.bulkPrompt = prompt
+	m.bulkInput.SetValue("")
+	m.message = ""
+
+	m.bulkInput.Placeholder = "e.g. docs, backend"
+	if prompt == bulkPromptPreset {
+		m.bulkInput.Placeholder = "existing or new preset name"
+	}
+	return m.bulkInput.Focus()
+}
+
+func (m model) updateBulkPrompt(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "ctrl+c":
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Cancel):
+			m.bulkPrompt = bulkPromptNone
+			m.bulkInput.Blur()
+			m.message = "Cancelled"
+			return m, nil
+
+		case key.Matches(msg, m.keys.Confirm):
+			value := strings.TrimSpace(m.bulkInput.Value())
+			targets := m.targets()
+			if value == "" {
+				m.message = "Error: Name cannot be empty"
+				return m, nil
+			}
+
+			if m.bulkPrompt == bulkPromptTag {
+				m.message = m.bulkTag(targets, value)
+			} else {
+				m.message = m.bulkAddToPreset(targets, value)
+			}
+			m.bulkPrompt = bulkPromptNone
+			m.bulkInput.Blur()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.bulkInput, cmd = m.bulkInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) renderBulkPrompt() string {
+	var b strings.Builder
+
+	n := len(m.targets())
+	label := fmt.Sprintf("Tags to add to %d source(s), comma-separated:", n)
+	if m.bulkPrompt == bulkPromptPreset {
+		label = fmt.Sprintf("Add %d source(s) to preset:", n)
+	}
+
+	b.WriteString(inputLabelStyle.Render(label))
+	b.WriteString("\n")
+	b.WriteString(m.bulkInput.View())
+	b.WriteString("\n\n")
+
+	if m.bulkPrompt == bulkPromptPreset && len(m.presets) > 0 {
+		names := make([]string, 0, len(m.presets))
+		for _, entry := range m.presets {
+			names = append(names, entry.preset.Name)
+		}
+		b.WriteString(helpStyle.Render("Presets: " + strings.Join(names, ", ")))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render(m.help.ShortHelpView([]key.Binding{withDesc(m.keys.Confirm, "apply"), m.keys.Cancel})))
+
+	return modalStyle.Render(b.String())
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newBulkTestModel returns a model over sources named a to e, all enabled
+func newBulkTestModel(t *testing.T) (model, *storage.Store) {
+	t.Helper()
+	return newTestModel(t, testSources("a", "b", "c", "d", "e")...)
+}
+
+var (
+	shiftDown = tea.KeyMsg{Type: tea.KeyShiftDown}
+	selectAll = tea.KeyMsg{Type: tea.KeyCtrlA}
+)
+
+func TestBulkToggleAndDelete(t *testing.T) {
+	m, store := newBulkTestModel(t)
+	ctx := context.Background()
+
+	// Select b to d with shift+down, then disable them together
+	m = press(m, down, shiftDown, shiftDown)
+	if got := len(m.targets()); got != 3 {
+		t.Fatalf("expected 3 selected sources, got %d", got)
+	}
+	m = press(m, space)
+
+	if got := enabledNames(t, store); len(got) != 2 || got[0] != "a" || got[1] != "e" {
+		t.Errorf("expected only a and e enabled, got %v", got)
+	}
+	if m.hasSelection() {
+		t.Error("expected the selection to be cleared after the toggle")
+	}
+
+	// Select everything visible and delete it with a single confirmation
+	m = press(m, selectAll, runes("d"))
+	if !m.deleteConfirm || len(m.deleteIDs) != 5 {
+		t.Fatalf("expected to confirm deleting 5 sources, got %v", m.deleteIDs)
+	}
+	m = press(m, runes("y"))
+
+	count, err := store.Queries().CountSources(ctx)
+	if err != nil {
+		t.Fatalf("failed to count sources: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected every source deleted, %d left", count)
+	}
+	if m.message != "✓ Deleted 5 sources" {
+		t.Errorf("unexpected message %q", m.message)
+	}
+}
+
+func TestBulkTagAndAddToPreset(t *testing.T) {
+	m, store := newBulkTestModel(t)
+	ctx := context.Background()
+
+	// Visual mode from a down to c, kept with v, plus e marked with x
+	m = press(m, runes("v"), down, down, runes("v"), down, down, runes("x"))
+	targets := m.targets()
+	if len(targets) != 4 || targets[3].Name != "e" {
+		t.Fatalf("expected a, b, c and e selected, got %v", targets)
+	}
+
+	m = press(m, runes("T"), runes("docs, api"), enter)
+	if m.message != "✓ Tagged 4 sources with docs, api" {
+		t.Errorf("unexpected message %q", m.message)
+	}
+	tags, err := store.Queries().ListSourceTags(ctx, targets[3].ID)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "api" || tags[1] != "docs" {
+		t.Errorf("expected e tagged api and docs, got %v", tags)
+	}
+
+	// Adding to a missing preset creates it
+	m = press(m, selectAll, runes("P"), runes("everything"), enter)
+	if m.message != "✓ Added 5 sources to preset everything (new)" {
+		t.Errorf("unexpected message %q", m.message)
+	}
+	preset, err := store.Queries().GetPresetByName(ctx, "everything")
+	if err != nil {
+		t.Fatalf("preset was not created: %v", err)
+	}
+	members, err := store.Queries().GetPresetSources(ctx, preset.ID)
+	if err != nil {
+		t.Fatalf("failed to get preset sources: %v", err)
+	}
+	if len(members) != 5 {
+		t.Errorf("expected 5 sources in the preset, got %d", len(members))
+	}
+	if m.activePreset() != "everything" {
+		t.Errorf("expected the new preset to be active, got %q", m.activePreset())
+	}
+}
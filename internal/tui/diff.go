@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loadChanges marks the sources whose content changed since they were last
+// included in a generation. Failing only loses the marks, so it's logged.
+func (m *model) loadChanges() {
+	ctx := context.Background()
+	changed, err := m.generator.Changed(ctx, m.sources)
+	if err != nil {
+		m.logger.WarnContext(ctx, "failed to find changed sources", "error", err)
+		return
+	}
+	m.changed = changed
+}
+
+// openDiff shows how the highlighted source changed since its last generation
+func (m *model) openDiff() {
+	source, ok := m.selected()
+	if !ok {
+		return
+	}
+	hash, ok := m.changed[source.ID]
+	if !ok {
+		m.message = fmt.Sprintf("%s is unchanged since it was last generated", source.Name)
+		return
+	}
+
+	diff, err := m.generator.Diff(context.Background(), source, hash)
+	if err != nil {
+		m.message = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	m.diffName = source.Name
+	m.diffView = viewport.New(0, 0)
+	m.diffView.SetHorizontalStep(4)
+	m.resizeDiff()
+	m.diffView.SetContent(colorDiff(diff))
+	m.diffOpen = true
+	m.message = ""
+}
+
+// resizeDiff fits the diff viewport below the title and above the help line
+func (m *model) resizeDiff() {
+	m.diffView.Width = max(20, m.width)
+	m.diffView.Height = max(5, m.height-6)
+}
+
+func (m model) updateDiff(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "ctrl+c":
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Cancel, m.keys.Diff), msg.String() == "q":
+			m.diffOpen = false
+			return m, nil
+		case key.Matches(msg, previewTop):
+			m.diffView.GotoTop()
+			return m, nil
+		case key.Matches(msg, previewBottom):
+			m.diffView.GotoBottom()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.diffView, cmd = m.diffView.Update(msg)
+	return m, cmd
+}
+
+// colorDiff colors the lines of a unified diff
+func colorDiff(diff string) string {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = diffFileStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = diffHunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = diffAddStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = diffRemoveStyle.Render(line)
+		default:
+			lines[i] = line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) renderDiff() string {
+	var b strings.Builder
+
+	b.WriteString(inputLabelStyle.Render("Changes to " + m.diffName + " since it was last generated"))
+	b.WriteString("\n\n")
+	b.WriteString(m.diffView.View())
+	b.WriteString("\n\n")
+
+	scroll := ""
+	if m.diffView.TotalLineCount() > m.diffView.Height {
+		scroll = fmt.Sprintf("%3.f%% • ", m.diffView.ScrollPercent()*100)
+	}
+	b.WriteString(helpStyle.Render(scroll + m.help.ShortHelpView([]key.Binding{
+		scrollKeys, pageKeys, panKeys, previewTop, previewBottom,
+		withDesc(m.keys.Cancel, "close"), withDesc(m.keys.Diff, "close"),
+	})))
+	b.WriteString("\n")
+
+	return b.String()
+}
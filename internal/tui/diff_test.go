@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDiffSinceGeneration(t *testing.T) {
+	m, store := newBulkTestModel(t)
+	ctx := context.Background()
+
+	// Nothing has been generated yet, so nothing is marked
+	if len(m.changed) != 0 {
+		t.Fatalf("expected no changes before a generation, got %v", m.changed)
+	}
+
+	result, err := m.generator.Render(ctx, generator.GenerateOptions{})
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	if _, err := m.generator.Record(ctx, generator.GenerateOptions{}, result); err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+
+	a := m.sources[0]
+	if err := store.Queries().UpdateSourceContent(ctx, dbgen.UpdateSourceContentParams{
+		Content: "a changed",
+		Hash:    storage.ComputeHash("a changed"),
+		ID:      a.ID,
+	}); err != nil {
+		t.Fatalf("failed to update source: %v", err)
+	}
+
+	m = press(m, runes("r"))
+	if len(m.changed) != 1 {
+		t.Fatalf("expected only a marked changed, got %v", m.changed)
+	}
+	if view := m.View(); strings.Count(view, "● changed") != 1 {
+		t.Errorf("expected one source marked changed, got:\n%s", view)
+	}
+
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = press(next.(model), runes("D"))
+	if !m.diffOpen {
+		t.Fatalf("expected the diff to open, message %q", m.message)
+	}
+	view := m.View()
+	for _, want := range []string{"Changes to a", "-a", "+a changed"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the diff view, got:\n%s", want, view)
+		}
+	}
+
+	// Unchanged sources have nothing to show
+	m = press(m, runes("D"), down, runes("D"))
+	if m.diffOpen || m.message != "b is unchanged since it was last generated" {
+		t.Errorf("expected no diff for b, got message %q", m.message)
+	}
+}
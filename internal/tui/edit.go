@@ -0,0 +1,421 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/redact"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editField is an input in the edit dialog
+type editField int
+
+const (
+	editName editField = iota
+	editPath
+	editTags
+	editPriority
+	editRefreshPolicy
+	editRedactAllow
+	editNotebookOutputs
+	editOpenAPITags
+	editOpenAPIPrefixes
+)
+
+var editLabels = map[editField]string{
+	editName:            "Name:",
+	editPath:            "Path or URL:",
+	editTags:            "Tags, comma-separated:",
+	editPriority:        "Priority, higher comes first in generated output:",
+	editRefreshPolicy:   "Refresh: auto (re-read when generating) or manual (only on refresh):",
+	editRedactAllow:     "Keep from redaction (rule names or regexps), comma-separated:",
+	editNotebookOutputs: "Cell outputs, max characters each (empty leaves them out):",
+	editOpenAPITags:     "OpenAPI tags to keep, comma-separated (empty keeps all):",
+	editOpenAPIPrefixes: "OpenAPI path prefixes to keep, comma-separated (empty keeps all):",
+}
+
+// editDialog edits the name, path, tags, priority, refresh policy and options
+// of a source. Only the options that apply to the source's type are shown.
+type editDialog struct {
+	open   bool
+	source dbgen.Source
+	tags   []string // as loaded, to tell whether they changed
+	fields []editField
+	inputs []textinput.Model
+	focus  int
+}
+
+// sourceEdit is a validated change from the edit dialog
+type sourceEdit struct {
+	source        dbgen.Source // as it was before the edit
+	name          string
+	path          string
+	sourceType    string
+	tags          []string
+	retag         bool // the tags changed
+	priority      int64
+	refreshPolicy string
+	opts          parser.SourceOptions
+	refetch       bool // the path or parsing options changed
+}
+
+// editedMsg reports an edit saved in the background after re-fetching
+type editedMsg struct {
+	name string
+	err  error
+}
+
+// openEditDialog fills the dialog in from the highlighted source
+func (m *model) openEditDialog() tea.Cmd {
+	source, ok := m.selected()
+	if !ok {
+		return nil
+	}
+	if running, busy := m.jobs.forSource(source.ID); busy {
+		m.message = fmt.Sprintf("%s is busy %s", source.Name, running.kind.verb())
+		return nil
+	}
+
+	ctx := context.Background()
+	tags, err := m.store.Queries().ListSourceTags(ctx, source.ID)
+	if err != nil {
+		m.message = fmt.Sprintf("Error: failed to list tags: %v", err)
+		return nil
+	}
+	opts, err := parser.DecodeOptions(source.Options)
+	if err != nil {
+		m.message = fmt.Sprintf("Error: %v", err)
+		return nil
+	}
+
+	values := map[editField]string{
+		editName:            source.Name,
+		editPath:            source.Path,
+		editTags:            strings.Join(tags, ", "),
+		editPriority:        strconv.FormatInt(source.Priority, 10),
+		editRefreshPolicy:   source.RefreshPolicy,
+		editRedactAllow:     strings.Join(opts.RedactAllow, ", "),
+		editOpenAPITags:     strings.Join(opts.OpenAPITags, ", "),
+		editOpenAPIPrefixes: strings.Join(opts.OpenAPIPathPrefixes, ", "),
+	}
+	if opts.NotebookOutputs {
+		limit := opts.NotebookOutputLimit
+		if limit <= 0 {
+			limit = parser.DefaultNotebookOutputLimit
+		}
+		values[editNotebookOutputs] = strconv.Itoa(limit)
+	}
+
+	fields := []editField{editName, editPath, editTags, editPriority, editRefreshPolicy, editRedactAllow}
+	switch source.SourceType {
+	case "notebook":
+		fields = append(fields, editNotebookOutputs)
+	case "openapi":
+		fields = append(fields, editOpenAPITags, editOpenAPIPrefixes)
+	}
+
+	inputs := make([]textinput.Model, len(fields))
+	for i, field := range fields {
+		input := textinput.New()
+		input.CharLimit = 500
+		input.Width = 50
+		input.SetValue(values[field])
+		inputs[i] = input
+	}
+
+	m.edit = editDialog{
+		open:   true,
+		source: source,
+		tags:   tags,
+		fields: fields,
+		inputs: inputs,
+	}
+	m.message = ""
+	return m.focusEditField(0)
+}
+
+// focusEditField moves focus to the input at index
+func (m *model) focusEditField(index int) tea.Cmd {
+	m.edit.inputs[m.edit.focus].Blur()
+	m.edit.focus = index
+	return m.edit.inputs[index].Focus()
+}
+
+// editValue returns the trimmed value of a field
+func (d editDialog) editValue(field editField) string {
+	if i := slices.Index(d.fields, field); i >= 0 {
+		return strings.TrimSpace(d.inputs[i].Value())
+	}
+	return ""
+}
+
+// splitList splits a comma-separated value, dropping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// buildEdit validates the dialog's values
+func (m model) buildEdit() (sourceEdit, error) {
+	d := m.edit
+	e := sourceEdit{
+		source:     d.source,
+		name:       d.editValue(editName),
+		path:       d.editValue(editPath),
+		sourceType: d.source.SourceType,
+		tags:       splitList(d.editValue(editTags)),
+	}
+	e.retag = !slices.Equal(e.tags, d.tags)
+
+	if e.name == "" {
+		return e, fmt.Errorf("name cannot be empty")
+	}
+	if e.path == "" {
+		return e, fmt.Errorf("path cannot be empty")
+	}
+	priority, err := strconv.ParseInt(d.editValue(editPriority), 10, 64)
+	if err != nil {
+		return e, fmt.Errorf("priority must be a whole number")
+	}
+	e.priority = priority
+	e.refreshPolicy = strings.ToLower(d.editValue(editRefreshPolicy))
+	if e.refreshPolicy != storage.RefreshAuto && e.refreshPolicy != storage.RefreshManual {
+		return e, fmt.Errorf("refresh must be %s or %s", storage.RefreshAuto, storage.RefreshManual)
+	}
+
+	if e.name != d.source.Name {
+		if _, err := m.store.Queries().GetSourceByName(context.Background(), e.name); err == nil {
+			return e, fmt.Errorf("source %q already exists", e.name)
+		}
+	}
+
+	// Local paths are stored absolute, like when adding
+	if e.path != d.source.Path {
+		if !parser.IsURL(e.path) {
+			abs, err := filepath.Abs(e.path)
+			if err != nil {
+				return e, fmt.Errorf("failed to resolve path: %w", err)
+			}
+			e.path = abs
+		}
+		e.sourceType = parser.DetectSourceType(e.path)
+	}
+
+	opts, err := parser.DecodeOptions(d.source.Options)
+	if err != nil {
+		return e, err
+	}
+	previous := opts
+
+	opts.RedactAllow = splitList(d.editValue(editRedactAllow))
+	if _, err := redact.NewAllowlist(opts.RedactAllow); err != nil {
+		return e, err
+	}
+
+	if slices.Contains(d.fields, editNotebookOutputs) {
+		opts.NotebookOutputs = false
+		opts.NotebookOutputLimit = 0
+		if value := d.editValue(editNotebookOutputs); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit <= 0 {
+				return e, fmt.Errorf("cell outputs must be a number of characters")
+			}
+			opts.NotebookOutputs = true
+			opts.NotebookOutputLimit = limit
+		}
+	}
+	if slices.Contains(d.fields, editOpenAPITags) {
+		opts.OpenAPITags = splitList(d.editValue(editOpenAPITags))
+		opts.OpenAPIPathPrefixes = splitList(d.editValue(editOpenAPIPrefixes))
+	}
+	e.opts = opts
+
+	// Redaction applies when generating, but the other options change
+	// what is parsed
+	e.refetch = e.path != d.source.Path ||
+		opts.NotebookOutputs != previous.NotebookOutputs ||
+		opts.NotebookOutputLimit != previous.NotebookOutputLimit ||
+		!slices.Equal(opts.OpenAPITags, previous.OpenAPITags) ||
+		!slices.Equal(opts.OpenAPIPathPrefixes, previous.OpenAPIPathPrefixes)
+
+	return e, nil
+}
+
+// applyEdit stores an edit in one transaction, with the re-fetched content
+// if the edit needed it
+func (m model) applyEdit(ctx context.Context, e sourceEdit, content string) error {
+	id := e.source.ID
+	return m.withTx(ctx, func(q *dbgen.Queries) error {
+		if e.name != e.source.Name {
+			// Another source may have taken the name since the dialog checked
+			if err := q.UpdateSourceName(ctx, dbgen.UpdateSourceNameParams{Name: e.name, ID: id}); err != nil {
+				if storage.IsUniqueViolation(err) {
+					return fmt.Errorf("source %q already exists", e.name)
+				}
+				return fmt.Errorf("failed to rename source: %w", err)
+			}
+		}
+
+		if e.path != e.source.Path || e.sourceType != e.source.SourceType {
+			if err := q.UpdateSourcePath(ctx, dbgen.UpdateSourcePathParams{
+				Path:       e.path,
+				SourceType: e.sourceType,
+				ID:         id,
+			}); err != nil {
+				return fmt.Errorf("failed to update path: %w", err)
+			}
+		}
+
+		if e.refetch {
+			if err := q.UpdateSourceContent(ctx, dbgen.UpdateSourceContentParams{
+				Content: content,
+				Hash:    storage.ComputeHash(content),
+				ID:      id,
+			}); err != nil {
+				return fmt.Errorf("failed to update content: %w", err)
+			}
+		}
+
+		if e.priority != e.source.Priority || e.refreshPolicy != e.source.RefreshPolicy {
+			if err := q.UpdateSourceSettings(ctx, dbgen.UpdateSourceSettingsParams{
+				Priority:      e.priority,
+				RefreshPolicy: e.refreshPolicy,
+				ID:            id,
+			}); err != nil {
+				return fmt.Errorf("failed to update priority and refresh policy: %w", err)
+			}
+		}
+
+		if options := e.opts.Encode(); options != e.source.Options {
+			if err := q.UpdateSourceOptions(ctx, dbgen.UpdateSourceOptionsParams{
+				Options: options,
+				ID:      id,
+			}); err != nil {
+				return fmt.Errorf("failed to update options: %w", err)
+			}
+		}
+
+		if e.retag {
+			if err := q.DeleteSourceTags(ctx, id); err != nil {
+				return fmt.Errorf("failed to clear tags: %w", err)
+			}
+			for _, tag := range e.tags {
+				if err := q.AddSourceTag(ctx, dbgen.AddSourceTagParams{SourceID: id, Tag: tag}); err != nil {
+					return fmt.Errorf("failed to add tag: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// saveEdit stores the edit right away, or re-fetches the source in the
+// background first when its path or parsing options changed
+func (m model) saveEdit() (tea.Model, tea.Cmd) {
+	e, err := m.buildEdit()
+	if err != nil {
+		m.message = fmt.Sprintf("Error: %v", err)
+		return m, nil
+	}
+	m.edit.open = false
+	m.message = ""
+
+	if !e.refetch {
+		ctx := context.Background()
+		if err := m.applyEdit(ctx, e, ""); err != nil {
+			m.message = fmt.Sprintf("Error saving %s: %v", e.name, err)
+			return m, nil
+		}
+		if err := m.reloadSources(ctx); err != nil {
+			m.message = fmt.Sprintf("Error reloading: %v", err)
+			return m, nil
+		}
+		m.message = "✓ Saved " + e.name
+		return m, nil
+	}
+
+	return m, m.startJob(jobEdit, []int64{e.source.ID}, "Saving "+e.name, func(ctx context.Context) tea.Msg {
+		content, err := m.parser.ParseSourceContext(ctx, e.sourceType, e.path, e.opts)
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err == nil {
+			err = m.applyEdit(ctx, e, content)
+		}
+		return editedMsg{name: e.name, err: err}
+	})
+}
+
+func (m model) updateEditDialog(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		n := len(m.edit.fields)
+		switch {
+		case msg.String() == "ctrl+c":
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Cancel):
+			m.edit.open = false
+			m.message = "Edit cancelled"
+			return m, nil
+
+		case key.Matches(msg, fieldNext):
+			return m, m.focusEditField((m.edit.focus + 1) % n)
+
+		case key.Matches(msg, fieldPrev):
+			return m, m.focusEditField((m.edit.focus - 1 + n) % n)
+
+		case key.Matches(msg, m.keys.Confirm):
+			return m.saveEdit()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.edit.inputs[m.edit.focus], cmd = m.edit.inputs[m.edit.focus].Update(msg)
+	return m, cmd
+}
+
+func (m model) renderEditDialog() string {
+	var b strings.Builder
+
+	b.WriteString(inputLabelStyle.Render(fmt.Sprintf("Edit %s (%s)", m.edit.source.Name, m.edit.source.SourceType)))
+	b.WriteString("\n\n")
+
+	for i, field := range m.edit.fields {
+		b.WriteString(inputLabelStyle.Render(editLabels[field]))
+		b.WriteString("\n")
+		b.WriteString(m.edit.inputs[i].View())
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("Changing the path or parsing options fetches the content again"))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(m.help.ShortHelpView([]key.Binding{
+		withDesc(m.keys.Confirm, "save"), fieldNext, m.keys.Cancel,
+	})))
+
+	if m.message != "" {
+		b.WriteString("\n\n")
+		msgStyle := statusStyle
+		if strings.HasPrefix(m.message, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString(msgStyle.Render(m.message))
+	}
+
+	return modalStyle.Render(b.String())
+}
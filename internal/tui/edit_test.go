@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEditSource(t *testing.T) {
+	m, store := newBulkTestModel(t)
+	ctx := context.Background()
+	id := m.sources[0].ID
+
+	// Names are unique, and a taken one keeps the dialog open to fix it
+	m = press(m, runes("e"), clearInput, runes("b"), enter)
+	if !m.edit.open || m.message != `Error: source "b" already exists` {
+		t.Fatalf("expected a name conflict, got message %q", m.message)
+	}
+
+	m = press(m, clearInput, runes("alpha"), tab, tab, runes("docs, api"), enter)
+	if m.edit.open || m.message != "✓ Saved alpha" {
+		t.Fatalf("expected the edit saved, got message %q", m.message)
+	}
+	source, err := store.Queries().GetSource(ctx, id)
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	if source.Name != "alpha" || source.Content != "a" {
+		t.Errorf("expected a renamed without fetching, got %+v", source)
+	}
+	tags, err := store.Queries().ListSourceTags(ctx, id)
+	if err != nil {
+		t.Fatalf("failed to list tags: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "api" || tags[1] != "docs" {
+		t.Errorf("expected tags api and docs, got %v", tags)
+	}
+
+	// A new path fetches the content again in the background
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("new content"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	m = press(m, runes("e"), tab, clearInput, runes(path))
+	next, cmd := m.Update(enter)
+	m = next.(model)
+	if _, busy := m.jobs.forSource(id); !busy {
+		t.Error("expected the source to be busy while it is fetched")
+	}
+	m = finishJobs(m, cmd)
+
+	if m.message != "✓ Saved alpha (fetched again)" {
+		t.Errorf("unexpected message %q", m.message)
+	}
+	source, err = store.Queries().GetSource(ctx, id)
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	if source.Path != path || source.Content != "new content" {
+		t.Errorf("expected the new path and content, got %+v", source)
+	}
+}
+
+func TestEditPriorityAndRefreshPolicy(t *testing.T) {
+	m, store := newBulkTestModel(t)
+	id := m.sources[0].ID
+
+	// Priority and refresh policy follow the tags
+	m = press(m, runes("e"), tab, tab, tab, clearInput, runes("high"), enter)
+	if !m.edit.open || m.message != "Error: priority must be a whole number" {
+		t.Fatalf("expected a priority error, got message %q", m.message)
+	}
+	m = press(m, clearInput, runes("5"), tab, clearInput, runes("weekly"), enter)
+	if !m.edit.open || m.message != "Error: refresh must be auto or manual" {
+		t.Fatalf("expected a refresh policy error, got message %q", m.message)
+	}
+	m = press(m, clearInput, runes("Manual"), enter)
+	if m.edit.open || m.message != "✓ Saved a" {
+		t.Fatalf("expected the edit saved, got message %q", m.message)
+	}
+
+	source, err := store.Queries().GetSource(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	if source.Priority != 5 || source.RefreshPolicy != "manual" || source.Content != "a" {
+		t.Errorf("expected priority 5 and manual refresh without fetching, got %+v", source)
+	}
+}
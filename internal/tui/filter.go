@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/sahilm/fuzzy"
+)
+
+// sourceTypes is the order the type quick filter cycles through
+var sourceTypes = []string{"file", "url", "bookmark", "pdf", "notebook", "openapi"}
+
+// filterFields are the source fields the fuzzy query is matched against
+var filterFields = []func(dbgen.Source) string{
+	func(s dbgen.Source) string { return s.Name },
+	func(s dbgen.Source) string { return s.Path },
+	func(s dbgen.Source) string { return s.SourceType },
+}
+
+// fieldSource adapts one field of every source to fuzzy.Source
+type fieldSource struct {
+	sources []dbgen.Source
+	field   func(dbgen.Source) string
+}
+
+func (f fieldSource) String(i int) string { return f.field(f.sources[i]) }
+func (f fieldSource) Len() int            { return len(f.sources) }
+
+// filterSources returns the indexes of the sources that match the query and
+// quick filters. With a query, the best matches on name, path or type come first.
+func filterSources(sources []dbgen.Source, query string, enabledOnly bool, sourceType string) []int {
+	keep := func(s dbgen.Source) bool {
+		if enabledOnly && s.Enabled != 1 {
+			return false
+		}
+		return sourceType == "" || s.SourceType == sourceType
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		visible := make([]int, 0, len(sources))
+		for i, source := range sources {
+			if keep(source) {
+				visible = append(visible, i)
+			}
+		}
+		return visible
+	}
+
+	// A source scores as its best matching field
+	scores := make(map[int]int)
+	for _, field := range filterFields {
+		for _, match := range fuzzy.FindFrom(query, fieldSource{sources: sources, field: field}) {
+			if score, ok := scores[match.Index]; !ok || match.Score > score {
+				scores[match.Index] = match.Score
+			}
+		}
+	}
+
+	visible := make([]int, 0, len(scores))
+	for i, source := range sources {
+		if _, ok := scores[i]; ok && keep(source) {
+			visible = append(visible, i)
+		}
+	}
+	slices.SortStableFunc(visible, func(a, b int) int { return scores[b] - scores[a] })
+	return visible
+}
+
+// nextTypeFilter cycles the type quick filter through the types present in
+// sources, then back to all types
+func nextTypeFilter(sources []dbgen.Source, current string) string {
+	var present []string
+	for _, t := range sourceTypes {
+		if slices.ContainsFunc(sources, func(s dbgen.Source) bool { return s.SourceType == t }) {
+			present = append(present, t)
+		}
+	}
+
+	i := slices.Index(present, current)
+	if i+1 < len(present) {
+		return present[i+1]
+	}
+	return ""
+}
+
+// selected returns the source under the cursor
+func (m model) selected() (dbgen.Source, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return dbgen.Source{}, false
+	}
+	return m.sources[m.visible[m.cursor]], true
+}
+
+// filterActive reports whether any filter hides sources
+func (m model) filterActive() bool {
+	return m.filterInput.Value() != "" || m.enabledOnly || m.typeFilter != ""
+}
+
+// setSources replaces the sources, keeping the cursor on the same source
+func (m *model) setSources(sources []dbgen.Source) {
+	selected, _ := m.selected()
+	m.sources = sources
+	m.loadChanges()
+	m.refilter(selected.ID)
+}
+
+// refilter recomputes the visible sources and moves the cursor to the
+// source with keepID, or keeps it in range when that source is hidden
+func (m *model) refilter(keepID int64) {
+	m.visible = filterSources(m.sources, m.filterInput.Value(), m.enabledOnly, m.typeFilter)
+
+	for i, idx := range m.visible {
+		if m.sources[idx].ID == keepID {
+			m.cursor = i
+			return
+		}
+	}
+	m.cursor = max(0, min(m.cursor, len(m.visible)-1))
+}
@@ -0,0 +1,318 @@
+package tui
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestModel returns a model over a new store holding sources
+func newTestModel(t *testing.T, sources ...dbgen.CreateSourceParams) (model, *storage.Store) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelError, // Quiet during tests
+	}))
+
+	store, err := storage.NewStore(filepath.Join(t.TempDir(), "test.db"), logger)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	for _, source := range sources {
+		if _, err := store.Queries().CreateSource(context.Background(), source); err != nil {
+			t.Fatalf("failed to create source: %v", err)
+		}
+	}
+
+	// Sources are listed by creation time, which has a resolution of a second.
+	// Give them all the same time, so crossing a second while creating them
+	// doesn't reorder the list.
+	if _, err := store.DB().Exec("UPDATE sources SET created_at = 1700000000"); err != nil {
+		t.Fatalf("failed to set creation times: %v", err)
+	}
+
+	m, err := initialModel(store, parser.NewParser(10*1024*1024), logger)
+	if err != nil {
+		t.Fatalf("failed to create model: %v", err)
+	}
+	return m, store
+}
+
+// testSources returns enabled file sources with the given names, each
+// holding its name as content
+func testSources(names ...string) []dbgen.CreateSourceParams {
+	var sources []dbgen.CreateSourceParams
+	for _, name := range names {
+		sources = append(sources, dbgen.CreateSourceParams{
+			Name:       name,
+			SourceType: "file",
+			Path:       "/path/to/" + name,
+			Content:    name,
+			Hash:       storage.ComputeHash(name),
+			Enabled:    1,
+			Options:    "{}",
+		})
+	}
+	return sources
+}
+
+// press sends keys to the model, ignoring the commands it returns
+func press(m model, keys ...tea.KeyMsg) model {
+	for _, key := range keys {
+		next, _ := m.Update(key)
+		m = next.(model)
+	}
+	return m
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+var (
+	down  = tea.KeyMsg{Type: tea.KeyDown}
+	enter = tea.KeyMsg{Type: tea.KeyEnter}
+	space = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	esc   = tea.KeyMsg{Type: tea.KeyEsc}
+)
+
+// enabledNames returns the names of the enabled sources in generation order
+func enabledNames(t *testing.T, store *storage.Store) []string {
+	t.Helper()
+
+	sources, err := store.Queries().ListEnabledSources(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list enabled sources: %v", err)
+	}
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	return names
+}
+
+// newFilterTestModel returns a model over sources of several types, some
+// of them disabled
+func newFilterTestModel(t *testing.T) (model, *storage.Store) {
+	t.Helper()
+
+	source := func(name, sourceType, path string, enabled int64) dbgen.CreateSourceParams {
+		return dbgen.CreateSourceParams{
+			Name:       name,
+			SourceType: sourceType,
+			Path:       path,
+			Content:    name,
+			Hash:       storage.ComputeHash(name),
+			Enabled:    enabled,
+			Options:    "{}",
+		}
+	}
+	return newTestModel(t,
+		source("api-docs", "url", "https://example.com/api", 1),
+		source("style-guide", "file", "/repo/docs/style.md", 0),
+		source("notes", "file", "/repo/notes.md", 1),
+		source("paper", "pdf", "/papers/attention.pdf", 0),
+	)
+}
+
+// visibleNames returns the names of the filtered sources in display order
+func visibleNames(m model) []string {
+	var names []string
+	for _, i := range m.visible {
+		names = append(names, m.sources[i].Name)
+	}
+	return names
+}
+
+func sortedNames(names []string) []string {
+	names = slices.Clone(names)
+	slices.Sort(names)
+	return names
+}
+
+func TestFilterFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "name", query: "stgd", want: []string{"style-guide"}},
+		{name: "path", query: "attention", want: []string{"paper"}},
+		{name: "type", query: "url", want: []string{"api-docs"}},
+		{name: "no match", query: "zzz", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newFilterTestModel(t)
+			m = press(m, runes("/"), runes(tt.query))
+
+			if got := visibleNames(m); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterBestMatchFirst(t *testing.T) {
+	m, _ := newFilterTestModel(t)
+	m = press(m, runes("/"), runes("notes"))
+
+	if got := visibleNames(m); len(got) == 0 || got[0] != "notes" {
+		t.Fatalf("expected notes to rank first, got %v", got)
+	}
+}
+
+func TestFilterKeepAndClear(t *testing.T) {
+	m, _ := newFilterTestModel(t)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = next.(model)
+
+	// enter leaves typing but keeps the filter applied
+	m = press(m, runes("/"), runes("stgd"), enter)
+	if m.filtering {
+		t.Fatal("expected enter to stop typing the filter")
+	}
+	if got := visibleNames(m); !slices.Equal(got, []string{"style-guide"}) {
+		t.Fatalf("expected the filter to be kept, got %v", got)
+	}
+	if view := m.View(); !strings.Contains(view, "1 of 4 sources") {
+		t.Errorf("expected the filter count in view, got:\n%s", view)
+	}
+
+	// esc in the list clears every filter
+	m = press(m, runes("E"), runes("t"), esc)
+	if m.filterActive() {
+		t.Error("expected esc to clear all filters")
+	}
+	if got := visibleNames(m); len(got) != 4 {
+		t.Errorf("expected all sources after clearing, got %v", got)
+	}
+
+	// esc while typing drops the query
+	m = press(m, runes("/"), runes("stgd"), esc)
+	if got := visibleNames(m); len(got) != 4 {
+		t.Errorf("expected esc to drop the query, got %v", got)
+	}
+}
+
+func TestFilterEnabledOnly(t *testing.T) {
+	m, _ := newFilterTestModel(t)
+
+	m = press(m, runes("E"))
+	want := []string{"api-docs", "notes"}
+	if got := sortedNames(visibleNames(m)); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	m = press(m, runes("E"))
+	if got := visibleNames(m); len(got) != 4 {
+		t.Errorf("expected all sources when turned off, got %v", got)
+	}
+}
+
+func TestFilterTypeCycle(t *testing.T) {
+	m, _ := newFilterTestModel(t)
+
+	// Only the types present are cycled through, then back to all
+	steps := []struct {
+		typeFilter string
+		want       []string
+	}{
+		{typeFilter: "file", want: []string{"notes", "style-guide"}},
+		{typeFilter: "url", want: []string{"api-docs"}},
+		{typeFilter: "pdf", want: []string{"paper"}},
+		{typeFilter: "", want: []string{"api-docs", "notes", "paper", "style-guide"}},
+	}
+	for _, step := range steps {
+		m = press(m, runes("t"))
+		if m.typeFilter != step.typeFilter {
+			t.Fatalf("expected type filter %q, got %q", step.typeFilter, m.typeFilter)
+		}
+		if got := sortedNames(visibleNames(m)); !slices.Equal(got, step.want) {
+			t.Errorf("type %q: expected %v, got %v", step.typeFilter, step.want, got)
+		}
+	}
+
+	// Quick filters combine
+	m = press(m, runes("t"), runes("E"))
+	if got := visibleNames(m); !slices.Equal(got, []string{"notes"}) {
+		t.Errorf("expected only enabled files, got %v", got)
+	}
+}
+
+func TestFilterCursorStaysInRange(t *testing.T) {
+	m, _ := newFilterTestModel(t)
+
+	m = press(m, down, down, down)
+	m = press(m, runes("E"))
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		t.Fatalf("cursor %d out of range for %d visible sources", m.cursor, len(m.visible))
+	}
+
+	m = press(m, runes("/"), runes("zzz"))
+	if _, ok := m.selected(); ok {
+		t.Error("expected nothing selected with no matches")
+	}
+}
+
+func TestFilterToggleActsOnFilteredRow(t *testing.T) {
+	m, store := newFilterTestModel(t)
+
+	// The first sources in the unfiltered list are left alone
+	m = press(m, runes("/"), runes("attention"), enter, space)
+
+	want := []string{"api-docs", "notes", "paper"}
+	if got := sortedNames(enabledNames(t, store)); !slices.Equal(got, want) {
+		t.Errorf("expected %v enabled, got %v", want, got)
+	}
+
+	// Moving within the filtered list toggles the highlighted row
+	m = press(m, esc, runes("t"), down)
+	source, ok := m.selected()
+	if !ok || source.SourceType != "file" {
+		t.Fatalf("expected a file source highlighted, got %+v", source)
+	}
+	wasEnabled := slices.Contains(enabledNames(t, store), source.Name)
+	press(m, space)
+	if slices.Contains(enabledNames(t, store), source.Name) == wasEnabled {
+		t.Errorf("expected %s to be toggled", source.Name)
+	}
+}
+
+func TestFilterDeleteActsOnFilteredRow(t *testing.T) {
+	m, store := newFilterTestModel(t)
+
+	m = press(m, runes("/"), runes("stgd"), enter, runes("d"))
+	if !m.deleteConfirm || m.deleteTarget != "style-guide" {
+		t.Fatalf("expected to confirm deleting style-guide, got %q", m.deleteTarget)
+	}
+	m = press(m, runes("y"))
+
+	sources, err := store.Queries().ListSources(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list sources: %v", err)
+	}
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	want := []string{"api-docs", "notes", "paper"}
+	if got := sortedNames(names); !slices.Equal(got, want) {
+		t.Errorf("expected %v left, got %v", want, got)
+	}
+	if got := visibleNames(m); len(got) != 0 {
+		t.Errorf("expected the filter to still apply after deleting, got %v", got)
+	}
+}
@@ -0,0 +1,260 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// generateFormats are the output formats the generate dialog cycles through
+var generateFormats = []string{"claude", "cursor", "default"}
+
+// clipboardOut receives the OSC52 escape sequence that sets the clipboard.
+// The terminal, not the local machine, handles it, so it works over SSH.
+var clipboardOut io.Writer = os.Stderr
+
+const (
+	generateFocusFormat = iota
+	generateFocusOutput
+	generateFocusPreset
+	generateFields
+)
+
+// generateDialog holds the options picked in the generate dialog
+type generateDialog struct {
+	open   bool
+	focus  int
+	format int // index into generateFormats
+	output textinput.Model
+	// presets are the choices for the source selection; the first, empty,
+	// one means the enabled sources
+	presets []string
+	preset  int
+}
+
+// generatedMsg reports the result of generating in the background
+type generatedMsg struct {
+	result *generator.Result
+	output string // file written, or empty when copied to the clipboard
+	err    error
+}
+
+func newGenerateDialog() generateDialog {
+	output := textinput.New()
+	output.Placeholder = "empty to copy to the clipboard"
+	output.CharLimit = 500
+	output.Width = 50
+
+	return generateDialog{output: output}
+}
+
+// openGenerateDialog shows the dialog with the current presets to choose from
+func (m model) openGenerateDialog() (model, error) {
+	presets, err := m.store.Queries().ListPresets(context.Background())
+	if err != nil {
+		return m, fmt.Errorf("failed to list presets: %w", err)
+	}
+
+	m.gen.presets = []string{""}
+	for _, preset := range presets {
+		m.gen.presets = append(m.gen.presets, preset.Name)
+	}
+	if m.gen.preset >= len(m.gen.presets) {
+		m.gen.preset = 0
+	}
+
+	m.gen.open = true
+	m.gen.focus = generateFocusFormat
+	m.gen.output.Blur()
+	return m, nil
+}
+
+func (m model) updateGenerateDialog(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "ctrl+c":
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Cancel):
+			m.gen.open = false
+			m.message = "Generate cancelled"
+			return m, nil
+
+		case key.Matches(msg, fieldNext):
+			return m, m.focusGenerateField((m.gen.focus + 1) % generateFields)
+
+		case key.Matches(msg, fieldPrev):
+			return m, m.focusGenerateField((m.gen.focus - 1 + generateFields) % generateFields)
+
+		case key.Matches(msg, pickPrev, pickNext):
+			step := 1
+			if key.Matches(msg, pickPrev) {
+				step = -1
+			}
+			switch m.gen.focus {
+			case generateFocusFormat:
+				m.gen.format = (m.gen.format + step + len(generateFormats)) % len(generateFormats)
+				return m, nil
+			case generateFocusPreset:
+				m.gen.preset = (m.gen.preset + step + len(m.gen.presets)) % len(m.gen.presets)
+				return m, nil
+			}
+
+		case key.Matches(msg, m.keys.Confirm):
+			if m.jobs.busy(jobGenerate) {
+				m.message = "Already generating"
+				return m, nil
+			}
+
+			output := strings.TrimSpace(m.gen.output.Value())
+			if output != "" {
+				abs, err := filepath.Abs(output)
+				if err != nil {
+					m.message = fmt.Sprintf("Error: failed to resolve output path: %v", err)
+					return m, nil
+				}
+				output = abs
+			}
+
+			m.gen.open = false
+			m.message = ""
+			return m, m.runGenerate(generator.GenerateOptions{
+				OutputPath: output,
+				Format:     generateFormats[m.gen.format],
+				PresetName: m.gen.presets[m.gen.preset],
+			})
+		}
+	}
+
+	var cmd tea.Cmd
+	if m.gen.focus == generateFocusOutput {
+		m.gen.output, cmd = m.gen.output.Update(msg)
+	}
+	return m, cmd
+}
+
+// focusGenerateField moves focus in the dialog, focusing the output input
+// when it is selected
+func (m *model) focusGenerateField(field int) tea.Cmd {
+	m.gen.focus = field
+	if field == generateFocusOutput {
+		return m.gen.output.Focus()
+	}
+	m.gen.output.Blur()
+	return nil
+}
+
+// runGenerate returns a command that generates in the background and
+// writes the result to a file, or copies it to the clipboard
+func (m model) runGenerate(opts generator.GenerateOptions) tea.Cmd {
+	gen := m.generator
+	return m.startJob(jobGenerate, nil, "Generating "+opts.Format, func(ctx context.Context) tea.Msg {
+		if opts.OutputPath != "" {
+			result, err := gen.Generate(ctx, opts)
+			return generatedMsg{result: result, output: opts.OutputPath, err: err}
+		}
+
+		result, err := gen.Render(ctx, opts)
+		if err != nil {
+			return generatedMsg{err: err}
+		}
+		if len(result.Sources) == 0 {
+			return generatedMsg{err: fmt.Errorf("no enabled sources found")}
+		}
+		if err := ctx.Err(); err != nil {
+			return generatedMsg{err: err}
+		}
+
+		if err := copyToClipboard(result.Content); err != nil {
+			return generatedMsg{err: err}
+		}
+		if _, err := gen.Record(ctx, opts, result); err != nil {
+			m.logger.WarnContext(ctx, "failed to record history", "error", err)
+		}
+		return generatedMsg{result: result}
+	})
+}
+
+// copyToClipboard sets the terminal's clipboard with an OSC52 escape sequence
+func copyToClipboard(content string) error {
+	seq := osc52.New(content)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+
+	if _, err := seq.WriteTo(clipboardOut); err != nil {
+		return fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+	return nil
+}
+
+// generatedMessage describes a finished generation for the status line
+func generatedMessage(msg generatedMsg) string {
+	if msg.err != nil {
+		return fmt.Sprintf("Error generating: %v", msg.err)
+	}
+
+	destination := "copied to clipboard"
+	if msg.output != "" {
+		destination = "written to " + msg.output
+	}
+	text := fmt.Sprintf("✓ Generated %d sources (~%d tokens), %s",
+		len(msg.result.Sources), msg.result.Tokens, destination)
+
+	if n := len(msg.result.Omitted); n > 0 {
+		text += fmt.Sprintf(" • %d over budget", n)
+	}
+	if n := len(msg.result.Redactions); n > 0 {
+		text += fmt.Sprintf(" • masked %d secret(s)", n)
+	}
+	return text
+}
+
+func (m model) renderGenerateDialog() string {
+	var b strings.Builder
+
+	field := func(index int, label, value string) {
+		style := normalItemStyle
+		marker := "  "
+		if m.gen.focus == index {
+			style = selectedItemStyle
+			marker = "> "
+		}
+		b.WriteString(inputLabelStyle.Render(label))
+		b.WriteString("\n")
+		b.WriteString(style.Render(marker + value))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString("\n")
+	field(generateFocusFormat, "Format:", "‹ "+generateFormats[m.gen.format]+" ›")
+
+	b.WriteString(inputLabelStyle.Render("Output file:"))
+	b.WriteString("\n")
+	b.WriteString(m.gen.output.View())
+	b.WriteString("\n\n")
+
+	preset := "enabled sources"
+	if name := m.gen.presets[m.gen.preset]; name != "" {
+		preset = "preset " + name
+	}
+	field(generateFocusPreset, "Sources:", "‹ "+preset+" ›")
+
+	b.WriteString(helpStyle.Render(m.help.ShortHelpView([]key.Binding{
+		withDesc(m.keys.Confirm, "generate"), fieldNext, withDesc(panKeys, "pick"), m.keys.Cancel,
+	})))
+
+	return modalStyle.Render(b.String())
+}
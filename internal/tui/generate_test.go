@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	left     = tea.KeyMsg{Type: tea.KeyLeft}
+	right    = tea.KeyMsg{Type: tea.KeyRight}
+	shiftTab = tea.KeyMsg{Type: tea.KeyShiftTab}
+)
+
+// captureClipboard sends clipboard escape sequences to a buffer for the
+// rest of the test
+func captureClipboard(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	var buf bytes.Buffer
+	previous := clipboardOut
+	clipboardOut = &buf
+	t.Cleanup(func() { clipboardOut = previous })
+	return &buf
+}
+
+func TestGenerateDialogSelection(t *testing.T) {
+	m, store := newTestModel(t, testSources("a", "b", "c", "d", "e")...)
+
+	if _, err := store.Queries().CreatePreset(context.Background(), dbgen.CreatePresetParams{Name: "docs"}); err != nil {
+		t.Fatalf("failed to create preset: %v", err)
+	}
+
+	m = press(m, runes("g"))
+	if !m.gen.open {
+		t.Fatal("expected g to open the generate dialog")
+	}
+
+	// The format wraps around in both directions
+	m = press(m, right)
+	if got := generateFormats[m.gen.format]; got != "cursor" {
+		t.Errorf("expected cursor, got %q", got)
+	}
+	m = press(m, left, left)
+	if got := generateFormats[m.gen.format]; got != "default" {
+		t.Errorf("expected to wrap to default, got %q", got)
+	}
+
+	// Picking keys go to the focused field, and typing only to the output
+	m = press(m, tab, runes("out.md"), tab, right)
+	if m.gen.focus != generateFocusPreset {
+		t.Fatalf("expected the preset focused, got field %d", m.gen.focus)
+	}
+	if got := m.gen.presets[m.gen.preset]; got != "docs" {
+		t.Errorf("expected preset docs, got %q", got)
+	}
+	if got := m.gen.output.Value(); got != "out.md" {
+		t.Errorf("expected the typed output, got %q", got)
+	}
+	if got := generateFormats[m.gen.format]; got != "default" {
+		t.Errorf("expected the format to be left alone, got %q", got)
+	}
+
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	view := next.(model).View()
+	for _, want := range []string{"‹ default ›", "out.md", "‹ preset docs ›"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view, got:\n%s", want, view)
+		}
+	}
+
+	// shift+tab moves back, and right then wraps to the enabled sources
+	m = press(m, shiftTab, tab, right)
+	if got := m.gen.presets[m.gen.preset]; got != "" {
+		t.Errorf("expected the enabled sources, got preset %q", got)
+	}
+
+	m = press(m, esc)
+	if m.gen.open || m.message != "Generate cancelled" {
+		t.Errorf("expected esc to cancel, got message %q", m.message)
+	}
+}
+
+func TestGenerateToFile(t *testing.T) {
+	m, store := newTestModel(t, testSources("a", "b", "c", "d", "e")...)
+	ctx := context.Background()
+	output := filepath.Join(t.TempDir(), "CONTEXT.md")
+
+	// Only the preset's sources are generated, enabled or not
+	preset, err := store.Queries().CreatePreset(ctx, dbgen.CreatePresetParams{Name: "bd"})
+	if err != nil {
+		t.Fatalf("failed to create preset: %v", err)
+	}
+	for _, name := range []string{"b", "d"} {
+		source, err := store.Queries().GetSourceByName(ctx, name)
+		if err != nil {
+			t.Fatalf("failed to get source: %v", err)
+		}
+		if err := store.Queries().AddSourceToPreset(ctx, dbgen.AddSourceToPresetParams{
+			PresetID: preset.ID,
+			SourceID: source.ID,
+		}); err != nil {
+			t.Fatalf("failed to add source to preset: %v", err)
+		}
+	}
+
+	m = press(m, runes("g"), left, tab, runes(output), tab, right)
+	next, cmd := m.Update(enter)
+	m = next.(model)
+	if m.gen.open {
+		t.Fatal("expected enter to close the dialog")
+	}
+	m = finishJobs(m, cmd)
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected the output written: %v", err)
+	}
+	if want := "=== b ===\nb\n\n=== d ===\nd"; string(data) != want {
+		t.Errorf("expected the preset in the default format %q, got %q", want, data)
+	}
+	if !strings.HasPrefix(m.message, "✓ Generated 2 sources") || !strings.HasSuffix(m.message, "written to "+output) {
+		t.Errorf("expected the file reported in the status line, got %q", m.message)
+	}
+}
+
+func TestGenerateToClipboard(t *testing.T) {
+	clipboard := captureClipboard(t)
+	m, store := newTestModel(t, testSources("a", "b", "c", "d", "e")...)
+
+	// Leaving the output empty copies the enabled sources instead
+	m = press(m, runes("g"))
+	next, cmd := m.Update(enter)
+	m = finishJobs(next.(model), cmd)
+
+	if !strings.HasSuffix(m.message, "copied to clipboard") {
+		t.Fatalf("expected a copy, got message %q", m.message)
+	}
+
+	seq := clipboard.String()
+	prefix := "\x1b]52;c;"
+	if !strings.HasPrefix(seq, prefix) {
+		t.Fatalf("expected an OSC52 sequence, got %q", seq)
+	}
+	encoded := strings.TrimRight(strings.TrimPrefix(seq, prefix), "\a\x1b\\")
+	content, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("failed to decode clipboard content: %v", err)
+	}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		if !strings.Contains(string(content), "**Source:** /path/to/"+name) {
+			t.Errorf("expected source %s in the clipboard, got:\n%s", name, content)
+		}
+	}
+
+	// Copies are recorded in the history like written files
+	history, err := store.Queries().ListHistory(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("failed to list history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("expected the copy recorded in history, got %d entries", len(history))
+	}
+}
+
+func TestGenerateToClipboardNothingEnabled(t *testing.T) {
+	clipboard := captureClipboard(t)
+	m, _ := newTestModel(t, testSources("a", "b", "c", "d", "e")...)
+
+	m = press(m, space, down, space, down, space, down, space, down, space, runes("g"))
+	next, cmd := m.Update(enter)
+	m = finishJobs(next.(model), cmd)
+
+	if m.message != "Error generating: no enabled sources found" {
+		t.Errorf("expected an error, got message %q", m.message)
+	}
+	if clipboard.Len() != 0 {
+		t.Errorf("expected nothing copied, got %q", clipboard.String())
+	}
+}
@@ -0,0 +1,305 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type jobKind int
+
+const (
+	jobAdd jobKind = iota
+	jobFetch
+	jobRefresh
+	jobGenerate
+	jobEdit
+)
+
+// verb describes what a job does to a source in the list
+func (k jobKind) verb() string {
+	switch k {
+	case jobAdd:
+		return "adding…"
+	case jobFetch:
+		return "fetching…"
+	case jobRefresh:
+		return "refreshing…"
+	case jobEdit:
+		return "saving…"
+	default:
+		return "generating…"
+	}
+}
+
+// job is an operation running in the background
+type job struct {
+	kind    jobKind
+	sources []int64 // the sources worked on, if any
+	label   string
+	cancel  context.CancelFunc
+}
+
+// jobs tracks the operations running in the background. Like the maps in
+// the model, it is shared by every copy of the model.
+type jobs struct {
+	next     int
+	running  map[int]job
+	spinning bool // a spinner tick is in flight
+}
+
+func newJobs() *jobs {
+	return &jobs{running: make(map[int]job)}
+}
+
+// finish removes a job, reporting whether it was still running rather
+// than cancelled
+func (j *jobs) finish(id int) bool {
+	_, ok := j.running[id]
+	delete(j.running, id)
+	return ok
+}
+
+// cancelAll cancels every running job, returning how many there were
+func (j *jobs) cancelAll() int {
+	n := len(j.running)
+	for id, running := range j.running {
+		running.cancel()
+		delete(j.running, id)
+	}
+	return n
+}
+
+// busy reports whether a job of the given kind is running
+func (j *jobs) busy(kind jobKind) bool {
+	for _, running := range j.running {
+		if running.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// forSource returns the job working on a source, if any
+func (j *jobs) forSource(id int64) (job, bool) {
+	for _, running := range j.running {
+		if slices.Contains(running.sources, id) {
+			return running, true
+		}
+	}
+	return job{}, false
+}
+
+// labels returns the labels of the running jobs, oldest first
+func (j *jobs) labels() []string {
+	ids := make([]int, 0, len(j.running))
+	for id := range j.running {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	labels := make([]string, 0, len(ids))
+	for _, id := range ids {
+		labels = append(labels, j.running[id].label)
+	}
+	return labels
+}
+
+// jobDoneMsg delivers the result of a job
+type jobDoneMsg struct {
+	id  int
+	msg tea.Msg
+}
+
+// addedMsg reports the result of adding a source
+type addedMsg struct {
+	name string
+	hint string // e.g. an llms.txt suggestion
+	err  error
+}
+
+// refreshedMsg reports the result of refreshing sources
+type refreshedMsg struct {
+	name    string // the source refreshed, when there was only one
+	count   int
+	changed int
+	failed  []string // "name: error" for each source that couldn't be read
+	err     error    // storing the refreshed content failed
+}
+
+// String describes the refresh for the status line
+func (msg refreshedMsg) String() string {
+	switch {
+	case msg.err != nil:
+		return fmt.Sprintf("Error refreshing: %v", msg.err)
+	case msg.name != "" && len(msg.failed) > 0:
+		return fmt.Sprintf("Error refreshing %s", msg.failed[0])
+	case msg.name != "" && msg.changed > 0:
+		return fmt.Sprintf("✓ Refreshed %s", msg.name)
+	case msg.name != "":
+		return fmt.Sprintf("%s is up to date", msg.name)
+	}
+
+	text := fmt.Sprintf("✓ Refreshed %d sources, %d changed", msg.count, msg.changed)
+	if len(msg.failed) == msg.count {
+		text = fmt.Sprintf("Error refreshing %d sources", msg.count)
+	}
+	if len(msg.failed) > 0 {
+		text += fmt.Sprintf(" • %d failed, e.g. %s", len(msg.failed), msg.failed[0])
+	}
+	return text
+}
+
+// startJob runs fn in the background with a context that esc cancels,
+// starting the spinner if it isn't already spinning
+func (m model) startJob(kind jobKind, sources []int64, label string, fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.jobs.next++
+	id := m.jobs.next
+	m.jobs.running[id] = job{kind: kind, sources: sources, label: label, cancel: cancel}
+
+	cmd := func() tea.Msg {
+		defer cancel()
+		return jobDoneMsg{id: id, msg: fn(ctx)}
+	}
+	if m.jobs.spinning {
+		return cmd
+	}
+	m.jobs.spinning = true
+	return tea.Batch(cmd, m.spinner.Tick)
+}
+
+// updateJobs handles job results and spinner ticks, reporting whether msg
+// was one of them
+func (m model) updateJobs(msg tea.Msg) (tea.Model, tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case jobDoneMsg:
+		// Results of cancelled jobs are dropped
+		if !m.jobs.finish(msg.id) {
+			return m, nil, true
+		}
+		next, cmd := m.update(msg.msg)
+		return next, cmd, true
+
+	case spinner.TickMsg:
+		if len(m.jobs.running) == 0 {
+			m.jobs.spinning = false
+			return m, nil, true
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd, true
+	}
+
+	return m, nil, false
+}
+
+// fetchPending returns a command that fetches a pending source in the background
+func (m model) fetchPending(source dbgen.Source) tea.Cmd {
+	return m.startJob(jobFetch, []int64{source.ID}, "Fetching "+source.Name, func(ctx context.Context) tea.Msg {
+		_, err := m.importer.Fetch(ctx, source)
+		return fetchedMsg{id: source.ID, name: source.Name, err: err}
+	})
+}
+
+// addSourceCmd returns a command that parses and stores a new source in
+// the background
+func (m model) addSourceCmd(name, path string) tea.Cmd {
+	// Re-adding an existing source updates it, so show it as busy
+	var ids []int64
+	if existing, err := m.store.Queries().GetSourceByName(context.Background(), name); err == nil {
+		ids = append(ids, existing.ID)
+	}
+
+	return m.startJob(jobAdd, ids, "Adding "+name, func(ctx context.Context) tea.Msg {
+		if err := m.addSource(ctx, name, path); err != nil {
+			return addedMsg{name: name, err: err}
+		}
+		if ctx.Err() != nil {
+			return addedMsg{name: name, err: ctx.Err()}
+		}
+		return addedMsg{name: name, hint: m.llmsTxtHint(ctx, path)}
+	})
+}
+
+// refreshSources returns a command that re-reads sources from their paths
+// in the background, then stores the ones that changed in one transaction
+func (m model) refreshSources(sources []dbgen.Source) tea.Cmd {
+	ids := make([]int64, 0, len(sources))
+	for _, source := range sources {
+		ids = append(ids, source.ID)
+	}
+
+	label := fmt.Sprintf("Refreshing %d sources", len(sources))
+	if len(sources) == 1 {
+		label = "Refreshing " + sources[0].Name
+	}
+
+	return m.startJob(jobRefresh, ids, label, func(ctx context.Context) tea.Msg {
+		msg := refreshedMsg{count: len(sources)}
+		if len(sources) == 1 {
+			msg.name = sources[0].Name
+		}
+
+		var changed []dbgen.UpdateSourceContentParams
+		for _, source := range sources {
+			content, err := m.readSource(ctx, source)
+			if err != nil {
+				if ctx.Err() != nil {
+					return refreshedMsg{err: ctx.Err()}
+				}
+				msg.failed = append(msg.failed, fmt.Sprintf("%s: %v", source.Name, err))
+				continue
+			}
+
+			// Storing clears the pending flag of placeholders
+			if hash := storage.ComputeHash(content); hash != source.Hash || source.Pending == 1 {
+				changed = append(changed, dbgen.UpdateSourceContentParams{
+					Content: content,
+					Hash:    hash,
+					ID:      source.ID,
+				})
+			}
+		}
+
+		if err := m.withTx(ctx, func(q *dbgen.Queries) error {
+			for _, params := range changed {
+				if err := q.UpdateSourceContent(ctx, params); err != nil {
+					return fmt.Errorf("failed to update source: %w", err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return refreshedMsg{err: err}
+		}
+
+		msg.changed = len(changed)
+		return msg
+	})
+}
+
+// readSource parses a source from its path or URL with its options
+func (m model) readSource(ctx context.Context, source dbgen.Source) (string, error) {
+	opts, err := parser.DecodeOptions(source.Options)
+	if err != nil {
+		return "", err
+	}
+	return m.parser.ParseSourceContext(ctx, source.SourceType, source.Path, opts)
+}
+
+// renderJobs describes the running jobs for the status line
+func (m model) renderJobs() string {
+	labels := m.jobs.labels()
+	if len(labels) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s%s (%s to cancel)", m.spinner.View(), strings.Join(labels, ", "), m.keys.Cancel.Help().Key)
+}
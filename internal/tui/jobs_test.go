@@ -0,0 +1,319 @@
+package tui
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// slowServer serves /docs only once release is closed, reporting each
+// request on arrived and each abandoned one on cancelled. Other paths 404.
+type slowServer struct {
+	*httptest.Server
+	arrived   chan struct{}
+	cancelled chan struct{}
+	release   chan struct{}
+	content   string
+}
+
+func newSlowServer(t *testing.T, content string) *slowServer {
+	t.Helper()
+
+	s := &slowServer{
+		arrived:   make(chan struct{}, 1),
+		cancelled: make(chan struct{}, 1),
+		release:   make(chan struct{}),
+		content:   content,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/docs" {
+			http.NotFound(w, r)
+			return
+		}
+
+		s.arrived <- struct{}{}
+		select {
+		case <-s.release:
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, s.content)
+		case <-r.Context().Done():
+			s.cancelled <- struct{}{}
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// probeMsg asks the test program for a snapshot of its view
+type probeMsg struct{}
+
+// testProgram runs the TUI in a bubbletea program without a terminal
+type testProgram struct {
+	*tea.Program
+	store *storage.Store
+	views chan string   // views captured when probed
+	done  chan struct{} // closed once a job result has been handled
+	final chan model
+}
+
+func startTestProgram(t *testing.T, sources ...dbgen.CreateSourceParams) *testProgram {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelError, // Quiet during tests
+	}))
+
+	store, err := storage.NewStore(filepath.Join(t.TempDir(), "test.db"), logger)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	for _, source := range sources {
+		if _, err := store.Queries().CreateSource(context.Background(), source); err != nil {
+			t.Fatalf("failed to create source: %v", err)
+		}
+	}
+
+	m, err := initialModel(store, parser.NewParser(10*1024*1024), logger)
+	if err != nil {
+		t.Fatalf("failed to create model: %v", err)
+	}
+
+	tp := &testProgram{
+		store: store,
+		views: make(chan string, 1),
+		done:  make(chan struct{}, 1),
+		final: make(chan model, 1),
+	}
+
+	// The filter sees every message before the model does, so it only runs
+	// while the event loop is free
+	filter := func(current tea.Model, msg tea.Msg) tea.Msg {
+		switch msg.(type) {
+		case probeMsg:
+			tp.views <- current.View()
+		case jobDoneMsg:
+			tp.done <- struct{}{}
+		}
+		return msg
+	}
+
+	tp.Program = tea.NewProgram(m,
+		tea.WithInput(nil),
+		tea.WithOutput(io.Discard),
+		tea.WithoutSignalHandler(),
+		tea.WithFilter(filter),
+	)
+	go func() {
+		final, err := tp.Run()
+		if err != nil {
+			t.Errorf("program failed: %v", err)
+		}
+		tp.final <- final.(model)
+	}()
+
+	return tp
+}
+
+func (tp *testProgram) typeKeys(keys ...string) {
+	for _, key := range keys {
+		switch key {
+		case "enter":
+			tp.Send(tea.KeyMsg{Type: tea.KeyEnter})
+		case "tab":
+			tp.Send(tea.KeyMsg{Type: tea.KeyTab})
+		case "esc":
+			tp.Send(tea.KeyMsg{Type: tea.KeyEsc})
+		default:
+			tp.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		}
+	}
+}
+
+// view returns what the program currently shows
+func (tp *testProgram) view(t *testing.T) string {
+	t.Helper()
+	tp.Send(probeMsg{})
+	return wait(t, tp.views, "view")
+}
+
+// quit stops the program once it has handled everything sent so far,
+// returning its final model
+func (tp *testProgram) quit(t *testing.T) model {
+	t.Helper()
+	tp.Quit()
+	return wait(t, tp.final, "program to quit")
+}
+
+func wait[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+		var zero T
+		return zero
+	}
+}
+
+// finishJobs runs the commands a key press returned, handing the results of
+// background jobs back to the model
+func finishJobs(m model, cmd tea.Cmd) model {
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			m = finishJobs(m, cmd)
+		}
+	case jobDoneMsg:
+		next, _ := m.Update(msg)
+		m = next.(model)
+	}
+	return m
+}
+
+func TestAddSourceInBackground(t *testing.T) {
+	server := newSlowServer(t, "slow docs")
+	tp := startTestProgram(t)
+
+	tp.typeKeys("a", "docs", "tab", server.URL+"/docs", "enter")
+	wait(t, server.arrived, "request")
+
+	// The download is blocked, yet the program keeps handling messages
+	view := tp.view(t)
+	if !strings.Contains(view, "Adding docs (esc to cancel)") {
+		t.Errorf("expected the add in the status line, got:\n%s", view)
+	}
+
+	close(server.release)
+	wait(t, tp.done, "add to finish")
+
+	final := tp.quit(t)
+	if final.message != "✓ Added source: docs" {
+		t.Errorf("unexpected message %q", final.message)
+	}
+	if len(final.jobs.running) != 0 {
+		t.Errorf("expected no running jobs, got %d", len(final.jobs.running))
+	}
+
+	source, err := tp.store.Queries().GetSourceByName(context.Background(), "docs")
+	if err != nil {
+		t.Fatalf("source was not added: %v", err)
+	}
+	if source.Content != "slow docs" {
+		t.Errorf("unexpected content %q", source.Content)
+	}
+}
+
+func TestCancelAdd(t *testing.T) {
+	server := newSlowServer(t, "slow docs")
+	tp := startTestProgram(t)
+
+	tp.typeKeys("a", "docs", "tab", server.URL+"/docs", "enter")
+	wait(t, server.arrived, "request")
+
+	// esc aborts the download itself, not just the wait for it
+	tp.typeKeys("esc")
+	wait(t, server.cancelled, "request to be cancelled")
+
+	final := tp.quit(t)
+	if final.message != "Cancelled 1 operation(s)" {
+		t.Errorf("unexpected message %q", final.message)
+	}
+	if len(final.jobs.running) != 0 {
+		t.Errorf("expected no running jobs, got %d", len(final.jobs.running))
+	}
+
+	if _, err := tp.store.Queries().GetSourceByName(context.Background(), "docs"); err == nil {
+		t.Error("cancelled add still stored the source")
+	}
+}
+
+func TestCancelAddDuringLLMsTxtDiscovery(t *testing.T) {
+	// The page comes back at once, but the site never answers the llms.txt
+	// checks that follow
+	arrived := make(chan struct{}, 2)
+	cancelled := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/docs" {
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, "docs")
+			return
+		}
+		arrived <- struct{}{}
+		<-r.Context().Done()
+		cancelled <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	tp := startTestProgram(t)
+
+	tp.typeKeys("a", "docs", "tab", server.URL+"/docs", "enter")
+	wait(t, arrived, "llms.txt check")
+
+	// esc stops the discovery rather than leaving the job waiting on the site
+	tp.typeKeys("esc")
+	wait(t, cancelled, "llms.txt check to be cancelled")
+
+	final := tp.quit(t)
+	if final.message != "Cancelled 1 operation(s)" {
+		t.Errorf("unexpected message %q", final.message)
+	}
+	if len(final.jobs.running) != 0 {
+		t.Errorf("expected no running jobs, got %d", len(final.jobs.running))
+	}
+}
+
+func TestRefreshSourceInBackground(t *testing.T) {
+	server := newSlowServer(t, "docs v2")
+	tp := startTestProgram(t, dbgen.CreateSourceParams{
+		Name:       "docs",
+		SourceType: "url",
+		Path:       server.URL + "/docs",
+		Content:    "docs v1",
+		Hash:       storage.ComputeHash("docs v1"),
+		Enabled:    1,
+		Options:    "{}",
+	})
+
+	tp.typeKeys("R")
+	wait(t, server.arrived, "request")
+
+	// The source shows as in flight while it refreshes
+	view := tp.view(t)
+	if !strings.Contains(view, "refreshing…") {
+		t.Errorf("expected the source to show as refreshing, got:\n%s", view)
+	}
+
+	close(server.release)
+	wait(t, tp.done, "refresh to finish")
+
+	final := tp.quit(t)
+	if final.message != "✓ Refreshed docs" {
+		t.Errorf("unexpected message %q", final.message)
+	}
+
+	source, err := tp.store.Queries().GetSourceByName(context.Background(), "docs")
+	if err != nil {
+		t.Fatalf("failed to get source: %v", err)
+	}
+	if source.Content != "docs v2" {
+		t.Errorf("expected refreshed content, got %q", source.Content)
+	}
+}
@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap holds the bindings of the source list, along with confirm and
+// cancel for dialogs. Help is rendered from these, so rebinding a key in the
+// config changes the help text along with it.
+type keyMap struct {
+	Up          key.Binding
+	Down        key.Binding
+	ExtendUp    key.Binding
+	ExtendDown  key.Binding
+	Toggle      key.Binding
+	Add         key.Binding
+	Edit        key.Binding
+	Delete      key.Binding
+	Refresh     key.Binding
+	Diff        key.Binding
+	Visual      key.Binding
+	Mark        key.Binding
+	SelectAll   key.Binding
+	Tag         key.Binding
+	AddToPreset key.Binding
+	Generate    key.Binding
+	Presets     key.Binding
+	Filter      key.Binding
+	EnabledOnly key.Binding
+	TypeFilter  key.Binding
+	Preview     key.Binding
+	PageUp      key.Binding
+	PageDown    key.Binding
+	Reload      key.Binding
+	Confirm     key.Binding
+	Cancel      key.Binding
+	Quit        key.Binding
+}
+
+// binding creates a binding whose help shows its keys
+func binding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keyLabel(keys), desc))
+}
+
+// keyLabel shows keys the way help displays them
+func keyLabel(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case " ":
+			labels[i] = "space"
+		case "up":
+			labels[i] = "↑"
+		case "down":
+			labels[i] = "↓"
+		case "left":
+			labels[i] = "←"
+		case "right":
+			labels[i] = "→"
+		default:
+			labels[i] = k
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+// Fixed keys of the modes that take typed input or scroll a viewport, where
+// rebinding letters would get in the way. Dialogs pair these with the
+// keymap's Confirm and Cancel, and their help is rendered from both.
+var (
+	scrollKeys    = binding("scroll", "up", "down")
+	pageKeys      = binding("page", "pgup", "pgdown")
+	panKeys       = binding("pan", "left", "right")
+	previewTop    = binding("top", "g", "home")
+	previewBottom = binding("bottom", "G", "end")
+
+	filterKeep  = binding("keep filter", "enter")
+	filterClear = binding("clear filter", "esc")
+	filterUp    = binding("move", "up", "ctrl+p")
+	filterDown  = binding("move", "down", "ctrl+n")
+
+	presetApply  = binding("apply", "enter")
+	presetNew    = binding("save enabled as new", "n")
+	presetUpdate = binding("update with enabled", "u")
+	presetRename = binding("rename", "r")
+	presetDelete = binding("delete", "d")
+
+	fieldNext   = binding("next", "tab", "down")
+	fieldPrev   = binding("previous", "shift+tab", "up")
+	pickPrev    = binding("pick", "left")
+	pickNext    = binding("pick", "right")
+	browseFiles = binding("browse files", "ctrl+f")
+	deleteYes   = key.NewBinding(key.WithKeys("y", "Y"), key.WithHelp("y", "yes, delete"))
+	deleteNo    = key.NewBinding(key.WithKeys("n", "N"), key.WithHelp("n", "cancel"))
+
+	pickerUp      = binding("up", "up", "k")
+	pickerDown    = binding("down", "down", "j")
+	pickerParent  = binding("up a directory", "left", "h", "backspace")
+	pickerOpen    = binding("open", "right", "l")
+	pickerSelect  = binding("select", " ")
+	pickerAll     = binding("all", "ctrl+a")
+	pickerIgnored = binding("ignored", "i")
+)
+
+// withDesc returns the binding with a different help description, for
+// showing it in another context
+func withDesc(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Up:          binding("up", "up", "k"),
+		Down:        binding("down", "down", "j"),
+		ExtendUp:    binding("extend up", "shift+up"),
+		ExtendDown:  binding("extend down", "shift+down"),
+		Toggle:      binding("toggle", " ", "enter"),
+		Add:         binding("add", "a"),
+		Edit:        binding("edit", "e"),
+		Delete:      binding("delete", "d"),
+		Refresh:     binding("refresh", "R"),
+		Diff:        binding("diff", "D"),
+		Visual:      binding("visual", "v"),
+		Mark:        binding("select", "x"),
+		SelectAll:   binding("all", "ctrl+a"),
+		Tag:         binding("tag", "T"),
+		AddToPreset: binding("add to preset", "P"),
+		Generate:    binding("generate", "g"),
+		Presets:     binding("presets", "p"),
+		Filter:      binding("filter", "/"),
+		EnabledOnly: binding("enabled only", "E"),
+		TypeFilter:  binding("type", "t"),
+		Preview:     binding("preview", "tab"),
+		PageUp:      binding("page up", "pgup"),
+		PageDown:    binding("page down", "pgdown"),
+		Reload:      binding("reload", "r"),
+		Confirm:     binding("confirm", "enter"),
+		Cancel:      binding("cancel", "esc"),
+		Quit:        binding("quit", "q", "ctrl+c"),
+	}
+}
+
+// actions maps the action names used in the config to their bindings
+func (k *keyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":            &k.Up,
+		"down":          &k.Down,
+		"extend_up":     &k.ExtendUp,
+		"extend_down":   &k.ExtendDown,
+		"toggle":        &k.Toggle,
+		"add":           &k.Add,
+		"edit":          &k.Edit,
+		"delete":        &k.Delete,
+		"refresh":       &k.Refresh,
+		"diff":          &k.Diff,
+		"visual":        &k.Visual,
+		"mark":          &k.Mark,
+		"select_all":    &k.SelectAll,
+		"tag":           &k.Tag,
+		"add_to_preset": &k.AddToPreset,
+		"generate":      &k.Generate,
+		"presets":       &k.Presets,
+		"filter":        &k.Filter,
+		"enabled_only":  &k.EnabledOnly,
+		"type_filter":   &k.TypeFilter,
+		"preview":       &k.Preview,
+		"page_up":       &k.PageUp,
+		"page_down":     &k.PageDown,
+		"reload":        &k.Reload,
+		"confirm":       &k.Confirm,
+		"cancel":        &k.Cancel,
+		"quit":          &k.Quit,
+	}
+}
+
+// loadKeyMap rebinds the default keys from the config, which maps action
+// names to the keys that trigger them. Keys are named the way bubbletea
+// reports them, e.g. "ctrl+d" or "shift+down", with "space" for the space bar.
+func loadKeyMap(overrides map[string][]string) (keyMap, error) {
+	keys := newKeyMap()
+	actions := keys.actions()
+
+	for action, bound := range overrides {
+		b, ok := actions[action]
+		if !ok {
+			return keyMap{}, fmt.Errorf("unknown key action %q", action)
+		}
+		if len(bound) == 0 {
+			return keyMap{}, fmt.Errorf("no keys bound to %q", action)
+		}
+		normalized := make([]string, len(bound))
+		for i, k := range bound {
+			if k == "space" {
+				k = " "
+			}
+			normalized[i] = k
+		}
+		*b = binding(b.Help().Desc, normalized...)
+	}
+
+	// A key bound to two actions would only ever trigger one of them. Confirm
+	// only applies in dialogs, where the list keys don't, so it may share a
+	// key with those.
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		if name != "confirm" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	owner := make(map[string]string)
+	for _, name := range names {
+		for _, k := range actions[name].Keys() {
+			if other, ok := owner[k]; ok {
+				return keyMap{}, fmt.Errorf("key %q is bound to both %q and %q", keyLabel([]string{k}), other, name)
+			}
+			owner[k] = name
+		}
+	}
+	for _, k := range keys.Confirm.Keys() {
+		if slices.Contains(keys.Cancel.Keys(), k) {
+			return keyMap{}, fmt.Errorf("key %q is bound to both %q and %q", keyLabel([]string{k}), "cancel", "confirm")
+		}
+	}
+
+	return keys, nil
+}
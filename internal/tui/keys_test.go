@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLoadKeyMap(t *testing.T) {
+	m, store := newBulkTestModel(t)
+
+	keys, err := loadKeyMap(map[string][]string{
+		"delete": {"X"},
+		"mark":   {"space"},
+		"toggle": {"enter"},
+	})
+	if err != nil {
+		t.Fatalf("failed to load key map: %v", err)
+	}
+	m.keys = keys
+
+	// Help follows the bindings, so it can't drift from them
+	view := m.View()
+	for _, want := range []string{"X delete", "space select", "enter toggle"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the help, got:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "d delete") {
+		t.Errorf("expected the old delete key gone from the help, got:\n%s", view)
+	}
+
+	// The old key does nothing, the new one asks to delete
+	if m = press(m, runes("d")); m.deleteConfirm {
+		t.Error("expected d to be unbound")
+	}
+	if m = press(m, runes("X")); !m.deleteConfirm || m.deleteTarget != "a" {
+		t.Errorf("expected X to ask to delete a, got target %q", m.deleteTarget)
+	}
+	m = press(m, runes("n"))
+
+	m = press(m, enter, space)
+	if names := enabledNames(t, store); len(names) != 4 || names[0] != "b" {
+		t.Errorf("expected enter to disable only a, got %v", names)
+	}
+	if !m.marked[m.sources[0].ID] {
+		t.Error("expected space to select a")
+	}
+
+	for name, overrides := range map[string]map[string][]string{
+		"unknown action": {"explode": {"z"}},
+		"no keys":        {"delete": {}},
+		"conflict":       {"delete": {"a"}},
+	} {
+		if _, err := loadKeyMap(overrides); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadKeyMapDialogs(t *testing.T) {
+	m, store := newBulkTestModel(t)
+
+	keys, err := loadKeyMap(map[string][]string{
+		"cancel":  {"ctrl+g"},
+		"confirm": {"ctrl+s"},
+	})
+	if err != nil {
+		t.Fatalf("failed to load key map: %v", err)
+	}
+	m.keys = keys
+	cancel := tea.KeyMsg{Type: tea.KeyCtrlG}
+	confirm := tea.KeyMsg{Type: tea.KeyCtrlS}
+
+	// Dialogs cancel and confirm with the rebound keys, and say so
+	m = press(m, runes("a"))
+	if view := m.View(); !strings.Contains(view, "ctrl+s add") || !strings.Contains(view, "ctrl+g cancel") {
+		t.Errorf("expected the rebound keys in the add dialog help, got:\n%s", view)
+	}
+	if m = press(m, esc); !m.addMode {
+		t.Error("expected esc to leave the add dialog open")
+	}
+	if m = press(m, cancel); m.addMode || m.message != "Add cancelled" {
+		t.Errorf("expected ctrl+g to cancel, got message %q", m.message)
+	}
+
+	m = press(m, runes("d"), cancel)
+	if m.deleteConfirm {
+		t.Error("expected ctrl+g to cancel the delete")
+	}
+
+	m = press(m, runes("p"), runes("n"), runes("docs"), enter)
+	if m.presetPrompt != presetPromptNew {
+		t.Fatal("expected enter to leave the preset prompt open")
+	}
+	m = press(m, confirm)
+	if m.presetPrompt != presetPromptNone || m.message != "✓ Saved preset docs" {
+		t.Errorf("expected ctrl+s to save the preset, got message %q", m.message)
+	}
+	if _, err := store.Queries().GetPresetByName(context.Background(), "docs"); err != nil {
+		t.Errorf("expected the preset saved: %v", err)
+	}
+
+	// Confirm only applies in dialogs, so it may share a key with the list
+	// but not with cancel
+	if _, err := loadKeyMap(map[string][]string{"confirm": {"d"}}); err != nil {
+		t.Errorf("expected confirm to share a key with delete: %v", err)
+	}
+	if _, err := loadKeyMap(map[string][]string{"confirm": {"esc"}}); err == nil {
+		t.Error("expected an error binding confirm and cancel to one key")
+	}
+}
@@ -0,0 +1,401 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// pickerHeight is the number of entries the file picker shows at once
+const pickerHeight = 12
+
+// filePicker browses local directories to choose files to add, hiding what
+// git ignores unless asked to show it
+type filePicker struct {
+	dir         string
+	entries     []pickerEntry
+	cursor      int
+	selected    map[string]bool // absolute paths of chosen files
+	showIgnored bool
+	hidden      int // ignored entries not shown
+	err         error
+}
+
+type pickerEntry struct {
+	name    string
+	dir     bool
+	ignored bool
+}
+
+// ignoreRule is a .gitignore file and the directory its patterns are relative to
+type ignoreRule struct {
+	base  string
+	rules *ignore.GitIgnore
+}
+
+// newFilePicker opens a picker in dir, or the working directory if dir
+// isn't one
+func newFilePicker(dir string) filePicker {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir, _ = os.Getwd()
+	}
+
+	p := filePicker{dir: dir, selected: make(map[string]bool)}
+	p.load()
+	return p
+}
+
+// load reads the current directory, directories first
+func (p *filePicker) load() {
+	p.entries = nil
+	p.hidden = 0
+
+	entries, err := os.ReadDir(p.dir)
+	p.err = err
+	if err != nil {
+		return
+	}
+
+	rules := ignoreRules(p.dir)
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(p.dir, entry.Name())); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+
+		ignored := isIgnored(rules, filepath.Join(p.dir, entry.Name()), isDir)
+		if ignored && !p.showIgnored {
+			p.hidden++
+			continue
+		}
+		p.entries = append(p.entries, pickerEntry{name: entry.Name(), dir: isDir, ignored: ignored})
+	}
+
+	sort.SliceStable(p.entries, func(i, j int) bool {
+		return p.entries[i].dir && !p.entries[j].dir
+	})
+	p.cursor = max(0, min(p.cursor, len(p.entries)-1))
+}
+
+// chdir moves to another directory, highlighting focus in it if present
+func (p *filePicker) chdir(dir, focus string) {
+	p.dir = dir
+	p.cursor = 0
+	p.load()
+
+	for i, entry := range p.entries {
+		if entry.name == focus {
+			p.cursor = i
+		}
+	}
+}
+
+func (p filePicker) current() (pickerEntry, bool) {
+	if p.cursor < 0 || p.cursor >= len(p.entries) {
+		return pickerEntry{}, false
+	}
+	return p.entries[p.cursor], true
+}
+
+// chosen returns the selected files in order
+func (p filePicker) chosen() []string {
+	paths := make([]string, 0, len(p.selected))
+	for path := range p.selected {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ignoreRules loads the .gitignore files that apply to dir, from the root of
+// its git repository down to dir itself
+func ignoreRules(dir string) []ignoreRule {
+	// Without a repository only the directory's own .gitignore applies
+	root := dir
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	var dirs []string
+	for d := dir; ; d = filepath.Dir(d) {
+		dirs = append([]string{d}, dirs...)
+		if d == root || filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	var rules []ignoreRule
+	for _, d := range dirs {
+		compiled, err := ignore.CompileIgnoreFile(filepath.Join(d, ".gitignore"))
+		if err != nil {
+			continue
+		}
+		rules = append(rules, ignoreRule{base: d, rules: compiled})
+	}
+	return rules
+}
+
+func isIgnored(rules []ignoreRule, path string, isDir bool) bool {
+	for _, rule := range rules {
+		rel, err := filepath.Rel(rule.base, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if isDir {
+			rel += "/"
+		}
+		if rule.rules.MatchesPath(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestName derives a source name from a file name that isn't in taken,
+// adding the parent directory and then a number when needed
+func suggestName(path string, taken map[string]bool) string {
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	if name == "" {
+		name = base
+	}
+	if taken[name] {
+		name = filepath.Base(filepath.Dir(path)) + "/" + name
+	}
+
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)", name, i)
+	}
+	return candidate
+}
+
+// openPicker shows the file picker in the add modal, starting next to the
+// path typed so far
+func (m *model) openPicker() {
+	start := strings.TrimSpace(m.pathInput.Value())
+	if start != "" {
+		if abs, err := filepath.Abs(start); err == nil {
+			start = abs
+		}
+		if info, err := os.Stat(start); err != nil || !info.IsDir() {
+			start = filepath.Dir(start)
+		}
+	}
+
+	m.picker = newFilePicker(start)
+	m.picking = true
+	m.message = ""
+}
+
+func (m model) updatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	p := &m.picker
+	switch {
+	case keyMsg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(keyMsg, m.keys.Cancel):
+		m.picking = false
+
+	case key.Matches(keyMsg, pickerUp):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+
+	case key.Matches(keyMsg, pickerDown):
+		if p.cursor < len(p.entries)-1 {
+			p.cursor++
+		}
+
+	case key.Matches(keyMsg, pickerParent):
+		if parent := filepath.Dir(p.dir); parent != p.dir {
+			p.chdir(parent, filepath.Base(p.dir))
+		}
+
+	case key.Matches(keyMsg, pickerOpen):
+		if entry, ok := p.current(); ok && entry.dir {
+			p.chdir(filepath.Join(p.dir, entry.name), "")
+		}
+
+	case key.Matches(keyMsg, pickerSelect):
+		if entry, ok := p.current(); ok && !entry.dir {
+			path := filepath.Join(p.dir, entry.name)
+			if p.selected[path] {
+				delete(p.selected, path)
+			} else {
+				p.selected[path] = true
+			}
+			if p.cursor < len(p.entries)-1 {
+				p.cursor++
+			}
+		}
+
+	case key.Matches(keyMsg, pickerAll):
+		// Select every file shown in this directory
+		for _, entry := range p.entries {
+			if !entry.dir {
+				p.selected[filepath.Join(p.dir, entry.name)] = true
+			}
+		}
+
+	case key.Matches(keyMsg, pickerIgnored):
+		p.showIgnored = !p.showIgnored
+		focus := ""
+		if entry, ok := p.current(); ok {
+			focus = entry.name
+		}
+		p.chdir(p.dir, focus)
+
+	case key.Matches(keyMsg, m.keys.Confirm):
+		// Directories open; files add the selection, or just this file
+		entry, ok := p.current()
+		if ok && entry.dir {
+			p.chdir(filepath.Join(p.dir, entry.name), "")
+			return m, nil
+		}
+
+		paths := p.chosen()
+		if len(paths) == 0 && ok {
+			paths = []string{filepath.Join(p.dir, entry.name)}
+		}
+		if len(paths) == 0 {
+			return m, nil
+		}
+		return m.addPicked(paths)
+	}
+
+	return m, nil
+}
+
+// addPicked fills in the form for a single file so its name can be edited,
+// or adds several files at once with suggested names
+func (m model) addPicked(paths []string) (tea.Model, tea.Cmd) {
+	m.picking = false
+
+	// Re-adding a file that is already a source keeps its name
+	byPath := make(map[string]string, len(m.sources))
+	taken := make(map[string]bool, len(m.sources))
+	for _, source := range m.sources {
+		byPath[source.Path] = source.Name
+		taken[source.Name] = true
+	}
+	nameFor := func(path string) string {
+		if name, ok := byPath[path]; ok {
+			return name
+		}
+		name := suggestName(path, taken)
+		taken[name] = true
+		return name
+	}
+
+	if len(paths) == 1 {
+		m.pathInput.SetValue(paths[0])
+		if strings.TrimSpace(m.nameInput.Value()) == "" {
+			m.nameInput.SetValue(nameFor(paths[0]))
+		}
+		m.focusIndex = 0
+		m.pathInput.Blur()
+		return m, m.nameInput.Focus()
+	}
+
+	m.addMode = false
+	m.message = ""
+	cmds := make([]tea.Cmd, 0, len(paths))
+	for _, path := range paths {
+		cmds = append(cmds, m.addSourceCmd(nameFor(path), path))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m model) renderPicker() string {
+	var b strings.Builder
+	p := m.picker
+
+	dir := p.dir
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(dir, home) {
+		dir = "~" + strings.TrimPrefix(dir, home)
+	}
+	if len(dir) > 50 {
+		dir = "…" + dir[len(dir)-49:]
+	}
+	b.WriteString(inputLabelStyle.Render("Choose files: " + dir))
+	b.WriteString("\n\n")
+
+	switch {
+	case p.err != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", p.err)))
+		b.WriteString("\n")
+	case len(p.entries) == 0:
+		b.WriteString(normalItemStyle.Render("  (empty)"))
+		b.WriteString("\n")
+	}
+
+	start := max(0, min(p.cursor-pickerHeight/2, len(p.entries)-pickerHeight))
+	end := min(len(p.entries), start+pickerHeight)
+	for i := start; i < end; i++ {
+		entry := p.entries[i]
+		cursor := "  "
+		style := normalItemStyle
+		if i == p.cursor {
+			cursor = "> "
+			style = selectedItemStyle
+		} else if entry.ignored {
+			style = helpStyle
+		}
+
+		line := "▸ " + entry.name + "/"
+		if !entry.dir {
+			check := "[ ]"
+			if p.selected[filepath.Join(p.dir, entry.name)] {
+				check = "[x]"
+			}
+			line = check + " " + entry.name
+		}
+		b.WriteString(style.Render(cursor + truncate(line, 50)))
+		b.WriteString("\n")
+	}
+
+	status := fmt.Sprintf("%d selected", len(p.selected))
+	if p.hidden > 0 {
+		status += fmt.Sprintf(" • %d ignored hidden (%s to show)", p.hidden, pickerIgnored.Help().Key)
+	} else if p.showIgnored {
+		status += fmt.Sprintf(" • showing ignored (%s to hide)", pickerIgnored.Help().Key)
+	}
+	b.WriteString("\n")
+	b.WriteString(statusStyle.Render(status))
+	b.WriteString("\n\n")
+
+	b.WriteString(helpStyle.Render(m.help.ShortHelpView([]key.Binding{
+		pickerSelect, withDesc(m.keys.Confirm, "open/add"), withDesc(panKeys, "up/open"),
+	})))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(m.help.ShortHelpView([]key.Binding{
+		pickerAll, pickerIgnored, withDesc(m.keys.Cancel, "back"),
+	})))
+
+	return modalStyle.Render(b.String())
+}
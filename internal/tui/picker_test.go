@@ -0,0 +1,139 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	up    = tea.KeyMsg{Type: tea.KeyUp}
+	ctrlF = tea.KeyMsg{Type: tea.KeyCtrlF}
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func entryNames(p filePicker) []string {
+	var names []string
+	for _, entry := range p.entries {
+		names = append(names, entry.name)
+	}
+	return names
+}
+
+func TestFilePickerHidesIgnored(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD":         "ref: refs/heads/main",
+		".gitignore":        "*.log\nbuild/\n",
+		"docs/.gitignore":   "draft.md\n",
+		"docs/guide.md":     "guide",
+		"docs/draft.md":     "draft",
+		"docs/debug.log":    "log",
+		"build/out.txt":     "out",
+		"README.md":         "readme",
+		"docs/api/spec.yml": "spec",
+	})
+
+	p := newFilePicker(root)
+	if got := entryNames(p); len(got) != 3 || got[0] != "docs" || got[1] != ".gitignore" || got[2] != "README.md" {
+		t.Errorf("expected docs/, .gitignore and README.md, got %v", got)
+	}
+	if p.hidden != 1 {
+		t.Errorf("expected build/ hidden, got %d hidden", p.hidden)
+	}
+
+	// Rules from the repository root and the directory itself both apply
+	p.chdir(filepath.Join(root, "docs"), "")
+	if got := entryNames(p); len(got) != 3 || got[0] != "api" || got[2] != "guide.md" {
+		t.Errorf("expected api/, .gitignore and guide.md, got %v", got)
+	}
+
+	p.showIgnored = true
+	p.load()
+	if got := len(p.entries); got != 5 {
+		t.Errorf("expected 5 entries with ignored files shown, got %d", got)
+	}
+}
+
+func TestPickMultipleFiles(t *testing.T) {
+	m, _ := newBulkTestModel(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a.md":       "a again",
+		"notes.txt":  "notes",
+		"sub/x.go":   "package x",
+		"sub/y.json": "{}",
+	})
+
+	m = press(m, runes("a"))
+	m.pathInput.SetValue(root)
+	m = press(m, ctrlF)
+	if !m.picking || m.picker.dir != root {
+		t.Fatalf("expected the picker open in %s, got %q", root, m.picker.dir)
+	}
+
+	// Select a.md and notes.txt, then everything in sub/
+	m = press(m, down, space, space, up, up, enter, selectAll)
+	if got := m.picker.chosen(); len(got) != 4 {
+		t.Fatalf("expected 4 files selected, got %v", got)
+	}
+
+	next, cmd := m.Update(enter)
+	m = next.(model)
+	if m.addMode || m.picking {
+		t.Error("expected adding several files to close the dialog")
+	}
+	if got := len(m.jobs.running); got != 4 {
+		t.Errorf("expected 4 adds running, got %d", got)
+	}
+	if cmd == nil {
+		t.Fatal("expected commands adding the files")
+	}
+
+	// a is taken, so a.md is named after its directory
+	var labels []string
+	for _, job := range m.jobs.running {
+		labels = append(labels, strings.TrimPrefix(job.label, "Adding "))
+	}
+	want := map[string]bool{filepath.Base(root) + "/a": true, "notes": true, "x": true, "y": true}
+	for _, label := range labels {
+		if !want[label] {
+			t.Errorf("unexpected suggested name %q in %v", label, labels)
+		}
+	}
+}
+
+func TestPickSingleFileSuggestsName(t *testing.T) {
+	m, _ := newBulkTestModel(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"design-notes.md": "notes"})
+
+	m = press(m, runes("a"))
+	m.pathInput.SetValue(filepath.Join(root, "design-notes.md"))
+	m = press(m, ctrlF, enter)
+
+	if m.picking || !m.addMode {
+		t.Fatal("expected to be back in the add form")
+	}
+	if got := m.pathInput.Value(); got != filepath.Join(root, "design-notes.md") {
+		t.Errorf("unexpected path %q", got)
+	}
+	if got := m.nameInput.Value(); got != "design-notes" {
+		t.Errorf("expected the name suggested from the file, got %q", got)
+	}
+}
@@ -0,0 +1,428 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// presetPaneWidth is the width of the preset sidebar, border included
+const presetPaneWidth = 30
+
+// presetEntry is a preset along with the IDs of its sources
+type presetEntry struct {
+	preset  dbgen.Preset
+	sources map[int64]bool
+}
+
+// presetPrompt is what the sidebar is asking for
+type presetPrompt int
+
+const (
+	presetPromptNone presetPrompt = iota
+	presetPromptNew
+	presetPromptRename
+	presetPromptDelete
+)
+
+func newPresetInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "preset name"
+	input.CharLimit = 100
+	input.Width = presetPaneWidth - 6
+	return input
+}
+
+// loadPresets reads the presets and their sources from the store
+func (m *model) loadPresets(ctx context.Context) error {
+	presets, err := m.store.Queries().ListPresets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list presets: %w", err)
+	}
+
+	entries := make([]presetEntry, 0, len(presets))
+	for _, preset := range presets {
+		sources, err := m.store.Queries().GetPresetSources(ctx, preset.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get preset sources: %w", err)
+		}
+
+		ids := make(map[int64]bool, len(sources))
+		for _, source := range sources {
+			ids[source.ID] = true
+		}
+		entries = append(entries, presetEntry{preset: preset, sources: ids})
+	}
+
+	m.presets = entries
+	m.presetCursor = max(0, min(m.presetCursor, len(m.presets)-1))
+	return nil
+}
+
+// activePreset returns the name of the preset whose sources are exactly the
+// enabled ones, or an empty string if none match
+func (m model) activePreset() string {
+	enabled := make(map[int64]bool)
+	for _, source := range m.sources {
+		if source.Enabled == 1 {
+			enabled[source.ID] = true
+		}
+	}
+	if len(enabled) == 0 {
+		return ""
+	}
+
+	for _, entry := range m.presets {
+		if len(entry.sources) != len(enabled) {
+			continue
+		}
+		match := true
+		for id := range enabled {
+			if !entry.sources[id] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return entry.preset.Name
+		}
+	}
+	return ""
+}
+
+// sidebarWidth is the width taken from the list and preview by the sidebar
+func (m model) sidebarWidth() int {
+	if !m.presetsOpen {
+		return 0
+	}
+	return presetPaneWidth + 1
+}
+
+// togglePresets opens or closes the preset sidebar
+func (m *model) togglePresets() error {
+	if !m.presetsOpen {
+		if err := m.loadPresets(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	m.presetsOpen = !m.presetsOpen
+	m.presetPrompt = presetPromptNone
+	m.resizePreview()
+	if !m.showPreview() {
+		m.previewFocus = false
+	}
+	return nil
+}
+
+func (m model) selectedPreset() (presetEntry, bool) {
+	if m.presetCursor < 0 || m.presetCursor >= len(m.presets) {
+		return presetEntry{}, false
+	}
+	return m.presets[m.presetCursor], true
+}
+
+func (m model) updatePresets(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.presetPrompt != presetPromptNone {
+		return m.updatePresetPrompt(msg)
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case keyMsg.String() == "ctrl+c":
+		return m, tea.Quit
+
+	case key.Matches(keyMsg, m.keys.Cancel, m.keys.Presets):
+		if err := m.togglePresets(); err != nil {
+			m.message = fmt.Sprintf("Error: %v", err)
+		}
+
+	case key.Matches(keyMsg, m.keys.Up):
+		if m.presetCursor > 0 {
+			m.presetCursor--
+		}
+
+	case key.Matches(keyMsg, m.keys.Down):
+		if m.presetCursor < len(m.presets)-1 {
+			m.presetCursor++
+		}
+
+	case key.Matches(keyMsg, presetApply):
+		if entry, ok := m.selectedPreset(); ok {
+			var cmd tea.Cmd
+			m.message, cmd = m.applyPreset(entry)
+			return m, cmd
+		}
+
+	case key.Matches(keyMsg, presetNew):
+		// Save the enabled sources as a new preset
+		m.presetPrompt = presetPromptNew
+		m.presetInput.SetValue("")
+		m.message = ""
+		return m, m.presetInput.Focus()
+
+	case key.Matches(keyMsg, presetUpdate):
+		if entry, ok := m.selectedPreset(); ok {
+			m.message = m.updatePresetSources(entry)
+		}
+
+	case key.Matches(keyMsg, presetRename):
+		if entry, ok := m.selectedPreset(); ok {
+			m.presetPrompt = presetPromptRename
+			m.presetInput.SetValue(entry.preset.Name)
+			m.presetInput.CursorEnd()
+			m.message = ""
+			return m, m.presetInput.Focus()
+		}
+
+	case key.Matches(keyMsg, presetDelete):
+		if _, ok := m.selectedPreset(); ok {
+			m.presetPrompt = presetPromptDelete
+			m.message = ""
+		}
+	}
+
+	return m, nil
+}
+
+func (m model) updatePresetPrompt(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+
+	if m.presetPrompt == presetPromptDelete {
+		if !ok {
+			return m, nil
+		}
+		if key.Matches(keyMsg, deleteYes) {
+			if entry, ok := m.selectedPreset(); ok {
+				m.message = m.deletePreset(entry)
+			}
+		} else {
+			m.message = "Delete cancelled"
+		}
+		m.presetPrompt = presetPromptNone
+		return m, nil
+	}
+
+	if ok {
+		switch {
+		case keyMsg.String() == "ctrl+c":
+			return m, tea.Quit
+
+		case key.Matches(keyMsg, m.keys.Cancel):
+			m.presetPrompt = presetPromptNone
+			m.presetInput.Blur()
+			return m, nil
+
+		case key.Matches(keyMsg, m.keys.Confirm):
+			name := strings.TrimSpace(m.presetInput.Value())
+			if name == "" {
+				m.message = "Error: Name cannot be empty"
+				return m, nil
+			}
+
+			if m.presetPrompt == presetPromptNew {
+				m.message = m.savePreset(name)
+			} else if entry, ok := m.selectedPreset(); ok {
+				m.message = m.renamePreset(entry, name)
+			}
+			if !strings.HasPrefix(m.message, "Error") {
+				m.presetPrompt = presetPromptNone
+				m.presetInput.Blur()
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.presetInput, cmd = m.presetInput.Update(msg)
+	return m, cmd
+}
+
+// applyPreset enables exactly the preset's sources, returning a status
+// message and a command fetching any pending sources it enabled
+func (m *model) applyPreset(entry presetEntry) (string, tea.Cmd) {
+	ctx := context.Background()
+	changed, err := m.store.Queries().ApplyPreset(ctx, entry.preset.ID)
+	if err != nil {
+		return fmt.Sprintf("Error applying preset: %v", err), nil
+	}
+
+	sources, err := m.store.Queries().ListSources(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error reloading: %v", err), nil
+	}
+	m.setSources(sources)
+
+	var cmds []tea.Cmd
+	for _, source := range sources {
+		if _, busy := m.jobs.forSource(source.ID); source.Pending == 1 && source.Enabled == 1 && !busy {
+			cmds = append(cmds, m.fetchPending(source))
+		}
+	}
+
+	msg := fmt.Sprintf("✓ Applied preset %s (%d sources changed)", entry.preset.Name, changed)
+	if len(cmds) > 0 {
+		msg += " • fetching in background"
+	}
+	return msg, tea.Batch(cmds...)
+}
+
+// savePreset stores the enabled sources as a new preset
+func (m *model) savePreset(name string) string {
+	ctx := context.Background()
+	if _, err := m.store.Queries().GetPresetByName(ctx, name); err == nil {
+		return fmt.Sprintf("Error: preset %q already exists", name)
+	}
+
+	if err := m.withTx(ctx, func(q *dbgen.Queries) error {
+		preset, err := q.CreatePreset(ctx, dbgen.CreatePresetParams{Name: name})
+		if err != nil {
+			return fmt.Errorf("failed to create preset: %w", err)
+		}
+		return m.addEnabledSources(ctx, q, preset.ID)
+	}); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	if err := m.loadPresets(ctx); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	for i, entry := range m.presets {
+		if entry.preset.Name == name {
+			m.presetCursor = i
+		}
+	}
+	return fmt.Sprintf("✓ Saved preset %s", name)
+}
+
+// updatePresetSources replaces a preset's sources with the enabled ones
+func (m *model) updatePresetSources(entry presetEntry) string {
+	ctx := context.Background()
+	if err := m.withTx(ctx, func(q *dbgen.Queries) error {
+		if err := q.ClearPresetSources(ctx, entry.preset.ID); err != nil {
+			return fmt.Errorf("failed to clear preset sources: %w", err)
+		}
+		return m.addEnabledSources(ctx, q, entry.preset.ID)
+	}); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	if err := m.loadPresets(ctx); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("✓ Updated preset %s with the enabled sources", entry.preset.Name)
+}
+
+func (m *model) renamePreset(entry presetEntry, name string) string {
+	if name == entry.preset.Name {
+		return ""
+	}
+
+	ctx := context.Background()
+	if _, err := m.store.Queries().GetPresetByName(ctx, name); err == nil {
+		return fmt.Sprintf("Error: preset %q already exists", name)
+	}
+
+	if err := m.store.Queries().RenamePreset(ctx, dbgen.RenamePresetParams{
+		Name: name,
+		ID:   entry.preset.ID,
+	}); err != nil {
+		return fmt.Sprintf("Error renaming preset: %v", err)
+	}
+
+	if err := m.loadPresets(ctx); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("✓ Renamed preset %s to %s", entry.preset.Name, name)
+}
+
+func (m *model) deletePreset(entry presetEntry) string {
+	ctx := context.Background()
+	if err := m.store.Queries().DeletePreset(ctx, entry.preset.ID); err != nil {
+		return fmt.Sprintf("Error deleting preset: %v", err)
+	}
+
+	if err := m.loadPresets(ctx); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("✓ Deleted preset %s", entry.preset.Name)
+}
+
+func (m *model) addEnabledSources(ctx context.Context, q *dbgen.Queries, presetID int64) error {
+	for _, source := range m.sources {
+		if source.Enabled != 1 {
+			continue
+		}
+		if err := q.AddSourceToPreset(ctx, dbgen.AddSourceToPresetParams{
+			PresetID: presetID,
+			SourceID: source.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to add source to preset: %w", err)
+		}
+	}
+	return nil
+}
+
+// renderPresets draws the preset sidebar
+func (m model) renderPresets() string {
+	var b strings.Builder
+
+	b.WriteString(inputLabelStyle.Render("Presets"))
+	b.WriteString("\n\n")
+
+	active := m.activePreset()
+	if len(m.presets) == 0 {
+		b.WriteString(helpStyle.Render("No presets yet. Press " + presetNew.Help().Key + " to save the enabled sources as one."))
+		b.WriteString("\n")
+	}
+	for i, entry := range m.presets {
+		cursor := "  "
+		style := normalItemStyle
+		if i == m.presetCursor {
+			cursor = "> "
+			style = selectedItemStyle
+		}
+
+		marker := "  "
+		if entry.preset.Name == active {
+			marker = activePresetStyle.Render("● ")
+		}
+
+		name := truncate(entry.preset.Name, presetPaneWidth-14)
+		b.WriteString(style.Render(cursor) + marker + style.Render(fmt.Sprintf("%s (%d)", name, len(entry.sources))))
+		b.WriteString("\n")
+	}
+
+	switch m.presetPrompt {
+	case presetPromptNew:
+		b.WriteString("\n")
+		b.WriteString(inputLabelStyle.Render("Save enabled sources as:"))
+		b.WriteString("\n")
+		b.WriteString(m.presetInput.View())
+	case presetPromptRename:
+		b.WriteString("\n")
+		b.WriteString(inputLabelStyle.Render("Rename to:"))
+		b.WriteString("\n")
+		b.WriteString(m.presetInput.View())
+	case presetPromptDelete:
+		if entry, ok := m.selectedPreset(); ok {
+			b.WriteString("\n")
+			b.WriteString(errorStyle.Render(fmt.Sprintf("Delete %s? (y/n)", truncate(entry.preset.Name, 16))))
+		}
+	}
+
+	style := presetPaneStyle
+	if m.height > 0 {
+		style = style.Height(max(1, m.bodyHeight()-style.GetVerticalFrameSize()))
+	}
+	return style.Render(b.String())
+}
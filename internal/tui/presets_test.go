@@ -0,0 +1,277 @@
+package tui
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var clearInput = tea.KeyMsg{Type: tea.KeyCtrlU}
+
+// newPresetTestModel returns a model over sources named a to e, all enabled
+func newPresetTestModel(t *testing.T) (model, *storage.Store) {
+	t.Helper()
+	return newTestModel(t, testSources("a", "b", "c", "d", "e")...)
+}
+
+// createPreset stores a preset of the named sources
+func createPreset(t *testing.T, store *storage.Store, name string, sources ...string) {
+	t.Helper()
+	ctx := context.Background()
+
+	preset, err := store.Queries().CreatePreset(ctx, dbgen.CreatePresetParams{Name: name})
+	if err != nil {
+		t.Fatalf("failed to create preset: %v", err)
+	}
+	for _, sourceName := range sources {
+		source, err := store.Queries().GetSourceByName(ctx, sourceName)
+		if err != nil {
+			t.Fatalf("failed to get source: %v", err)
+		}
+		if err := store.Queries().AddSourceToPreset(ctx, dbgen.AddSourceToPresetParams{
+			PresetID: preset.ID,
+			SourceID: source.ID,
+		}); err != nil {
+			t.Fatalf("failed to add source to preset: %v", err)
+		}
+	}
+}
+
+// presetSourceNames returns the names of a stored preset's sources
+func presetSourceNames(t *testing.T, store *storage.Store, name string) []string {
+	t.Helper()
+	ctx := context.Background()
+
+	preset, err := store.Queries().GetPresetByName(ctx, name)
+	if err != nil {
+		t.Fatalf("failed to get preset %s: %v", name, err)
+	}
+	sources, err := store.Queries().GetPresetSources(ctx, preset.ID)
+	if err != nil {
+		t.Fatalf("failed to get preset sources: %v", err)
+	}
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// openPresets opens the sidebar with the cursor on the named preset
+func openPresets(t *testing.T, m model, name string) model {
+	t.Helper()
+
+	m = press(m, runes("p"))
+	if !m.presetsOpen {
+		t.Fatal("expected p to open the presets")
+	}
+	for range m.presets {
+		if entry, ok := m.selectedPreset(); ok && entry.preset.Name == name {
+			return m
+		}
+		m = press(m, down)
+	}
+	t.Fatalf("preset %s not listed", name)
+	return m
+}
+
+// titleLine returns the first line of the view
+func titleLine(m model) string {
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	title, _, _ := strings.Cut(next.(model).View(), "\n")
+	return title
+}
+
+func TestPresetApply(t *testing.T) {
+	m, store := newPresetTestModel(t)
+	createPreset(t, store, "cd", "c", "d")
+	createPreset(t, store, "all", "a", "b", "c", "d", "e")
+
+	m = openPresets(t, m, "cd")
+	m = press(m, enter)
+
+	if want := []string{"c", "d"}; !slices.Equal(enabledNames(t, store), want) {
+		t.Errorf("expected %v enabled, got %v", want, enabledNames(t, store))
+	}
+	if m.message != "✓ Applied preset cd (3 sources changed)" {
+		t.Errorf("unexpected message %q", m.message)
+	}
+	// The list reflects the preset without reloading
+	for _, source := range m.sources {
+		if enabled := source.Enabled == 1; enabled != (source.Name == "c" || source.Name == "d") {
+			t.Errorf("expected %s enabled=%v in the list", source.Name, !enabled)
+		}
+	}
+}
+
+func TestPresetSaveEnabledAsNew(t *testing.T) {
+	m, store := newPresetTestModel(t)
+	createPreset(t, store, "taken", "a")
+
+	// Disable c, d and e, then save what's left
+	m = press(m, down, down, space, down, space, down, space)
+	m = press(m, runes("p"), runes("n"))
+	if m.presetPrompt != presetPromptNew {
+		t.Fatal("expected n to ask for a name")
+	}
+
+	// A taken name keeps the prompt open to fix it
+	m = press(m, runes("taken"), enter)
+	if m.presetPrompt != presetPromptNew || m.message != `Error: preset "taken" already exists` {
+		t.Fatalf("expected a name conflict, got message %q", m.message)
+	}
+
+	m = press(m, clearInput, runes("ab"), enter)
+	if m.presetPrompt != presetPromptNone || m.message != "✓ Saved preset ab" {
+		t.Fatalf("expected the preset saved, got message %q", m.message)
+	}
+	if got := presetSourceNames(t, store, "ab"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("expected the enabled sources saved, got %v", got)
+	}
+	if entry, _ := m.selectedPreset(); entry.preset.Name != "ab" {
+		t.Errorf("expected the cursor on the new preset, got %q", entry.preset.Name)
+	}
+
+	// esc leaves the prompt without saving
+	m = press(m, runes("n"), runes("nope"), esc)
+	if m.presetPrompt != presetPromptNone || !m.presetsOpen {
+		t.Error("expected esc to close only the prompt")
+	}
+	if _, err := store.Queries().GetPresetByName(context.Background(), "nope"); err == nil {
+		t.Error("expected the cancelled preset not to be saved")
+	}
+}
+
+func TestPresetUpdate(t *testing.T) {
+	m, store := newPresetTestModel(t)
+	createPreset(t, store, "cd", "c", "d")
+
+	// Disable a, then update the preset with the rest
+	m = press(m, space)
+	m = openPresets(t, m, "cd")
+	m = press(m, runes("u"))
+
+	if m.message != "✓ Updated preset cd with the enabled sources" {
+		t.Errorf("unexpected message %q", m.message)
+	}
+	want := []string{"b", "c", "d", "e"}
+	if got := presetSourceNames(t, store, "cd"); !slices.Equal(got, want) {
+		t.Errorf("expected %v in the preset, got %v", want, got)
+	}
+	if entry, _ := m.selectedPreset(); len(entry.sources) != len(want) {
+		t.Errorf("expected the sidebar reloaded, got %d sources", len(entry.sources))
+	}
+}
+
+func TestPresetRename(t *testing.T) {
+	m, store := newPresetTestModel(t)
+	createPreset(t, store, "cd", "c", "d")
+	createPreset(t, store, "taken", "a")
+	ctx := context.Background()
+
+	m = openPresets(t, m, "cd")
+	m = press(m, runes("r"))
+	if m.presetPrompt != presetPromptRename || m.presetInput.Value() != "cd" {
+		t.Fatalf("expected a rename prompt with the current name, got %q", m.presetInput.Value())
+	}
+
+	m = press(m, clearInput, runes("taken"), enter)
+	if m.presetPrompt != presetPromptRename || m.message != `Error: preset "taken" already exists` {
+		t.Fatalf("expected a name conflict, got message %q", m.message)
+	}
+
+	m = press(m, clearInput, enter)
+	if m.message != "Error: Name cannot be empty" {
+		t.Fatalf("expected an empty name rejected, got message %q", m.message)
+	}
+
+	m = press(m, runes("docs"), enter)
+	if m.presetPrompt != presetPromptNone || m.message != "✓ Renamed preset cd to docs" {
+		t.Fatalf("expected the preset renamed, got message %q", m.message)
+	}
+	if _, err := store.Queries().GetPresetByName(ctx, "cd"); err == nil {
+		t.Error("expected the old name gone")
+	}
+	if got := presetSourceNames(t, store, "docs"); !slices.Equal(got, []string{"c", "d"}) {
+		t.Errorf("expected the sources kept, got %v", got)
+	}
+}
+
+func TestPresetDelete(t *testing.T) {
+	m, store := newPresetTestModel(t)
+	createPreset(t, store, "cd", "c", "d")
+	ctx := context.Background()
+
+	m = openPresets(t, m, "cd")
+	m = press(m, runes("d"), runes("n"))
+	if m.message != "Delete cancelled" {
+		t.Errorf("expected the delete cancelled, got message %q", m.message)
+	}
+	if _, err := store.Queries().GetPresetByName(ctx, "cd"); err != nil {
+		t.Fatalf("expected the preset kept: %v", err)
+	}
+
+	m = press(m, runes("d"))
+	if m.presetPrompt != presetPromptDelete {
+		t.Fatal("expected d to ask for confirmation")
+	}
+	m = press(m, runes("y"))
+	if m.message != "✓ Deleted preset cd" {
+		t.Errorf("unexpected message %q", m.message)
+	}
+	if _, err := store.Queries().GetPresetByName(ctx, "cd"); err == nil {
+		t.Error("expected the preset deleted")
+	}
+	if len(m.presets) != 0 {
+		t.Errorf("expected the sidebar emptied, got %d presets", len(m.presets))
+	}
+
+	// Deleting presets leaves the sources alone
+	if got := enabledNames(t, store); len(got) != 5 {
+		t.Errorf("expected all sources still enabled, got %v", got)
+	}
+}
+
+func TestPresetActiveIndicator(t *testing.T) {
+	m, store := newPresetTestModel(t)
+	createPreset(t, store, "cd", "c", "d")
+	createPreset(t, store, "all", "a", "b", "c", "d", "e")
+	m = press(m, runes("r"))
+
+	// All sources are enabled, which is exactly the "all" preset
+	if title := titleLine(m); !strings.Contains(title, "● preset: all") {
+		t.Errorf("expected all active in the title, got %q", title)
+	}
+
+	m = openPresets(t, m, "cd")
+	m = press(m, enter)
+	if title := titleLine(m); !strings.Contains(title, "● preset: cd") {
+		t.Errorf("expected cd active in the title, got %q", title)
+	}
+	for _, entry := range m.presets {
+		line := ""
+		for l := range strings.SplitSeq(m.renderPresets(), "\n") {
+			if strings.Contains(l, entry.preset.Name+" (") {
+				line = l
+			}
+		}
+		if marked := strings.Contains(line, "●"); marked != (entry.preset.Name == "cd") {
+			t.Errorf("expected %s marked=%v in the sidebar, got %q", entry.preset.Name, !marked, line)
+		}
+	}
+
+	// Enabling another source matches no preset
+	m = press(m, esc, space)
+	if m.activePreset() != "" {
+		t.Errorf("expected no active preset, got %q", m.activePreset())
+	}
+	if title := titleLine(m); strings.Contains(title, "● preset:") {
+		t.Errorf("expected no preset in the title, got %q", title)
+	}
+}
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// minPreviewWidth is the narrowest terminal that gets a preview pane
+	minPreviewWidth = 80
+	// maxPreviewBytes caps how much content is highlighted and shown
+	maxPreviewBytes = 256 * 1024
+	// previewHeaderLines is the height of the header above the content
+	previewHeaderLines = 3
+)
+
+// showPreview reports whether the terminal is wide enough for the preview pane
+func (m model) showPreview() bool {
+	return m.width-m.sidebarWidth() >= minPreviewWidth && m.height > 0
+}
+
+// paneWidths splits the terminal, less the preset sidebar, between the
+// source list and the preview
+func (m model) paneWidths() (list, preview int) {
+	width := m.width - m.sidebarWidth()
+	list = max(36, width*2/5)
+	return list, width - list - 1
+}
+
+// resizePreview fits the viewport into the preview pane
+func (m *model) resizePreview() {
+	_, width := m.paneWidths()
+	m.preview.Width = max(1, width-previewPaneStyle.GetHorizontalFrameSize())
+	m.preview.Height = max(1, m.bodyHeight()-previewPaneStyle.GetVerticalFrameSize()-previewHeaderLines)
+}
+
+// syncPreview loads the highlighted source into the viewport when the
+// selection or its content changes
+func (m *model) syncPreview() {
+	source, ok := m.selected()
+	key := ""
+	if ok {
+		key = fmt.Sprintf("%d:%s", source.ID, source.Hash)
+	}
+	if key == m.previewKey {
+		return
+	}
+
+	m.previewKey = key
+	m.preview.SetContent(renderPreviewContent(source, ok))
+	m.preview.GotoTop()
+}
+
+// renderPreviewContent highlights a source's cached content
+func renderPreviewContent(source dbgen.Source, ok bool) string {
+	switch {
+	case !ok:
+		return ""
+	case source.Pending == 1:
+		return "Not fetched yet. Enable this source to fetch it."
+	case source.Content == "":
+		return "(empty)"
+	}
+
+	content := source.Content
+	truncated := len(content) > maxPreviewBytes
+	if truncated {
+		// Cut at a rune boundary so the highlighter gets valid UTF-8
+		end := maxPreviewBytes
+		for end > 0 && !utf8.RuneStart(content[end]) {
+			end--
+		}
+		content = content[:end]
+	}
+	content = strings.ReplaceAll(content, "\t", "    ")
+
+	highlighted, err := highlight(content, lexerFor(source))
+	if err != nil {
+		highlighted = content
+	}
+	if truncated {
+		highlighted += fmt.Sprintf("\n\n… preview truncated at %d KB", maxPreviewBytes/1024)
+	}
+	return highlighted
+}
+
+// lexerFor picks a syntax highlighter for a source, or nil for plain text
+func lexerFor(source dbgen.Source) chroma.Lexer {
+	switch source.SourceType {
+	case "url", "bookmark", "notebook", "openapi":
+		// These are converted to markdown when parsed
+		return lexers.Get("markdown")
+	case "pdf":
+		return nil
+	default:
+		return lexers.Match(filepath.Base(source.Path))
+	}
+}
+
+func highlight(content string, lexer chroma.Lexer) (string, error) {
+	if lexer == nil {
+		return content, nil
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, content)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := formatters.TTY256.Format(&sb, styles.Get(previewStyle), iterator); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// renderPreview draws the preview pane: a header describing the source,
+// then its scrollable content
+func (m model) renderPreview() string {
+	_, width := m.paneWidths()
+	innerWidth := width - previewPaneStyle.GetHorizontalFrameSize()
+
+	var b strings.Builder
+	if source, ok := m.selected(); ok {
+		b.WriteString(inputLabelStyle.Render(truncate(source.Name, innerWidth)))
+		b.WriteString("\n")
+
+		hash := source.Hash
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		meta := fmt.Sprintf("%s • ~%d tokens • updated %s",
+			hash,
+			generator.EstimateTokens(source.Content),
+			time.Unix(source.UpdatedAt, 0).Format("2006-01-02 15:04"),
+		)
+		b.WriteString(helpStyle.UnsetMarginLeft().Render(truncate(meta, innerWidth)))
+		b.WriteString("\n")
+		if m.preview.TotalLineCount() > m.preview.Height {
+			b.WriteString(helpStyle.UnsetMarginLeft().Render(fmt.Sprintf("%3.f%%", m.preview.ScrollPercent()*100)))
+		}
+	}
+
+	header := lipgloss.NewStyle().Height(previewHeaderLines).Render(b.String())
+
+	style := previewPaneStyle
+	if m.previewFocus {
+		style = previewFocusedPaneStyle
+	}
+	return style.Width(innerWidth + 2).Render(header + "\n" + m.preview.View())
+}
@@ -0,0 +1,232 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	tab    = tea.KeyMsg{Type: tea.KeyTab}
+	pgDown = tea.KeyMsg{Type: tea.KeyPgDown}
+	pgUp   = tea.KeyMsg{Type: tea.KeyPgUp}
+)
+
+// newPreviewTestModel returns a sized model over a long Go source and a
+// short note, with the long source highlighted
+func newPreviewTestModel(t *testing.T) model {
+	t.Helper()
+
+	var lines []string
+	for i := range 200 {
+		lines = append(lines, fmt.Sprintf("var line%d = %d", i, i))
+	}
+	long := "package main\n\n" + strings.Join(lines, "\n")
+
+	m, _ := newTestModel(t,
+		dbgen.CreateSourceParams{
+			Name:       "main.go",
+			SourceType: "file",
+			Path:       "/repo/main.go",
+			Content:    long,
+			Hash:       storage.ComputeHash(long),
+			Enabled:    1,
+			Options:    "{}",
+		},
+		dbgen.CreateSourceParams{
+			Name:       "notes.md",
+			SourceType: "file",
+			Path:       "/repo/notes.md",
+			Content:    "short",
+			Hash:       storage.ComputeHash("short"),
+			Enabled:    1,
+			Options:    "{}",
+		},
+	)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = next.(model)
+
+	if source, _ := m.selected(); source.Name != "main.go" {
+		t.Fatalf("expected main.go highlighted, got %q", source.Name)
+	}
+	return m
+}
+
+func TestPreviewHeader(t *testing.T) {
+	m := newPreviewTestModel(t)
+	source, _ := m.selected()
+
+	view := m.View()
+	want := []string{
+		source.Hash[:12],
+		fmt.Sprintf("~%d tokens", generator.EstimateTokens(source.Content)),
+		"updated " + time.Unix(source.UpdatedAt, 0).Format("2006-01-02 15:04"),
+		"  0%",
+		"line0",
+	}
+	for _, w := range want {
+		if !strings.Contains(view, w) {
+			t.Errorf("expected %q in view, got:\n%s", w, view)
+		}
+	}
+}
+
+func TestPreviewFollowsCursor(t *testing.T) {
+	m := newPreviewTestModel(t)
+
+	m = press(m, down)
+	if source, _ := m.selected(); source.Name != "notes.md" {
+		t.Fatalf("expected notes.md highlighted, got %q", source.Name)
+	}
+	if view := m.preview.View(); !strings.Contains(view, "short") || strings.Contains(view, "line0") {
+		t.Errorf("expected the preview to show notes.md, got:\n%s", view)
+	}
+}
+
+func TestPreviewScrolling(t *testing.T) {
+	m := newPreviewTestModel(t)
+
+	// Paging from the list scrolls the preview without moving the cursor
+	m = press(m, pgDown)
+	if m.preview.YOffset == 0 {
+		t.Error("expected pgdown to scroll the preview")
+	}
+	m = press(m, pgUp)
+	if m.preview.YOffset != 0 {
+		t.Errorf("expected pgup to scroll back to the top, got offset %d", m.preview.YOffset)
+	}
+	if source, _ := m.selected(); source.Name != "main.go" {
+		t.Errorf("expected paging to leave the cursor, got %q", source.Name)
+	}
+
+	// With focus, the scroll keys go to the preview
+	m = press(m, tab)
+	if !m.previewFocus {
+		t.Fatal("expected tab to focus the preview")
+	}
+	m = press(m, down, down)
+	if m.preview.YOffset != 2 {
+		t.Errorf("expected down to scroll by a line each, got offset %d", m.preview.YOffset)
+	}
+	if source, _ := m.selected(); source.Name != "main.go" {
+		t.Errorf("expected scrolling to leave the cursor, got %q", source.Name)
+	}
+
+	m = press(m, runes("G"))
+	if !m.preview.AtBottom() {
+		t.Error("expected G to scroll to the bottom")
+	}
+	if view := m.View(); !strings.Contains(view, "100%") {
+		t.Errorf("expected the scroll position in the header, got:\n%s", view)
+	}
+	m = press(m, runes("g"))
+	if !m.preview.AtTop() {
+		t.Error("expected g to scroll to the top")
+	}
+}
+
+func TestPreviewFocusSwitch(t *testing.T) {
+	m := newPreviewTestModel(t)
+
+	m = press(m, tab)
+	if !m.previewFocus {
+		t.Fatal("expected tab to focus the preview")
+	}
+	if view := m.View(); !strings.Contains(view, "back to list") {
+		t.Errorf("expected the preview help, got:\n%s", view)
+	}
+
+	// tab and esc both return to the list, where down moves the cursor
+	m = press(m, tab)
+	if m.previewFocus {
+		t.Fatal("expected tab to return to the list")
+	}
+	m = press(m, tab, esc)
+	if m.previewFocus {
+		t.Fatal("expected esc to return to the list")
+	}
+	m = press(m, down)
+	if source, _ := m.selected(); source.Name != "notes.md" {
+		t.Errorf("expected down to move the cursor, got %q", source.Name)
+	}
+}
+
+func TestPreviewNarrowTerminal(t *testing.T) {
+	m := newPreviewTestModel(t)
+	m = press(m, tab)
+
+	// Shrinking the terminal hides the pane and drops its focus
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 60, Height: 40})
+	m = next.(model)
+	if m.previewFocus {
+		t.Error("expected focus to leave the hidden preview")
+	}
+	if m = press(m, tab); m.previewFocus {
+		t.Error("expected tab to do nothing without a preview")
+	}
+	if view := m.View(); strings.Contains(view, "tokens •") {
+		t.Errorf("expected no preview pane, got:\n%s", view)
+	}
+}
+
+func TestRenderPreviewContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    dbgen.Source
+		want      string
+		highlight bool
+	}{
+		{
+			name:      "highlighted by extension",
+			source:    dbgen.Source{SourceType: "file", Path: "/repo/main.go", Content: "package main"},
+			want:      "package",
+			highlight: true,
+		},
+		{
+			name:   "plain text",
+			source: dbgen.Source{SourceType: "pdf", Path: "/repo/paper.pdf", Content: "package main"},
+			want:   "package main",
+		},
+		{
+			name:   "pending",
+			source: dbgen.Source{SourceType: "url", Pending: 1},
+			want:   "Not fetched yet",
+		},
+		{
+			name: "truncated at a rune boundary",
+			source: dbgen.Source{
+				SourceType: "pdf",
+				Path:       "/repo/paper.pdf",
+				Content:    "x" + strings.Repeat("é", maxPreviewBytes),
+			},
+			want: "preview truncated",
+		},
+		{
+			name:   "empty",
+			source: dbgen.Source{SourceType: "file", Path: "/repo/empty.txt"},
+			want:   "(empty)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderPreviewContent(tt.source, true)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("expected %q in %q", tt.want, got)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("expected valid UTF-8, got a cut rune")
+			}
+			if highlighted := strings.Contains(got, "\x1b["); highlighted != tt.highlight {
+				t.Errorf("expected highlighted=%v, got %q", tt.highlight, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the set of colors the TUI is drawn with
+type Theme struct {
+	Title   lipgloss.TerminalColor
+	Accent  lipgloss.TerminalColor
+	Text    lipgloss.TerminalColor
+	Muted   lipgloss.TerminalColor
+	Success lipgloss.TerminalColor
+	Error   lipgloss.TerminalColor
+	Warning lipgloss.TerminalColor
+	Marked  lipgloss.TerminalColor
+	Info    lipgloss.TerminalColor
+
+	// Preview is the chroma style used to highlight previews
+	Preview string
+}
+
+// DefaultTheme is the theme used when the config doesn't name one
+const DefaultTheme = "dark"
+
+// themes are the built in themes, selectable by name from the config
+var themes = map[string]Theme{
+	"dark": {
+		Title:   lipgloss.Color("205"),
+		Accent:  lipgloss.Color("170"),
+		Text:    lipgloss.Color("252"),
+		Muted:   lipgloss.Color("241"),
+		Success: lipgloss.Color("42"),
+		Error:   lipgloss.Color("196"),
+		Warning: lipgloss.Color("214"),
+		Marked:  lipgloss.Color("212"),
+		Info:    lipgloss.Color("81"),
+		Preview: "monokai",
+	},
+	"light": {
+		Title:   lipgloss.Color("161"),
+		Accent:  lipgloss.Color("91"),
+		Text:    lipgloss.Color("236"),
+		Muted:   lipgloss.Color("243"),
+		Success: lipgloss.Color("28"),
+		Error:   lipgloss.Color("160"),
+		Warning: lipgloss.Color("166"),
+		Marked:  lipgloss.Color("127"),
+		Info:    lipgloss.Color("31"),
+		Preview: "github",
+	},
+	// high-contrast sticks to the bright ANSI colors and leaves plain text in
+	// the terminal's own foreground, so it reads on any background
+	"high-contrast": {
+		Title:   lipgloss.Color("15"),
+		Accent:  lipgloss.Color("11"),
+		Text:    lipgloss.NoColor{},
+		Muted:   lipgloss.NoColor{},
+		Success: lipgloss.Color("10"),
+		Error:   lipgloss.Color("9"),
+		Warning: lipgloss.Color("11"),
+		Marked:  lipgloss.Color("14"),
+		Info:    lipgloss.Color("14"),
+		Preview: "bw",
+	},
+}
+
+var (
+	titleStyle        lipgloss.Style
+	selectedItemStyle lipgloss.Style
+	normalItemStyle   lipgloss.Style
+	helpStyle         lipgloss.Style
+	statusStyle       lipgloss.Style
+	errorStyle        lipgloss.Style
+	modalStyle        lipgloss.Style
+	inputLabelStyle   lipgloss.Style
+	pendingStyle      lipgloss.Style
+	markedItemStyle   lipgloss.Style
+	changedStyle      lipgloss.Style
+
+	diffAddStyle    lipgloss.Style
+	diffRemoveStyle lipgloss.Style
+	diffHunkStyle   lipgloss.Style
+	diffFileStyle   lipgloss.Style
+
+	presetPaneStyle   lipgloss.Style
+	activePresetStyle lipgloss.Style
+
+	previewPaneStyle        lipgloss.Style
+	previewFocusedPaneStyle lipgloss.Style
+
+	// previewStyle is the chroma style used to highlight previews
+	previewStyle string
+
+	// helpKeyStyle and helpDescStyle color the keys and descriptions in help
+	helpKeyStyle  lipgloss.Style
+	helpDescStyle lipgloss.Style
+)
+
+func init() {
+	applyTheme(themes[DefaultTheme])
+}
+
+// applyTheme rebuilds every style from the theme's colors
+func applyTheme(t Theme) {
+	titleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Title).
+		MarginLeft(2)
+
+	selectedItemStyle = lipgloss.NewStyle().
+		Foreground(t.Accent).
+		Bold(true)
+
+	normalItemStyle = lipgloss.NewStyle().
+		Foreground(t.Text)
+
+	helpStyle = lipgloss.NewStyle().
+		Foreground(t.Muted).
+		MarginLeft(2)
+
+	statusStyle = lipgloss.NewStyle().
+		Foreground(t.Success).
+		MarginLeft(2)
+
+	errorStyle = lipgloss.NewStyle().
+		Foreground(t.Error).
+		MarginLeft(2)
+
+	modalStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Accent).
+		Padding(1, 2).
+		Width(60)
+
+	inputLabelStyle = lipgloss.NewStyle().
+		Foreground(t.Title).
+		Bold(true)
+
+	pendingStyle = lipgloss.NewStyle().Foreground(t.Warning)
+	markedItemStyle = lipgloss.NewStyle().Foreground(t.Marked)
+	changedStyle = lipgloss.NewStyle().Foreground(t.Info)
+
+	diffAddStyle = lipgloss.NewStyle().Foreground(t.Success)
+	diffRemoveStyle = lipgloss.NewStyle().Foreground(t.Error)
+	diffHunkStyle = lipgloss.NewStyle().Foreground(t.Info)
+	diffFileStyle = lipgloss.NewStyle().Bold(true)
+
+	presetPaneStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Accent).
+		Padding(0, 1).
+		Width(presetPaneWidth - 2)
+
+	activePresetStyle = lipgloss.NewStyle().
+		Foreground(t.Success).
+		Bold(true)
+
+	previewPaneStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Muted).
+		Padding(0, 1)
+
+	previewFocusedPaneStyle = previewPaneStyle.
+		BorderForeground(t.Accent)
+
+	previewStyle = t.Preview
+
+	helpKeyStyle = lipgloss.NewStyle().Foreground(t.Muted).Bold(true)
+	helpDescStyle = lipgloss.NewStyle().Foreground(t.Muted)
+}
+
+// loadTheme looks up a built in theme by name and applies color overrides on
+// top of it. Colors are named after the Theme fields in lower case, and take
+// ANSI numbers or hex values; preview takes a chroma style name.
+func loadTheme(name string, colors map[string]string) (Theme, error) {
+	if name == "" {
+		name = DefaultTheme
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(themeNames(), ", "))
+	}
+
+	for key, value := range colors {
+		color := lipgloss.Color(value)
+		switch strings.ToLower(key) {
+		case "title":
+			theme.Title = color
+		case "accent":
+			theme.Accent = color
+		case "text":
+			theme.Text = color
+		case "muted":
+			theme.Muted = color
+		case "success":
+			theme.Success = color
+		case "error":
+			theme.Error = color
+		case "warning":
+			theme.Warning = color
+		case "marked":
+			theme.Marked = color
+		case "info":
+			theme.Info = color
+		case "preview":
+			theme.Preview = value
+		default:
+			return Theme{}, fmt.Errorf("unknown theme color %q", key)
+		}
+	}
+
+	if _, ok := styles.Registry[theme.Preview]; !ok {
+		return Theme{}, fmt.Errorf("unknown preview style %q", theme.Preview)
+	}
+	return theme, nil
+}
+
+// themeNames lists the built in themes in order
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newHelp creates the help view in the current theme's colors
+func newHelp() help.Model {
+	h := help.New()
+	h.ShortSeparator = " • "
+	h.Styles.ShortKey = helpKeyStyle
+	h.Styles.ShortDesc = helpDescStyle
+	h.Styles.ShortSeparator = helpDescStyle
+	h.Styles.FullKey = helpKeyStyle
+	h.Styles.FullDesc = helpDescStyle
+	h.Styles.FullSeparator = helpDescStyle
+	h.Styles.Ellipsis = helpDescStyle
+	return h
+}
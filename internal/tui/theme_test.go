@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestLoadTheme(t *testing.T) {
+	for _, name := range themeNames() {
+		if _, err := loadTheme(name, nil); err != nil {
+			t.Errorf("failed to load built in theme %s: %v", name, err)
+		}
+	}
+
+	theme, err := loadTheme("light", map[string]string{"accent": "#5f87ff", "preview": "monokai"})
+	if err != nil {
+		t.Fatalf("failed to load theme: %v", err)
+	}
+	if theme.Accent != lipgloss.Color("#5f87ff") || theme.Preview != "monokai" {
+		t.Errorf("expected the overrides applied, got %+v", theme)
+	}
+	if theme.Text != themes["light"].Text {
+		t.Errorf("expected other colors kept, got %+v", theme)
+	}
+
+	for name, colors := range map[string]map[string]string{
+		"solarized":     nil,
+		"light":         {"background": "0"},
+		"high-contrast": {"preview": "no-such-style"},
+	} {
+		if _, err := loadTheme(name, colors); err == nil {
+			t.Errorf("expected an error loading %s with %v", name, colors)
+		}
+	}
+}
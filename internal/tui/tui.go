@@ -9,67 +9,101 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/brojonat/context-vacuum/internal/config"
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/importer"
 	"github.com/brojonat/context-vacuum/internal/parser"
 	"github.com/brojonat/context-vacuum/internal/storage"
 	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
 )
 
-var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("205")).
-			MarginLeft(2)
-
-	selectedItemStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("170")).
-				Bold(true)
-
-	normalItemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("252"))
-
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			MarginLeft(2)
-
-	statusStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("42")).
-			MarginLeft(2)
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
-			MarginLeft(2)
-
-	modalStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("170")).
-			Padding(1, 2).
-			Width(60)
-
-	inputLabelStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("205")).
-			Bold(true)
-)
+// fetchedMsg reports the result of fetching a pending source in the background
+type fetchedMsg struct {
+	id   int64
+	name string
+	err  error
+}
 
 type model struct {
 	store      *storage.Store
 	parser     *parser.Parser
+	importer   *importer.Importer
+	generator  *generator.Generator
 	logger     *slog.Logger
 	sources    []dbgen.Source
 	cursor     int
 	message    string
 	err        error
 
+	// Operations running in the background, shown with a spinner
+	jobs    *jobs
+	spinner spinner.Model
+
+	// Filtering: visible holds the indexes into sources that pass the
+	// fuzzy query and quick filters, and cursor indexes into visible
+	visible     []int
+	filterInput textinput.Model
+	filtering   bool   // typing a query
+	enabledOnly bool   // hide disabled sources
+	typeFilter  string // only show this source type when set
+	height      int    // terminal height, to keep the cursor on screen
+	width       int    // terminal width, to fit the preview pane
+
+	// Preview of the highlighted source; previewKey identifies what it shows
+	preview      viewport.Model
+	previewKey   string
+	previewFocus bool // scroll keys go to the preview instead of the list
+
+	// Sources changed since their last generation, mapped to the hash of
+	// the version included then, and the diff of one of them
+	changed  map[int64]string
+	diffOpen bool
+	diffView viewport.Model
+	diffName string
+
+	// Generate dialog
+	gen generateDialog
+
+	// Edit dialog
+	edit editDialog
+
+	// Multi-selection: marked sources, plus in visual mode the range from
+	// the anchor to the cursor; bulk operations apply to the selection
+	marked     map[int64]bool
+	visual     bool
+	anchorID   int64
+	bulkPrompt bulkPrompt
+	bulkInput  textinput.Model
+
+	// Preset sidebar
+	presets      []presetEntry
+	presetsOpen  bool
+	presetCursor int
+	presetPrompt presetPrompt
+	presetInput  textinput.Model
+
 	// Add mode fields
 	addMode    bool
 	nameInput  textinput.Model
 	pathInput  textinput.Model
 	focusIndex int // 0 = name, 1 = path
+	picking    bool
+	picker     filePicker
 
-	// Delete confirmation
+	// Key bindings and the help rendered from them
+	keys keyMap
+	help help.Model
+
+	// Delete confirmation; deleteIDs is set when deleting a selection
 	deleteConfirm bool
 	deleteTarget  string
+	deleteIDs     []int64
 }
 
 func initialModel(store *storage.Store, parser *parser.Parser, logger *slog.Logger) (model, error) {
@@ -91,10 +125,23 @@ func initialModel(store *storage.Store, parser *parser.Parser, logger *slog.Logg
 	pathInput.CharLimit = 500
 	pathInput.Width = 50
 
-	return model{
+	filterInput := textinput.New()
+	filterInput.Prompt = "/"
+	filterInput.Placeholder = "filter by name, path or type"
+	filterInput.CharLimit = 100
+	filterInput.Width = 40
+
+	preview := viewport.New(0, 0)
+	preview.SetHorizontalStep(4)
+
+	m := model{
 		store:         store,
 		parser:        parser,
+		importer:      importer.NewImporter(store, parser, logger),
+		generator:     generator.NewGenerator(store, parser, logger),
 		logger:        logger,
+		jobs:          newJobs(),
+		spinner:       spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(pendingStyle)),
 		sources:       sources,
 		cursor:        0,
 		addMode:       false,
@@ -103,16 +150,149 @@ func initialModel(store *storage.Store, parser *parser.Parser, logger *slog.Logg
 		focusIndex:    0,
 		deleteConfirm: false,
 		deleteTarget:  "",
-	}, nil
+		filterInput:   filterInput,
+		preview:       preview,
+		gen:           newGenerateDialog(),
+		presetInput:   newPresetInput(),
+		marked:        make(map[int64]bool),
+		bulkInput:     newBulkInput(),
+		keys:          newKeyMap(),
+		help:          newHelp(),
+	}
+	if err := m.loadPresets(ctx); err != nil {
+		return model{}, err
+	}
+	m.loadChanges()
+	m.refilter(0)
+	m.syncPreview()
+
+	return m, nil
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	// Enabled placeholders, e.g. from a failed fetch, are retried on startup
+	var cmds []tea.Cmd
+	for _, source := range m.sources {
+		if source.Pending == 1 && source.Enabled == 1 {
+			cmds = append(cmds, m.fetchPending(source))
+		}
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+
+	// Whatever happened, the preview follows the highlighted source
+	updated := next.(model)
+	updated.syncPreview()
+	return updated, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	// Background jobs finish regardless of the current mode
+	if next, cmd, ok := m.updateJobs(msg); ok {
+		return next, cmd
+	}
+
+	if msg, ok := msg.(fetchedMsg); ok {
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+
+		sources, err := m.store.Queries().ListSources(context.Background())
+		if err != nil {
+			m.message = fmt.Sprintf("Error reloading: %v", err)
+			return m, nil
+		}
+		m.setSources(sources)
+		m.message = fmt.Sprintf("✓ Fetched %s", msg.name)
+		return m, nil
+	}
+
+	if msg, ok := msg.(addedMsg); ok {
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error adding %s: %v", msg.name, msg.err)
+			return m, nil
+		}
+
+		sources, err := m.store.Queries().ListSources(context.Background())
+		if err != nil {
+			m.message = fmt.Sprintf("Error reloading: %v", err)
+			return m, nil
+		}
+		m.setSources(sources)
+		m.message = fmt.Sprintf("✓ Added source: %s", msg.name)
+		if msg.hint != "" {
+			m.message += " • " + msg.hint
+		}
+		return m, nil
+	}
+
+	if msg, ok := msg.(refreshedMsg); ok {
+		m.message = msg.String()
+		if msg.changed == 0 {
+			return m, nil
+		}
+
+		sources, err := m.store.Queries().ListSources(context.Background())
+		if err != nil {
+			m.message = fmt.Sprintf("Error reloading: %v", err)
+			return m, nil
+		}
+		m.setSources(sources)
+		return m, nil
+	}
+
+	if msg, ok := msg.(editedMsg); ok {
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error saving %s: %v", msg.name, msg.err)
+			return m, nil
+		}
+
+		if err := m.reloadSources(context.Background()); err != nil {
+			m.message = fmt.Sprintf("Error reloading: %v", err)
+			return m, nil
+		}
+		m.message = fmt.Sprintf("✓ Saved %s (fetched again)", msg.name)
+		return m, nil
+	}
+
+	if msg, ok := msg.(generatedMsg); ok {
+		m.message = generatedMessage(msg)
+		if msg.err != nil {
+			return m, nil
+		}
+
+		// Generating refreshes sources and clears their change marks
+		sources, err := m.store.Queries().ListSources(context.Background())
+		if err != nil {
+			m.message = fmt.Sprintf("Error reloading: %v", err)
+			return m, nil
+		}
+		m.setSources(sources)
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.height = msg.Height
+		m.width = msg.Width
+		m.resizePreview()
+		m.resizeDiff()
+		if !m.showPreview() {
+			m.previewFocus = false
+		}
+		return m, nil
+	}
+
+	// Handle the diff view
+	if m.diffOpen {
+		return m.updateDiff(msg)
+	}
+
 	// Handle add mode separately
 	if m.addMode {
 		return m.updateAddMode(msg)
@@ -123,13 +303,43 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateDeleteConfirm(msg)
 	}
 
+	// Handle the generate dialog
+	if m.gen.open {
+		return m.updateGenerateDialog(msg)
+	}
+
+	// Handle the edit dialog
+	if m.edit.open {
+		return m.updateEditDialog(msg)
+	}
+
+	// Handle tagging or adding the selection to a preset
+	if m.bulkPrompt != bulkPromptNone {
+		return m.updateBulkPrompt(msg)
+	}
+
+	// Handle the preset sidebar
+	if m.presetsOpen {
+		return m.updatePresets(msg)
+	}
+
+	// Handle typing a filter query
+	if m.filtering {
+		return m.updateFilter(msg)
+	}
+
+	// Handle scrolling the preview
+	if m.previewFocus {
+		return m.updatePreview(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
 
-		case "a":
+		case key.Matches(msg, m.keys.Add):
 			// Enter add mode
 			m.addMode = true
 			m.nameInput.Focus()
@@ -137,23 +347,143 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.nameInput.SetValue("")
 			m.pathInput.SetValue("")
 			m.focusIndex = 0
+			m.picking = false
 			m.message = ""
 			return m, nil
 
-		case "up", "k":
+		case key.Matches(msg, m.keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
 			}
 
-		case "down", "j":
-			if m.cursor < len(m.sources)-1 {
+		case key.Matches(msg, m.keys.Down):
+			if m.cursor < len(m.visible)-1 {
 				m.cursor++
 			}
 
-		case " ", "enter":
-			// Toggle enabled state
-			if len(m.sources) > 0 {
-				source := m.sources[m.cursor]
+		case key.Matches(msg, m.keys.ExtendUp):
+			// Extend the selection, starting visual mode if needed
+			if !m.visual {
+				m.startVisual()
+			}
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case key.Matches(msg, m.keys.ExtendDown):
+			if !m.visual {
+				m.startVisual()
+			}
+			if m.cursor < len(m.visible)-1 {
+				m.cursor++
+			}
+
+		case key.Matches(msg, m.keys.Visual):
+			// Start selecting a range, or keep the range selected
+			if m.visual {
+				m.endVisual()
+			} else {
+				m.startVisual()
+			}
+
+		case key.Matches(msg, m.keys.Mark):
+			// Select or deselect the current source
+			m.toggleMark()
+
+		case key.Matches(msg, m.keys.SelectAll):
+			// Select all visible sources
+			m.selectAllVisible()
+
+		case key.Matches(msg, m.keys.Tag):
+			// Tag the selection, or the current source
+			if len(m.targets()) > 0 {
+				return m, m.openBulkPrompt(bulkPromptTag)
+			}
+
+		case key.Matches(msg, m.keys.AddToPreset):
+			// Add the selection, or the current source, to a preset
+			if len(m.targets()) > 0 {
+				if err := m.loadPresets(context.Background()); err != nil {
+					m.message = fmt.Sprintf("Error: %v", err)
+					return m, nil
+				}
+				return m, m.openBulkPrompt(bulkPromptPreset)
+			}
+
+		case key.Matches(msg, m.keys.Generate):
+			// Open the generate dialog
+			if m.jobs.busy(jobGenerate) {
+				m.message = "Already generating"
+				return m, nil
+			}
+			opened, err := m.openGenerateDialog()
+			if err != nil {
+				m.message = fmt.Sprintf("Error: %v", err)
+				return m, nil
+			}
+			opened.message = ""
+			return opened, nil
+
+		case key.Matches(msg, m.keys.Presets):
+			// Open the preset sidebar
+			if err := m.togglePresets(); err != nil {
+				m.message = fmt.Sprintf("Error: %v", err)
+				return m, nil
+			}
+			m.message = ""
+
+		case key.Matches(msg, m.keys.Filter):
+			// Start typing a filter query
+			m.filtering = true
+			m.message = ""
+			return m, m.filterInput.Focus()
+
+		case key.Matches(msg, m.keys.EnabledOnly):
+			// Toggle showing only enabled sources
+			m.enabledOnly = !m.enabledOnly
+			m.applyFilter()
+
+		case key.Matches(msg, m.keys.TypeFilter):
+			// Cycle the source type filter
+			m.typeFilter = nextTypeFilter(m.sources, m.typeFilter)
+			m.applyFilter()
+
+		case key.Matches(msg, m.keys.Preview):
+			// Move focus to the preview to scroll it
+			if m.showPreview() {
+				m.previewFocus = true
+			}
+
+		case key.Matches(msg, m.keys.PageDown):
+			m.preview.PageDown()
+
+		case key.Matches(msg, m.keys.PageUp):
+			m.preview.PageUp()
+
+		case key.Matches(msg, m.keys.Cancel):
+			// Cancel background operations, or clear the selection, or
+			// clear all filters
+			if n := m.jobs.cancelAll(); n > 0 {
+				m.message = fmt.Sprintf("Cancelled %d operation(s)", n)
+				return m, nil
+			}
+			if m.hasSelection() {
+				m.clearSelection()
+				m.message = "Selection cleared"
+				return m, nil
+			}
+			m.filterInput.SetValue("")
+			m.enabledOnly = false
+			m.typeFilter = ""
+			m.applyFilter()
+
+		case key.Matches(msg, m.keys.Toggle):
+			// Toggle enabled state, of the whole selection if there is one
+			if m.hasSelection() {
+				if targets := m.targets(); len(targets) > 0 {
+					m.message, cmd = m.bulkToggle(targets)
+				}
+			} else if source, ok := m.selected(); ok {
 				newEnabled := int64(1)
 				if source.Enabled == 1 {
 					newEnabled = 0
@@ -173,33 +503,75 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if err != nil {
 						m.message = fmt.Sprintf("Error reloading: %v", err)
 					} else {
-						m.sources = sources
+						m.setSources(sources)
 						status := "disabled"
 						if newEnabled == 1 {
 							status = "enabled"
 						}
 						m.message = fmt.Sprintf("Toggled %s to %s", source.Name, status)
+
+						if _, busy := m.jobs.forSource(source.ID); newEnabled == 1 && source.Pending == 1 && !busy {
+							m.message += " • fetching in background"
+							cmd = m.fetchPending(source)
+						}
 					}
 				}
 			}
 
-		case "d":
-			// Delete current source (with confirmation)
-			if len(m.sources) > 0 {
-				source := m.sources[m.cursor]
+		case key.Matches(msg, m.keys.Delete):
+			// Delete the selection or current source (with one confirmation)
+			if m.hasSelection() {
+				m.deleteIDs = nil
+				for _, source := range m.targets() {
+					m.deleteIDs = append(m.deleteIDs, source.ID)
+				}
+				if len(m.deleteIDs) > 0 {
+					m.deleteConfirm = true
+					m.deleteTarget = fmt.Sprintf("%d sources", len(m.deleteIDs))
+					m.message = ""
+				}
+			} else if source, ok := m.selected(); ok {
 				m.deleteConfirm = true
 				m.deleteTarget = source.Name
 				m.message = ""
 			}
 
-		case "r":
+		case key.Matches(msg, m.keys.Refresh):
+			// Re-read the selection or current source from its path or URL
+			var idle []dbgen.Source
+			for _, source := range m.targets() {
+				if _, busy := m.jobs.forSource(source.ID); !busy {
+					idle = append(idle, source)
+				}
+			}
+			if len(idle) == 0 {
+				m.message = "Nothing to refresh that isn't busy already"
+				return m, nil
+			}
+			m.clearSelection()
+			m.message = ""
+			return m, m.refreshSources(idle)
+
+		case key.Matches(msg, m.keys.Edit):
+			// Edit the highlighted source's name, path, tags and options
+			return m, m.openEditDialog()
+
+		case key.Matches(msg, m.keys.Diff):
+			// Show what changed since the source was last generated
+			m.openDiff()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Reload):
 			// Reload sources
 			ctx := context.Background()
 			sources, err := m.store.Queries().ListSources(ctx)
+			if err == nil {
+				err = m.loadPresets(ctx)
+			}
 			if err != nil {
 				m.message = fmt.Sprintf("Error reloading: %v", err)
 			} else {
-				m.sources = sources
+				m.setSources(sources)
 				m.message = "Sources reloaded"
 			}
 		}
@@ -211,21 +583,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) updateAddMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if m.picking {
+		return m.updatePicker(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
+		switch {
+		case msg.String() == "ctrl+c":
 			return m, tea.Quit
 
-		case "esc":
+		case key.Matches(msg, browseFiles):
+			// Browse for local files instead of typing a path
+			m.openPicker()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Cancel):
 			// Exit add mode
 			m.addMode = false
 			m.message = "Add cancelled"
 			return m, nil
 
-		case "tab", "shift+tab":
+		case key.Matches(msg, fieldNext, fieldPrev):
 			// Switch focus between inputs
-			if msg.String() == "tab" {
+			if key.Matches(msg, fieldNext) {
 				m.focusIndex = (m.focusIndex + 1) % 2
 			} else {
 				m.focusIndex = (m.focusIndex - 1 + 2) % 2
@@ -240,7 +621,7 @@ func (m model) updateAddMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "enter":
+		case key.Matches(msg, m.keys.Confirm):
 			// Submit the form
 			name := strings.TrimSpace(m.nameInput.Value())
 			path := strings.TrimSpace(m.pathInput.Value())
@@ -255,25 +636,10 @@ func (m model) updateAddMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
-			// Add the source
-			ctx := context.Background()
-			if err := m.addSource(ctx, name, path); err != nil {
-				m.message = fmt.Sprintf("Error: %v", err)
-				return m, nil
-			}
-
-			// Reload sources
-			sources, err := m.store.Queries().ListSources(ctx)
-			if err != nil {
-				m.message = fmt.Sprintf("Error reloading: %v", err)
-			} else {
-				m.sources = sources
-				m.message = fmt.Sprintf("✓ Added source: %s", name)
-			}
-
-			// Exit add mode
+			// Add the source in the background and exit add mode
 			m.addMode = false
-			return m, nil
+			m.message = ""
+			return m, m.addSourceCmd(name, path)
 		}
 	}
 
@@ -287,15 +653,101 @@ func (m model) updateAddMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m model) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "ctrl+c":
+			return m, tea.Quit
+
+		case key.Matches(msg, filterClear):
+			// Drop the query and go back to the list
+			m.filtering = false
+			m.filterInput.Blur()
+			m.filterInput.SetValue("")
+			m.applyFilter()
+			return m, nil
+
+		case key.Matches(msg, filterKeep):
+			// Keep the query and go back to the list
+			m.filtering = false
+			m.filterInput.Blur()
+			return m, nil
+
+		case key.Matches(msg, filterUp):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+
+		case key.Matches(msg, filterDown):
+			if m.cursor < len(m.visible)-1 {
+				m.cursor++
+			}
+			return m, nil
+		}
+	}
+
+	query := m.filterInput.Value()
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	if m.filterInput.Value() != query {
+		// Jump to the best match as the query changes
+		m.cursor = 0
+		m.refilter(0)
+	}
+
+	return m, cmd
+}
+
+func (m model) updatePreview(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case msg.String() == "ctrl+c", key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Preview, m.keys.Cancel):
+			// Back to the source list
+			m.previewFocus = false
+			return m, nil
+
+		case key.Matches(msg, previewTop):
+			m.preview.GotoTop()
+			return m, nil
+
+		case key.Matches(msg, previewBottom):
+			m.preview.GotoBottom()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.preview, cmd = m.preview.Update(msg)
+	return m, cmd
+}
+
+// applyFilter recomputes the visible sources after a filter changes
+func (m *model) applyFilter() {
+	selected, _ := m.selected()
+	m.refilter(selected.ID)
+}
+
 func (m model) updateDeleteConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
+		switch {
+		case msg.String() == "ctrl+c":
 			return m, tea.Quit
 
-		case "y", "Y":
+		case key.Matches(msg, deleteYes):
 			// Confirm deletion
+			if len(m.deleteIDs) > 0 {
+				m.message = m.bulkDelete(m.deleteIDs)
+				m.deleteConfirm = false
+				m.deleteTarget = ""
+				m.deleteIDs = nil
+				return m, nil
+			}
+
 			ctx := context.Background()
 			err := m.store.Queries().DeleteSource(ctx, m.deleteTarget)
 			if err != nil {
@@ -306,22 +758,23 @@ func (m model) updateDeleteConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if err != nil {
 					m.message = fmt.Sprintf("Error reloading: %v", err)
 				} else {
-					m.sources = sources
-					// Adjust cursor if needed
-					if m.cursor >= len(m.sources) && m.cursor > 0 {
-						m.cursor = len(m.sources) - 1
-					}
+					m.setSources(sources)
 					m.message = fmt.Sprintf("✓ Deleted source: %s", m.deleteTarget)
+					// Deleting a source removes it from presets too
+					if err := m.loadPresets(ctx); err != nil {
+						m.message = fmt.Sprintf("Error reloading presets: %v", err)
+					}
 				}
 			}
 			m.deleteConfirm = false
 			m.deleteTarget = ""
 			return m, nil
 
-		case "n", "N", "esc":
+		case key.Matches(msg, deleteNo, m.keys.Cancel):
 			// Cancel deletion
 			m.deleteConfirm = false
 			m.deleteTarget = ""
+			m.deleteIDs = nil
 			m.message = "Delete cancelled"
 			return m, nil
 		}
@@ -330,65 +783,68 @@ func (m model) updateDeleteConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// addSource adds a new source to the database
+// addSource parses a path or URL and stores it as a source
 func (m model) addSource(ctx context.Context, name, path string) error {
 	// Determine source type and parse content
-	var content string
-	var sourceType string
-	var err error
-
-	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		sourceType = "url"
-		content, err = m.parser.ParseURL(path)
-		if err != nil {
-			return fmt.Errorf("failed to fetch URL: %w", err)
-		}
-	} else {
-		sourceType = "file"
+	sourceType := parser.DetectSourceType(path)
+	if !parser.IsURL(path) {
 		absPath, err := filepath.Abs(path)
 		if err != nil {
 			return fmt.Errorf("failed to resolve path: %w", err)
 		}
 		path = absPath
-		content, err = m.parser.ParseFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read file: %w", err)
-		}
 	}
 
-	// Compute hash
-	hash := storage.ComputeHash(content)
-
-	// Check if already exists
-	existing, err := m.store.Queries().GetSourceByName(ctx, name)
-	if err == nil {
-		// Source exists, update it
-		return m.store.Queries().UpdateSourceContent(ctx, dbgen.UpdateSourceContentParams{
-			Content: content,
-			Hash:    hash,
-			ID:      existing.ID,
-		})
+	// The context aborts slow downloads when the add is cancelled
+	content, err := m.parser.ParseSourceContext(ctx, sourceType, path, parser.SourceOptions{})
+	if err != nil {
+		return err
 	}
 
-	// Create new source (enabled by default)
-	_, err = m.store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+	// Update the source with this name, or create it enabled, like the CLI
+	_, err = m.store.SaveSource(ctx, dbgen.CreateSourceParams{
 		Name:       name,
 		SourceType: sourceType,
 		Path:       path,
 		Content:    content,
-		Hash:       hash,
 		Enabled:    1,
+		Options:    parser.SourceOptions{}.Encode(),
 	})
-
 	return err
 }
 
+// llmsTxtHint returns a note about the llms.txt file published by the site
+// behind a URL source, or an empty string if there is none or ctx is
+// cancelled first
+func (m model) llmsTxtHint(ctx context.Context, path string) string {
+	if parser.DetectSourceType(path) != "url" || parser.IsLLMsTxtURL(path) {
+		return ""
+	}
+
+	discovery, err := m.parser.DiscoverLLMsTxt(ctx, path)
+	if err != nil || !discovery.Found() {
+		return ""
+	}
+
+	return fmt.Sprintf("LLM-ready docs available: %s", discovery.Preferred())
+}
+
 func (m model) View() string {
 	var b strings.Builder
 
 	b.WriteString(titleStyle.Render("🚀 context-vacuum - Interactive Source Manager"))
+	if active := m.activePreset(); active != "" {
+		b.WriteString(" ")
+		b.WriteString(activePresetStyle.Render("● preset: " + active))
+	}
 	b.WriteString("\n\n")
 
+	// Show the diff of a changed source
+	if m.diffOpen {
+		b.WriteString(m.renderDiff())
+		return b.String()
+	}
+
 	// Show add modal if in add mode
 	if m.addMode {
 		b.WriteString(m.renderAddModal())
@@ -401,17 +857,131 @@ func (m model) View() string {
 		return b.String()
 	}
 
-	// Normal source list view
+	// Show generate dialog
+	if m.gen.open {
+		b.WriteString(m.renderGenerateDialog())
+		return b.String()
+	}
+
+	// Show the edit dialog
+	if m.edit.open {
+		b.WriteString(m.renderEditDialog())
+		return b.String()
+	}
+
+	// Show the tag or add-to-preset prompt
+	if m.bulkPrompt != bulkPromptNone {
+		b.WriteString(m.renderBulkPrompt())
+		return b.String()
+	}
+
+	// Filter bar
+	if m.filtering || m.filterActive() {
+		b.WriteString(m.renderFilterBar())
+		b.WriteString("\n\n")
+	}
+
+	// Normal source list view, with the preview alongside when it fits and
+	// the preset sidebar on the left when open
+	body := m.renderList()
+	if m.showPreview() {
+		listWidth, _ := m.paneWidths()
+		list := lipgloss.NewStyle().Width(listWidth).MaxWidth(listWidth).Render(body)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, list, " ", m.renderPreview())
+	}
+	if m.presetsOpen {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, m.renderPresets(), " ", strings.TrimSuffix(body, "\n"))
+	}
+	b.WriteString(body)
+	if m.showPreview() || m.presetsOpen {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+
+	if status := m.renderJobs(); status != "" {
+		b.WriteString(statusStyle.Render(status))
+		b.WriteString("\n")
+	}
+
+	if m.message != "" {
+		msgStyle := statusStyle
+		if strings.HasPrefix(m.message, "Error") {
+			msgStyle = errorStyle
+		}
+		b.WriteString(msgStyle.Render(m.message))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render(m.helpView()))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// helpView shows the keys of the current mode, rendered from their bindings
+func (m model) helpView() string {
+	k := m.keys
+	switch {
+	case m.presetsOpen && m.presetPrompt != presetPromptNone:
+		if m.presetPrompt == presetPromptDelete {
+			return m.help.ShortHelpView([]key.Binding{deleteYes, deleteNo})
+		}
+		return m.help.ShortHelpView([]key.Binding{k.Confirm, k.Cancel})
+	case m.presetsOpen:
+		return m.help.ShortHelpView([]key.Binding{
+			presetApply, presetNew, presetUpdate, presetRename, presetDelete,
+			withDesc(k.Cancel, "close"), withDesc(k.Presets, "close"),
+		})
+	case m.filtering:
+		return m.help.ShortHelpView([]key.Binding{filterKeep, filterClear, filterUp, filterDown})
+	case m.previewFocus:
+		return m.help.ShortHelpView([]key.Binding{
+			scrollKeys, pageKeys, panKeys, previewTop, previewBottom,
+			withDesc(k.Preview, "back to list"), withDesc(k.Cancel, "back to list"),
+		})
+	case m.hasSelection():
+		prefix := fmt.Sprintf("%d selected • ", len(m.selectedIDs()))
+		if m.visual {
+			prefix = "-- VISUAL -- " + m.help.ShortHelpView([]key.Binding{withDesc(k.Visual, "keep range")}) + " • " + prefix
+		}
+		return prefix + m.help.ShortHelpView([]key.Binding{
+			k.Toggle, k.Delete, k.Tag, k.AddToPreset, k.Refresh, k.Mark, k.SelectAll,
+			withDesc(k.Cancel, "clear"),
+		})
+	}
+	return m.help.ShortHelpView([]key.Binding{
+		k.Add, k.Edit, k.Delete, k.Up, k.Down, k.Toggle, k.Visual, k.Mark,
+		k.Generate, k.Presets, k.Filter, k.EnabledOnly, k.TypeFilter, k.Preview,
+		k.Diff, k.Refresh, k.Reload, k.Cancel, k.Quit,
+	})
+}
+
+// renderList draws the visible sources, scrolled to keep the cursor on screen
+func (m model) renderList() string {
+	var b strings.Builder
+
 	if len(m.sources) == 0 {
-		b.WriteString(normalItemStyle.Render("  No sources found. Press 'a' to add sources."))
+		b.WriteString(normalItemStyle.Render("  No sources found. Press '"+m.keys.Add.Help().Key+"' to add sources."))
+		b.WriteString("\n\n")
+	} else if len(m.visible) == 0 {
+		b.WriteString(normalItemStyle.Render("  No sources match the filter. Press "+m.keys.Cancel.Help().Key+" to clear it."))
 		b.WriteString("\n\n")
 	} else {
-		for i, source := range m.sources {
+		selection := m.selectedIDs()
+		start, end := m.listWindow()
+		for i := start; i < end; i++ {
+			source := m.sources[m.visible[i]]
 			cursor := "  "
 			if i == m.cursor {
 				cursor = "> "
 			}
 
+			mark := " "
+			if selection[source.ID] {
+				mark = "+"
+			}
+
 			enabled := "[ ]"
 			if source.Enabled == 1 {
 				enabled = "[✓]"
@@ -420,39 +990,78 @@ func (m model) View() string {
 			style := normalItemStyle
 			if i == m.cursor {
 				style = selectedItemStyle
+			} else if selection[source.ID] {
+				style = markedItemStyle
 			}
 
-			line := fmt.Sprintf("%s %s %s (%s)",
+			line := fmt.Sprintf("%s%s%s %s (%s)",
 				cursor,
+				mark,
 				enabled,
 				truncate(source.Name, 40),
 				source.SourceType,
 			)
 
 			b.WriteString(style.Render(line))
+			if running, ok := m.jobs.forSource(source.ID); ok {
+				b.WriteString(pendingStyle.Render(" " + m.spinner.View() + running.kind.verb()))
+			} else if source.Pending == 1 {
+				b.WriteString(pendingStyle.Render(" pending"))
+			} else if _, ok := m.changed[source.ID]; ok {
+				b.WriteString(changedStyle.Render(" ● changed"))
+			}
 			b.WriteString("\n")
 		}
 	}
 
-	b.WriteString("\n")
+	return b.String()
+}
 
-	if m.message != "" {
-		msgStyle := statusStyle
-		if strings.HasPrefix(m.message, "Error") {
-			msgStyle = errorStyle
-		}
-		b.WriteString(msgStyle.Render(m.message))
-		b.WriteString("\n\n")
+// listChrome is the number of lines the view uses besides the source list
+const listChrome = 10
+
+// bodyHeight is the number of lines available to the list and preview
+func (m model) bodyHeight() int {
+	return max(1, m.height-listChrome)
+}
+
+// listWindow returns the range of visible sources that fits on screen,
+// scrolled to keep the cursor in view
+func (m model) listWindow() (int, int) {
+	rows := m.bodyHeight()
+	if m.height == 0 || rows >= len(m.visible) {
+		return 0, len(m.visible)
 	}
 
-	help := "a: add • d: delete • ↑/k: up • ↓/j: down • space/enter: toggle • r: reload • q: quit"
-	b.WriteString(helpStyle.Render(help))
-	b.WriteString("\n")
+	start := max(0, m.cursor-rows/2)
+	end := min(len(m.visible), start+rows)
+	return end - rows, end
+}
 
-	return b.String()
+// renderFilterBar shows the query and quick filters, with how many sources match
+func (m model) renderFilterBar() string {
+	var parts []string
+	if m.filtering {
+		parts = append(parts, m.filterInput.View())
+	} else if m.filterInput.Value() != "" {
+		parts = append(parts, "/"+m.filterInput.Value())
+	}
+	if m.enabledOnly {
+		parts = append(parts, "enabled only")
+	}
+	if m.typeFilter != "" {
+		parts = append(parts, "type: "+m.typeFilter)
+	}
+	parts = append(parts, fmt.Sprintf("%d of %d sources", len(m.visible), len(m.sources)))
+
+	return helpStyle.Render(strings.Join(parts, " • "))
 }
 
 func (m model) renderAddModal() string {
+	if m.picking {
+		return m.renderPicker()
+	}
+
 	var b strings.Builder
 
 	b.WriteString("\n")
@@ -466,8 +1075,11 @@ func (m model) renderAddModal() string {
 	b.WriteString(m.pathInput.View())
 	b.WriteString("\n\n")
 
-	help := helpStyle.Render("[Enter] Add  [Tab] Switch  [Esc] Cancel")
-	b.WriteString(help)
+	b.WriteString(helpStyle.Render(m.help.ShortHelpView([]key.Binding{
+		withDesc(m.keys.Confirm, "add"), withDesc(fieldNext, "switch"), m.keys.Cancel,
+	})))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(m.help.ShortHelpView([]key.Binding{browseFiles})))
 
 	if m.message != "" {
 		b.WriteString("\n\n")
@@ -486,20 +1098,44 @@ func (m model) renderDeleteConfirm() string {
 	var b strings.Builder
 
 	b.WriteString("\n")
-	b.WriteString(errorStyle.Render(fmt.Sprintf("⚠️  Delete source: %s?", m.deleteTarget)))
+	if len(m.deleteIDs) > 0 {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("⚠️  Delete %s?", m.deleteTarget)))
+		b.WriteString("\n\n")
+		targets := m.targets()
+		for i, source := range targets {
+			if i == 5 {
+				b.WriteString(normalItemStyle.Render(fmt.Sprintf("  …and %d more", len(targets)-i)))
+				b.WriteString("\n")
+				break
+			}
+			b.WriteString(normalItemStyle.Render("  " + truncate(source.Name, 50)))
+			b.WriteString("\n")
+		}
+	} else {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("⚠️  Delete source: %s?", m.deleteTarget)))
+	}
 	b.WriteString("\n\n")
 	b.WriteString(normalItemStyle.Render("This action cannot be undone."))
 	b.WriteString("\n\n")
 
-	help := helpStyle.Render("[Y] Yes, delete  [N/Esc] Cancel")
-	b.WriteString(help)
+	b.WriteString(helpStyle.Render(m.help.ShortHelpView([]key.Binding{deleteYes, deleteNo, m.keys.Cancel})))
 
 	modal := modalStyle.Render(b.String())
 	return modal
 }
 
 // Run starts the TUI
-func Run(store *storage.Store) error {
+func Run(store *storage.Store, settings config.TUIConfig) error {
+	theme, err := loadTheme(settings.Theme, settings.Colors)
+	if err != nil {
+		return fmt.Errorf("invalid TUI config: %w", err)
+	}
+	keys, err := loadKeyMap(settings.Keys)
+	if err != nil {
+		return fmt.Errorf("invalid TUI config: %w", err)
+	}
+	applyTheme(theme)
+
 	// Create parser and logger for add functionality
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		Level: slog.LevelError, // Quiet during TUI
@@ -510,6 +1146,7 @@ func Run(store *storage.Store) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize TUI: %w", err)
 	}
+	m.keys = keys
 
 	p := tea.NewProgram(m)
 	if _, err := p.Run(); err != nil {
@@ -0,0 +1,260 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// DefaultDebounce is how long to wait for changes to settle before regenerating
+	DefaultDebounce = 300 * time.Millisecond
+	// DefaultInterval is how often the database, and files when polling, are checked
+	DefaultInterval = time.Second
+)
+
+// Watcher regenerates an output file whenever enabled sources change on
+// disk or sources are toggled in the database
+type Watcher struct {
+	store     *storage.Store
+	generator *generator.Generator
+	logger    *slog.Logger
+}
+
+// NewWatcher creates a new Watcher with explicit dependencies
+func NewWatcher(store *storage.Store, gen *generator.Generator, logger *slog.Logger) *Watcher {
+	return &Watcher{
+		store:     store,
+		generator: gen,
+		logger:    logger,
+	}
+}
+
+// Options controls how changes are detected
+type Options struct {
+	// Debounce delays regeneration until changes settle (DefaultDebounce if zero)
+	Debounce time.Duration
+	// Interval between database checks and file polls (DefaultInterval if zero)
+	Interval time.Duration
+	// Poll compares file modification times instead of using filesystem notifications
+	Poll bool
+	// OnGenerate is called after every generation with its result or error
+	OnGenerate func(result *generator.Result, err error)
+}
+
+// Run generates once, then regenerates on every change until ctx is cancelled.
+// It falls back to polling when filesystem notifications are unavailable.
+func (w *Watcher) Run(ctx context.Context, genOpts generator.GenerateOptions, opts Options) error {
+	if genOpts.OutputPath == "" {
+		return fmt.Errorf("watch mode requires an output file")
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+
+	var notifier *fsnotify.Watcher
+	if !opts.Poll {
+		var err error
+		notifier, err = fsnotify.NewWatcher()
+		if err != nil {
+			w.logger.WarnContext(ctx, "filesystem notifications unavailable, polling instead", "error", err)
+		} else {
+			defer notifier.Close()
+		}
+	}
+
+	// A nil channel blocks forever, which disables its select case
+	var events chan fsnotify.Event
+	var notifyErrors chan error
+	if notifier != nil {
+		events = notifier.Events
+		notifyErrors = notifier.Errors
+	}
+
+	targets, signature, err := w.targets(ctx)
+	if err != nil {
+		return err
+	}
+	watched := w.syncWatches(ctx, notifier, targets, nil)
+	snapshot := takeSnapshot(targets)
+
+	w.generate(ctx, genOpts, opts)
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	debounce := time.NewTimer(opts.Debounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event := <-events:
+			// Writing the output must not trigger another generation
+			if event.Name == genOpts.OutputPath || !matchesAny(targets, event.Name) {
+				continue
+			}
+			w.logger.DebugContext(ctx, "source changed", "path", event.Name, "op", event.Op.String())
+			debounce.Reset(opts.Debounce)
+
+		case err := <-notifyErrors:
+			w.logger.WarnContext(ctx, "filesystem watch error", "error", err)
+
+		case <-ticker.C:
+			current, currentSignature, err := w.targets(ctx)
+			if err != nil {
+				w.logger.WarnContext(ctx, "failed to check sources", "error", err)
+				continue
+			}
+			if currentSignature != signature {
+				w.logger.DebugContext(ctx, "enabled sources changed")
+				targets, signature = current, currentSignature
+				watched = w.syncWatches(ctx, notifier, targets, watched)
+				snapshot = takeSnapshot(targets)
+				debounce.Reset(opts.Debounce)
+				continue
+			}
+
+			if notifier == nil {
+				next := takeSnapshot(targets)
+				if !snapshot.equal(next) {
+					snapshot = next
+					debounce.Reset(opts.Debounce)
+				}
+			}
+
+		case <-debounce.C:
+			w.generate(ctx, genOpts, opts)
+		}
+	}
+}
+
+func (w *Watcher) generate(ctx context.Context, genOpts generator.GenerateOptions, opts Options) {
+	result, err := w.generator.Generate(ctx, genOpts)
+	if err != nil {
+		w.logger.WarnContext(ctx, "failed to regenerate context", "error", err)
+	}
+	if opts.OnGenerate != nil {
+		opts.OnGenerate(result, err)
+	}
+}
+
+// targets returns the local paths of the enabled sources, along with a
+// signature that changes whenever sources are added, removed, toggled,
+// reordered or switch refresh policy
+func (w *Watcher) targets(ctx context.Context) ([]string, string, error) {
+	sources, err := w.store.Queries().ListEnabledSources(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list enabled sources: %w", err)
+	}
+
+	var sb strings.Builder
+	var targets []string
+	for _, source := range sources {
+		sb.WriteString(strconv.FormatInt(source.ID, 10))
+		sb.WriteString("\x00")
+		sb.WriteString(source.Path)
+		sb.WriteString("\x00")
+		sb.WriteString(strconv.FormatInt(source.Priority, 10))
+		sb.WriteString("\x00")
+		sb.WriteString(source.RefreshPolicy)
+		sb.WriteString("\x00")
+
+		// Manual sources aren't re-read on generation, so edits to them
+		// wouldn't change the output
+		if !parser.IsURL(source.Path) && source.RefreshPolicy != storage.RefreshManual {
+			targets = append(targets, source.Path)
+		}
+	}
+
+	return targets, sb.String(), nil
+}
+
+// syncWatches points the notifier at the directories targets live in,
+// returning the directories now watched. Watching directories rather than
+// files keeps working when editors save by replacing the file.
+func (w *Watcher) syncWatches(ctx context.Context, notifier *fsnotify.Watcher, targets []string, watched map[string]bool) map[string]bool {
+	if notifier == nil {
+		return nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, path := range targets {
+		wanted[filepath.Dir(path)] = true
+	}
+
+	for dir := range watched {
+		if !wanted[dir] {
+			notifier.Remove(dir)
+		}
+	}
+
+	current := make(map[string]bool, len(wanted))
+	for dir := range wanted {
+		if watched[dir] {
+			current[dir] = true
+			continue
+		}
+		if err := notifier.Add(dir); err != nil {
+			w.logger.DebugContext(ctx, "failed to watch directory", "dir", dir, "error", err)
+			continue
+		}
+		current[dir] = true
+	}
+
+	return current
+}
+
+func matchesAny(targets []string, path string) bool {
+	return slices.Contains(targets, path)
+}
+
+// fileState is what polling compares to detect a change
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot maps file paths to their state; unreadable files map to the zero state
+type snapshot map[string]fileState
+
+func takeSnapshot(targets []string) snapshot {
+	s := make(snapshot)
+	for _, path := range targets {
+		info, err := os.Stat(path)
+		if err != nil {
+			s[path] = fileState{}
+			continue
+		}
+		s[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return s
+}
+
+func (s snapshot) equal(other snapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for path, state := range s {
+		if o, ok := other[path]; !ok || !o.modTime.Equal(state.modTime) || o.size != state.size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package watch_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/storage"
+	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
+	"github.com/brojonat/context-vacuum/internal/watch"
+)
+
+// waitForOutput waits for generations until the output contains want. The
+// output is written before OnGenerate signals, so it's complete by then.
+func waitForOutput(t *testing.T, generated <-chan struct{}, path, want string) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-generated:
+			data, err := os.ReadFile(path)
+			if err == nil && strings.Contains(string(data), want) {
+				return
+			}
+		case <-timeout:
+			data, _ := os.ReadFile(path)
+			t.Fatalf("output never contained %q, last content:\n%s", want, data)
+		}
+	}
+}
+
+// editSource rewrites a source and moves its modification time forward, so
+// polling sees the edit even within the filesystem's timestamp resolution
+func editSource(t *testing.T, path, content string, edits *int) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to edit source: %v", err)
+	}
+	*edits++
+	modTime := time.Now().Add(time.Duration(*edits) * time.Hour)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("failed to set modification time: %v", err)
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		name := "notify"
+		if poll {
+			name = "poll"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+				Level: slog.LevelError, // Quiet during tests
+			}))
+
+			store, err := storage.NewStore(filepath.Join(dir, "test.db"), logger)
+			if err != nil {
+				t.Fatalf("failed to create store: %v", err)
+			}
+			t.Cleanup(func() { store.Close() })
+
+			ctx := context.Background()
+			sourceDir := filepath.Join(dir, "src")
+			if err := os.Mkdir(sourceDir, 0755); err != nil {
+				t.Fatalf("failed to create source dir: %v", err)
+			}
+
+			for name, enabled := range map[string]int64{"notes": 1, "extra": 0} {
+				path := filepath.Join(sourceDir, name+".md")
+				content := name + " v1"
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write source: %v", err)
+				}
+				_, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+					Name:       name,
+					SourceType: "file",
+					Path:       path,
+					Content:    content,
+					Hash:       storage.ComputeHash(content),
+					Enabled:    enabled,
+					Options:    "{}",
+				})
+				if err != nil {
+					t.Fatalf("failed to create source: %v", err)
+				}
+			}
+
+			p := parser.NewParser(10 * 1024 * 1024)
+			w := watch.NewWatcher(store, generator.NewGenerator(store, p, logger), logger)
+
+			// The output lives next to the sources to make sure writing it
+			// doesn't retrigger generation
+			output := filepath.Join(sourceDir, "CLAUDE.md")
+			generated := make(chan struct{}, 100)
+			edits := 0
+
+			runCtx, cancel := context.WithCancel(ctx)
+			done := make(chan error, 1)
+			go func() {
+				done <- w.Run(runCtx, generator.GenerateOptions{OutputPath: output}, watch.Options{
+					Debounce:   20 * time.Millisecond,
+					Interval:   20 * time.Millisecond,
+					Poll:       poll,
+					OnGenerate: func(*generator.Result, error) { generated <- struct{}{} },
+				})
+			}()
+			t.Cleanup(func() {
+				cancel()
+				select {
+				case err := <-done:
+					if err != nil {
+						t.Errorf("watcher failed: %v", err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Error("watcher did not stop")
+				}
+			})
+
+			waitForOutput(t, generated, output, "notes v1")
+
+			editSource(t, filepath.Join(sourceDir, "notes.md"), "notes v2", &edits)
+			waitForOutput(t, generated, output, "notes v2")
+
+			// Toggling a source in the database regenerates too
+			if err := store.Queries().UpdateSourceEnabled(ctx, dbgen.UpdateSourceEnabledParams{
+				Enabled: 1,
+				Name:    "extra",
+			}); err != nil {
+				t.Fatalf("failed to enable source: %v", err)
+			}
+			waitForOutput(t, generated, output, "extra v1")
+
+			// Edits to manually refreshed sources are left out until refreshed
+			extra, err := store.Queries().GetSourceByName(ctx, "extra")
+			if err != nil {
+				t.Fatalf("failed to get source: %v", err)
+			}
+			if err := store.Queries().UpdateSourceSettings(ctx, dbgen.UpdateSourceSettingsParams{
+				RefreshPolicy: storage.RefreshManual,
+				ID:            extra.ID,
+			}); err != nil {
+				t.Fatalf("failed to update settings: %v", err)
+			}
+			<-generated
+			editSource(t, filepath.Join(sourceDir, "extra.md"), "extra v2", &edits)
+			editSource(t, filepath.Join(sourceDir, "notes.md"), "notes v3", &edits)
+			waitForOutput(t, generated, output, "notes v3")
+			if data, _ := os.ReadFile(output); !strings.Contains(string(data), "extra v1") {
+				t.Errorf("expected the manual source's cached content, got:\n%s", data)
+			}
+
+			// Nothing changes, so the output shouldn't keep being rewritten
+			select {
+			case <-generated:
+				t.Error("expected no further generations")
+			case <-time.After(200 * time.Millisecond):
+			}
+		})
+	}
+}
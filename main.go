@@ -2,18 +2,34 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/brojonat/context-vacuum/internal/config"
 	"github.com/brojonat/context-vacuum/internal/generator"
+	"github.com/brojonat/context-vacuum/internal/importer"
+	"github.com/brojonat/context-vacuum/internal/mcpserver"
 	"github.com/brojonat/context-vacuum/internal/parser"
+	"github.com/brojonat/context-vacuum/internal/redact"
+	"github.com/brojonat/context-vacuum/internal/server"
 	"github.com/brojonat/context-vacuum/internal/storage"
 	"github.com/brojonat/context-vacuum/internal/storage/dbgen"
 	"github.com/brojonat/context-vacuum/internal/tui"
+	"github.com/brojonat/context-vacuum/internal/watch"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/urfave/cli/v2"
 )
 
@@ -52,6 +68,43 @@ func main() {
 						Value: true,
 						Usage: "Enable source for context generation",
 					},
+					&cli.StringFlag{
+						Name:  "type",
+						Usage: "Source type (file, url, pdf, notebook, openapi); detected from the path when empty",
+					},
+					&cli.BoolFlag{
+						Name:  "notebook-outputs",
+						Usage: "Include code cell outputs in notebook sources",
+					},
+					&cli.IntFlag{
+						Name:  "notebook-output-limit",
+						Value: parser.DefaultNotebookOutputLimit,
+						Usage: "Truncate each notebook cell output to this many characters",
+					},
+					&cli.StringSliceFlag{
+						Name:  "openapi-tag",
+						Usage: "Only include OpenAPI operations with this tag (repeatable)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "openapi-path-prefix",
+						Usage: "Only include OpenAPI paths starting with this prefix (repeatable)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "redact-allow",
+						Usage: "Don't redact matches of this regexp, or this rule (e.g. high-entropy), in this source (repeatable)",
+					},
+					&cli.BoolFlag{
+						Name:  "llms-txt",
+						Usage: "Use the site's llms-full.txt or llms.txt instead of scraping the page",
+					},
+					&cli.BoolFlag{
+						Name:  "expand-llms-txt",
+						Usage: "Add each link listed in the site's llms.txt as a separate source",
+					},
+					&cli.BoolFlag{
+						Name:  "llms-txt-optional",
+						Usage: "Include links from the llms.txt \"Optional\" section when expanding",
+					},
 				},
 				Action: addSource,
 			},
@@ -92,14 +145,132 @@ func main() {
 						Value: "claude",
 						Usage: "Output format (claude, cursor, default)",
 					},
+					&cli.IntFlag{
+						Name:  "budget",
+						Usage: "Maximum estimated tokens of source content; sources that don't fit are left out (0 for no limit)",
+					},
+					&cli.BoolFlag{
+						Name:  "watch",
+						Usage: "Keep running and rewrite --output whenever enabled sources change or are toggled",
+					},
+					&cli.BoolFlag{
+						Name:  "poll",
+						Usage: "With --watch, poll modification times instead of using filesystem notifications",
+					},
+					&cli.DurationFlag{
+						Name:  "debounce",
+						Value: watch.DefaultDebounce,
+						Usage: "With --watch, how long changes must settle before regenerating",
+					},
+					&cli.StringFlag{
+						Name:  "redact",
+						Value: string(redact.ModeMask),
+						Usage: "What to do with secrets in source content: off, mask or fail",
+					},
 				},
 				Action: generateContext,
 			},
+			{
+				Name:  "history",
+				Usage: "Inspect, replay and prune past generations",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "List recent generations",
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:  "limit",
+								Value: 20,
+								Usage: "Number of generations to list",
+							},
+						},
+						Action: listHistory,
+					},
+					{
+						Name:      "show",
+						Usage:     "Show the sources and content hashes that went into a generation",
+						ArgsUsage: "<id>",
+						Action:    showHistory,
+					},
+					{
+						Name:      "replay",
+						Usage:     "Regenerate the exact output of a past generation from its recorded sources",
+						ArgsUsage: "<id>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "output",
+								Usage: "Output file path (default: stdout)",
+							},
+						},
+						Action: replayHistory,
+					},
+					{
+						Name:  "prune",
+						Usage: "Delete old generations now instead of waiting for the scheduled pruning",
+						Flags: []cli.Flag{
+							&cli.DurationFlag{
+								Name:  "older-than",
+								Usage: "Delete generations older than this (default: history_retention from the config)",
+							},
+						},
+						Action: pruneHistory,
+					},
+				},
+			},
 			{
 				Name:      "import-bookmarks",
 				Usage:     "Import bookmarks into cache DB",
 				ArgsUsage: "<file>",
-				Action:    importBookmarks,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "folder",
+						Usage: "Only import bookmarks in this folder and its subfolders (e.g. \"Dev/Go\")",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "List what would be imported without fetching anything",
+					},
+					&cli.BoolFlag{
+						Name:  "lazy",
+						Usage: "Store placeholders and fetch each bookmark when it is first enabled or generated",
+					},
+					&cli.BoolFlag{
+						Name:  "resume",
+						Usage: "Retry bookmarks that failed to fetch in earlier imports",
+					},
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Value: importer.DefaultConcurrency,
+						Usage: "Number of bookmarks to fetch in parallel",
+					},
+					&cli.StringFlag{
+						Name:  "report",
+						Usage: "Write failed bookmarks as JSON to this file (\"-\" for stdout)",
+					},
+				},
+				Action: importBookmarks,
+			},
+			{
+				Name:   "mcp",
+				Usage:  "Serve sources, presets and generation to agents over MCP (stdio)",
+				Action: serveMCP,
+			},
+			{
+				Name:  "serve",
+				Usage: "Serve a token-authenticated JSON REST API for editors and tools",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "addr",
+						Value: "localhost:7777",
+						Usage: "Address to listen on",
+					},
+					&cli.StringFlag{
+						Name:    "token",
+						Usage:   "Bearer token clients must send (random if unset)",
+						EnvVars: []string{"CONTEXT_VACUUM_TOKEN"},
+					},
+				},
+				Action: serveHTTP,
 			},
 			{
 				Name:   "tui",
@@ -185,71 +356,163 @@ func addSource(c *cli.Context) error {
 	// Determine source type and parse content
 	p := parser.NewParser(cfg.MaxFileSize)
 	var content string
-	var sourceType string
+	var opts parser.SourceOptions
 
-	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
-		sourceType = "url"
-		content, err = p.ParseURL(source)
-		if err != nil {
-			return fmt.Errorf("failed to parse URL: %w", err)
-		}
-	} else {
-		sourceType = "file"
+	sourceType := c.String("type")
+	if sourceType == "" {
+		sourceType = parser.DetectSourceType(source)
+	}
+
+	if !parser.IsURL(source) {
 		absPath, err := filepath.Abs(source)
 		if err != nil {
 			return fmt.Errorf("failed to resolve path: %w", err)
 		}
 		source = absPath
-		content, err = p.ParseFile(source)
-		if err != nil {
-			return fmt.Errorf("failed to parse file: %w", err)
-		}
 	}
 
-	// Compute hash
-	hash := storage.ComputeHash(content)
-
-	// Check if already exists
-	existing, err := store.Queries().GetSourceByName(ctx, name)
-	if err == nil {
-		// Source exists, update it
-		logger.DebugContext(ctx, "updating existing source", "name", name)
-		if err := store.Queries().UpdateSourceContent(ctx, dbgen.UpdateSourceContentParams{
-			Content: content,
-			Hash:    hash,
-			ID:      existing.ID,
-		}); err != nil {
-			return fmt.Errorf("failed to update source: %w", err)
+	opts.RedactAllow = c.StringSlice("redact-allow")
+	if _, err := redact.NewAllowlist(opts.RedactAllow); err != nil {
+		return err
+	}
+
+	switch sourceType {
+	case "url":
+		if c.Bool("expand-llms-txt") {
+			return expandLLMsTxt(ctx, c, store, p, name, source, enabled)
 		}
+		source = resolveLLMsTxt(ctx, c, p, source)
+	case "notebook":
+		opts.NotebookOutputs = c.Bool("notebook-outputs")
+		opts.NotebookOutputLimit = c.Int("notebook-output-limit")
+	case "openapi":
+		opts.OpenAPITags = c.StringSlice("openapi-tag")
+		opts.OpenAPIPathPrefixes = c.StringSlice("openapi-path-prefix")
+	}
+
+	content, err = p.ParseSource(sourceType, source, opts)
+	if err != nil {
+		return err
+	}
+
+	created, err := saveSource(ctx, store, name, sourceType, source, content, enabled, opts)
+	if err != nil {
+		return err
+	}
+
+	if created == nil {
 		fmt.Printf("Updated source: %s\n", name)
 		return nil
 	}
 
-	// Create new source
+	logger.InfoContext(ctx, "source added",
+		"name", name,
+		"type", sourceType,
+		"enabled", enabled,
+	)
+
+	fmt.Printf("Added source: %s (ID: %d)\n", created.Name, created.ID)
+	return nil
+}
+
+// saveSource updates the content of an existing source with the same name or
+// creates a new one. It returns the created source, or nil if one was updated.
+func saveSource(ctx context.Context, store *storage.Store, name, sourceType, path, content string, enabled bool, opts parser.SourceOptions) (*dbgen.Source, error) {
 	enabledInt := int64(0)
 	if enabled {
 		enabledInt = 1
 	}
 
-	created, err := store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
+	return store.SaveSource(ctx, dbgen.CreateSourceParams{
 		Name:       name,
 		SourceType: sourceType,
-		Path:       source,
+		Path:       path,
 		Content:    content,
-		Hash:       hash,
 		Enabled:    enabledInt,
+		Options:    opts.Encode(),
 	})
+}
+
+// resolveLLMsTxt checks whether the site behind a URL publishes llms.txt.
+// With --llms-txt the curated file replaces the page URL, otherwise the user
+// is told that it exists.
+func resolveLLMsTxt(ctx context.Context, c *cli.Context, p *parser.Parser, source string) string {
+	if parser.IsLLMsTxtURL(source) {
+		return source
+	}
+
+	discovery, err := p.DiscoverLLMsTxt(ctx, source)
+	if err != nil || !discovery.Found() {
+		if c.Bool("llms-txt") {
+			fmt.Fprintf(os.Stderr, "No llms.txt found for %s, using the page itself\n", source)
+		}
+		return source
+	}
+
+	if c.Bool("llms-txt") {
+		fmt.Fprintf(os.Stderr, "Using %s\n", discovery.Preferred())
+		return discovery.Preferred()
+	}
+
+	fmt.Fprintf(os.Stderr, "This site publishes LLM-ready docs at %s\n", discovery.Preferred())
+	fmt.Fprintf(os.Stderr, "Re-run with --llms-txt to use it, or --expand-llms-txt to add each linked page\n")
+	return source
+}
+
+// expandLLMsTxt adds every link listed in a site's llms.txt as its own source,
+// named "<name>: <link title>"
+func expandLLMsTxt(ctx context.Context, c *cli.Context, store *storage.Store, p *parser.Parser, name, source string, enabled bool) error {
+	logger := slog.Default()
+
+	indexURL := source
+	if !parser.IsLLMsTxtURL(source) {
+		discovery, err := p.DiscoverLLMsTxt(ctx, source)
+		if err != nil {
+			return fmt.Errorf("failed to discover llms.txt: %w", err)
+		}
+		if discovery.IndexURL == "" {
+			return fmt.Errorf("no llms.txt found for %s", source)
+		}
+		indexURL = discovery.IndexURL
+	}
+
+	doc, err := p.FetchLLMsTxt(ctx, indexURL)
 	if err != nil {
-		return fmt.Errorf("failed to create source: %w", err)
+		return fmt.Errorf("failed to fetch llms.txt: %w", err)
 	}
 
-	logger.InfoContext(ctx, "source added",
-		"name", name,
-		"type", sourceType,
-		"enabled", enabled,
-	)
+	includeOptional := c.Bool("llms-txt-optional")
+	added := 0
+	total := 0
+	for _, link := range doc.Links {
+		if link.Optional && !includeOptional {
+			continue
+		}
+		total++
 
-	fmt.Printf("Added source: %s (ID: %d)\n", created.Name, created.ID)
+		content, err := p.ParseURLContext(ctx, link.URL)
+		if err != nil {
+			logger.WarnContext(ctx, "failed to fetch llms.txt link",
+				"title", link.Title,
+				"url", link.URL,
+				"error", err,
+			)
+			continue
+		}
+
+		childName := fmt.Sprintf("%s: %s", name, link.Title)
+		if _, err := saveSource(ctx, store, childName, "url", link.URL, content, enabled, parser.SourceOptions{}); err != nil {
+			logger.WarnContext(ctx, "failed to save llms.txt link",
+				"title", link.Title,
+				"error", err,
+			)
+			continue
+		}
+
+		added++
+	}
+
+	fmt.Printf("Added %d/%d sources from %s\n", added, total, indexURL)
 	return nil
 }
 
@@ -285,7 +548,7 @@ func toggleOn(c *cli.Context) error {
 
 	name := c.Args().First()
 
-	store, _, err := getStoreAndConfig(c)
+	store, cfg, err := getStoreAndConfig(c)
 	if err != nil {
 		return err
 	}
@@ -293,6 +556,18 @@ func toggleOn(c *cli.Context) error {
 
 	ctx := context.Background()
 
+	// Lazily imported bookmarks are fetched the first time they're enabled
+	source, err := store.Queries().GetSourceByName(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to find source: %w", err)
+	}
+	if source.Pending == 1 {
+		imp := importer.NewImporter(store, parser.NewParser(cfg.MaxFileSize), slog.Default())
+		if _, err := imp.Fetch(ctx, source); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; it will be retried on generate\n", err)
+		}
+	}
+
 	if err := store.Queries().UpdateSourceEnabled(ctx, dbgen.UpdateSourceEnabledParams{
 		Enabled: 1,
 		Name:    name,
@@ -349,7 +624,7 @@ func listSources(c *cli.Context) error {
 		return nil
 	}
 
-	fmt.Printf("%-5s %-30s %-10s %-10s %s\n", "ID", "Name", "Type", "Enabled", "Path")
+	fmt.Printf("%-5s %-30s %-10s %-14s %s\n", "ID", "Name", "Type", "Enabled", "Path")
 	fmt.Println(strings.Repeat("-", 80))
 
 	for _, source := range sources {
@@ -357,7 +632,10 @@ func listSources(c *cli.Context) error {
 		if source.Enabled == 1 {
 			enabled = "yes"
 		}
-		fmt.Printf("%-5d %-30s %-10s %-10s %s\n",
+		if source.Pending == 1 {
+			enabled += " (pending)"
+		}
+		fmt.Printf("%-5d %-30s %-10s %-14s %s\n",
 			source.ID,
 			truncate(source.Name, 30),
 			source.SourceType,
@@ -372,6 +650,12 @@ func listSources(c *cli.Context) error {
 func generateContext(c *cli.Context) error {
 	outputPath := c.String("output")
 	format := c.String("format")
+	budget := c.Int("budget")
+
+	redactMode, err := redact.ParseMode(c.String("redact"))
+	if err != nil {
+		return err
+	}
 
 	store, cfg, err := getStoreAndConfig(c)
 	if err != nil {
@@ -388,15 +672,38 @@ func generateContext(c *cli.Context) error {
 	// Create generator
 	gen := generator.NewGenerator(store, p, logger)
 
+	// Prune old history before adding to it
+	deleted, err := gen.PruneHistoryOnSchedule(ctx, cfg.HistoryRetention, cfg.HistoryPruneInterval)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to prune history", "error", err)
+	} else if deleted > 0 {
+		logger.InfoContext(ctx, "pruned old history", "deleted", deleted)
+	}
+
+	toStdout := outputPath == "" || outputPath == "-"
+	if c.Bool("watch") && toStdout {
+		return fmt.Errorf("--watch requires --output")
+	}
+
 	// If no output specified, print to stdout
-	if outputPath == "" || outputPath == "-" {
-		content, err := gen.GenerateToString(ctx, generator.GenerateOptions{
-			Format: format,
-		})
+	if toStdout {
+		opts := generator.GenerateOptions{
+			Format:      format,
+			TokenBudget: budget,
+			Redact:      redactMode,
+		}
+		result, err := gen.Render(ctx, opts)
 		if err != nil {
 			return fmt.Errorf("failed to generate context: %w", err)
 		}
-		fmt.Print(content)
+		fmt.Print(result.Content)
+		reportRedactions(result.Redactions)
+
+		if len(result.Sources) > 0 {
+			if _, err := gen.Record(ctx, opts, result); err != nil {
+				logger.WarnContext(ctx, "failed to record history", "error", err)
+			}
+		}
 		return nil
 	}
 
@@ -409,24 +716,250 @@ func generateContext(c *cli.Context) error {
 		outputPath = filepath.Join(cwd, outputPath)
 	}
 
+	opts := generator.GenerateOptions{
+		OutputPath:  outputPath,
+		Format:      format,
+		TokenBudget: budget,
+		Redact:      redactMode,
+	}
+
+	if c.Bool("watch") {
+		return watchContext(ctx, store, gen, opts, c.Bool("poll"), c.Duration("debounce"))
+	}
+
 	// Generate context to file
-	if err := gen.Generate(ctx, generator.GenerateOptions{
-		OutputPath: outputPath,
-		Format:     format,
-	}); err != nil {
+	result, err := gen.Generate(ctx, opts)
+	if err != nil {
 		return fmt.Errorf("failed to generate context: %w", err)
 	}
 
 	fmt.Fprintf(os.Stderr, "Context generated: %s\n", outputPath)
+	reportRedactions(result.Redactions)
 	return nil
 }
 
-func importBookmarks(c *cli.Context) error {
+// reportRedactions tells the user which secrets were masked, without showing them
+func reportRedactions(redactions []generator.Redaction) {
+	if len(redactions) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Masked %d secret(s):\n", len(redactions))
+	for _, r := range redactions {
+		fmt.Fprintf(os.Stderr, "  %s\n", r)
+	}
+	fmt.Fprintf(os.Stderr, "Use add --redact-allow to keep false positives, or --redact=off to disable masking\n")
+}
+
+// watchContext regenerates the output file on every source change until interrupted
+func watchContext(ctx context.Context, store *storage.Store, gen *generator.Generator, opts generator.GenerateOptions, poll bool, debounce time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := watch.NewWatcher(store, gen, slog.Default())
+	fmt.Fprintf(os.Stderr, "Watching enabled sources, press Ctrl+C to stop\n")
+
+	return w.Run(ctx, opts, watch.Options{
+		Debounce: debounce,
+		Poll:     poll,
+		OnGenerate: func(result *generator.Result, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s  Failed to generate context: %v\n", time.Now().Format(time.TimeOnly), err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "%s  Context generated: %s\n", time.Now().Format(time.TimeOnly), opts.OutputPath)
+			reportRedactions(result.Redactions)
+		},
+	})
+}
+
+func listHistory(c *cli.Context) error {
+	store, _, err := getStoreAndConfig(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	entries, err := store.Queries().ListHistory(context.Background(), int64(c.Int("limit")))
+	if err != nil {
+		return fmt.Errorf("failed to list history: %w", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No history found")
+		return nil
+	}
+
+	fmt.Printf("%-5s %-19s %-7s %-7s %-15s %s\n", "ID", "Generated", "Sources", "Format", "Preset", "Output")
+	fmt.Println(strings.Repeat("-", 80))
+
+	for _, entry := range entries {
+		format := entry.Format
+		if format == "" {
+			format = "-"
+		}
+		preset := "-"
+		if entry.PresetName.Valid {
+			preset = entry.PresetName.String
+		}
+		fmt.Printf("%-5d %-19s %-7d %-7s %-15s %s\n",
+			entry.ID,
+			time.Unix(entry.GeneratedAt, 0).Format(time.DateTime),
+			entry.SourceCount,
+			format,
+			truncate(preset, 15),
+			truncate(historyOutput(entry), 40),
+		)
+	}
+
+	return nil
+}
+
+func showHistory(c *cli.Context) error {
+	id, err := historyID(c)
+	if err != nil {
+		return err
+	}
+
+	store, _, err := getStoreAndConfig(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+
+	entry, err := store.Queries().GetHistory(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get history entry %d: %w", id, err)
+	}
+	sources, err := store.Queries().ListHistorySources(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to list history sources: %w", err)
+	}
+
+	fmt.Printf("ID:        %d\n", entry.ID)
+	fmt.Printf("Generated: %s\n", time.Unix(entry.GeneratedAt, 0).Format(time.DateTime))
+	fmt.Printf("Output:    %s\n", historyOutput(entry))
+	if entry.PresetName.Valid {
+		fmt.Printf("Preset:    %s\n", entry.PresetName.String)
+	}
+	if entry.ContentHash == "" {
+		fmt.Printf("Sources:   %d (not recorded, this generation can't be replayed)\n", entry.SourceCount)
+		return nil
+	}
+	fmt.Printf("Format:    %s\n", entry.Format)
+	fmt.Printf("Hash:      %s\n", entry.ContentHash)
+	fmt.Printf("Sources:   %d\n\n", len(sources))
+
+	fmt.Printf("%-5s %-30s %-10s %-12s %s\n", "ID", "Name", "Type", "Hash", "Path")
+	fmt.Println(strings.Repeat("-", 80))
+
+	for _, source := range sources {
+		hash := source.Hash
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		fmt.Printf("%-5d %-30s %-10s %-12s %s\n",
+			source.SourceID,
+			truncate(source.Name, 30),
+			source.SourceType,
+			hash,
+			truncate(source.Path, 40),
+		)
+	}
+
+	return nil
+}
+
+func replayHistory(c *cli.Context) error {
+	id, err := historyID(c)
+	if err != nil {
+		return err
+	}
+
+	store, cfg, err := getStoreAndConfig(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	gen := generator.NewGenerator(store, parser.NewParser(cfg.MaxFileSize), slog.Default())
+
+	result, err := gen.Replay(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to replay history: %w", err)
+	}
+
+	outputPath := c.String("output")
+	if outputPath == "" || outputPath == "-" {
+		fmt.Print(result.Content)
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, []byte(result.Content), 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Replayed generation %d: %s\n", id, outputPath)
+	return nil
+}
+
+func pruneHistory(c *cli.Context) error {
+	store, cfg, err := getStoreAndConfig(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	olderThan := cfg.HistoryRetention
+	if c.IsSet("older-than") {
+		olderThan = c.Duration("older-than")
+	}
+	if olderThan <= 0 {
+		return fmt.Errorf("history_retention is 0, which keeps history forever; pass --older-than to prune anyway")
+	}
+
+	gen := generator.NewGenerator(store, parser.NewParser(cfg.MaxFileSize), slog.Default())
+	deleted, err := gen.PruneHistory(context.Background(), olderThan)
+	if err != nil {
+		return fmt.Errorf("failed to prune history: %w", err)
+	}
+
+	fmt.Printf("Pruned %d generation(s) older than %s\n", deleted, olderThan)
+	return nil
+}
+
+// historyID parses the history entry ID argument
+func historyID(c *cli.Context) (int64, error) {
 	if c.Args().Len() != 1 {
-		return fmt.Errorf("requires exactly one argument: <file>")
+		return 0, fmt.Errorf("requires exactly one argument: <id>")
 	}
 
-	bookmarkFile := c.Args().First()
+	id, err := strconv.ParseInt(c.Args().First(), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid history ID %q", c.Args().First())
+	}
+	return id, nil
+}
+
+// historyOutput describes where a generation was written
+func historyOutput(entry dbgen.History) string {
+	if entry.OutputPath == "" {
+		return "stdout"
+	}
+	return entry.OutputPath
+}
+
+func importBookmarks(c *cli.Context) error {
+	resume := c.Bool("resume")
+	if resume && c.Args().Len() != 0 {
+		return fmt.Errorf("--resume retries recorded failures and takes no file argument")
+	}
+	if !resume && c.Args().Len() != 1 {
+		return fmt.Errorf("requires exactly one argument: <file>")
+	}
 
 	store, cfg, err := getStoreAndConfig(c)
 	if err != nil {
@@ -437,69 +970,219 @@ func importBookmarks(c *cli.Context) error {
 	ctx := context.Background()
 	logger := slog.Default()
 
-	// Parse bookmarks
 	p := parser.NewParser(cfg.MaxFileSize)
-	bookmarks, err := p.ParseBookmarkHTML(bookmarkFile)
-	if err != nil {
-		return fmt.Errorf("failed to parse bookmarks: %w", err)
+	imp := importer.NewImporter(store, p, logger)
+
+	// Parse bookmarks, or load the ones that failed last time
+	var bookmarks []parser.Bookmark
+	if resume {
+		bookmarks, err = imp.Failures(ctx)
+		if err != nil {
+			return err
+		}
+	} else {
+		bookmarks, err = p.ParseBookmarks(c.Args().First())
+		if err != nil {
+			return fmt.Errorf("failed to parse bookmarks: %w", err)
+		}
+	}
+
+	if folder := c.String("folder"); folder != "" {
+		bookmarks = parser.FilterBookmarksByFolder(bookmarks, folder)
+		if len(bookmarks) == 0 {
+			return fmt.Errorf("no bookmarks found in folder %q", folder)
+		}
+	}
+
+	if len(bookmarks) == 0 {
+		fmt.Println("No bookmarks to import")
+		return nil
 	}
 
 	logger.InfoContext(ctx, "importing bookmarks", "count", len(bookmarks))
 
-	// Add each bookmark as a source (disabled by default)
-	imported := 0
-	for _, bookmark := range bookmarks {
-		// Try to fetch content
-		content, err := p.ParseURL(bookmark.URL)
-		if err != nil {
-			logger.WarnContext(ctx, "failed to fetch bookmark",
-				"title", bookmark.Title,
-				"url", bookmark.URL,
-				"error", err,
+	opts := importer.Options{
+		Concurrency: c.Int("concurrency"),
+		DryRun:      c.Bool("dry-run"),
+		Lazy:        c.Bool("lazy"),
+	}
+	if isTerminal(os.Stderr) && !opts.Lazy {
+		opts.Progress = os.Stderr
+	}
+
+	result, err := imp.Import(ctx, bookmarks, opts)
+	if err != nil {
+		return fmt.Errorf("failed to import bookmarks: %w", err)
+	}
+
+	if opts.DryRun {
+		fmt.Printf("%-8s %-40s %-20s %s\n", "Action", "Name", "Folder", "URL")
+		fmt.Println(strings.Repeat("-", 100))
+		for _, item := range result.Items {
+			action := "import"
+			if item.Skip != "" {
+				action = "skip"
+			}
+			fmt.Printf("%-8s %-40s %-20s %s\n",
+				action,
+				truncate(item.Name, 40),
+				truncate(item.Bookmark.Folder, 20),
+				item.Bookmark.URL,
 			)
-			continue
 		}
+		fmt.Printf("\nWould import %d/%d bookmarks\n", len(result.Items)-result.Skipped, len(result.Items))
+		return nil
+	}
 
-		hash := storage.ComputeHash(content)
-
-		// Check if already exists by hash
-		if _, err := store.Queries().GetSourceByHash(ctx, hash); err == nil {
-			logger.DebugContext(ctx, "bookmark already exists", "title", bookmark.Title)
-			continue
+	if reportPath := c.String("report"); reportPath != "" {
+		if err := writeImportReport(reportPath, result.Failures); err != nil {
+			return err
 		}
+	}
+
+	fmt.Printf("Imported %d/%d bookmarks (%d skipped, %d failed)\n",
+		result.Imported, len(bookmarks), result.Skipped, len(result.Failures))
+	if len(result.Failures) > 0 {
+		fmt.Fprintln(os.Stderr, "Run 'context-vacuum import-bookmarks --resume' to retry failed bookmarks")
+	}
+	return nil
+}
 
-		// Create source
-		_, err = store.Queries().CreateSource(ctx, dbgen.CreateSourceParams{
-			Name:       bookmark.Title,
-			SourceType: "bookmark",
-			Path:       bookmark.URL,
-			Content:    content,
-			Hash:       hash,
-			Enabled:    0, // Disabled by default
-		})
+// writeImportReport writes failed bookmarks as JSON; "-" writes to stdout
+func writeImportReport(path string, failures []importer.Failure) error {
+	if failures == nil {
+		failures = []importer.Failure{}
+	}
+
+	data, err := json.MarshalIndent(failures, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode import report: %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write import report: %w", err)
+	}
+	return nil
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func serveMCP(c *cli.Context) error {
+	store, cfg, err := getStoreAndConfig(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// stdout carries the protocol, so logs must stay on stderr
+	logger := slog.Default()
+	p := parser.NewParser(cfg.MaxFileSize)
+	server := mcpserver.NewServer(
+		store,
+		generator.NewGenerator(store, p, logger),
+		importer.NewImporter(store, p, logger),
+		logger,
+		buildVersion(),
+	)
+
+	return server.Run(c.Context, &mcp.StdioTransport{})
+}
+
+func serveHTTP(c *cli.Context) error {
+	store, cfg, err := getStoreAndConfig(c)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	token := c.String("token")
+	if token == "" {
+		token, err = randomToken()
 		if err != nil {
-			logger.WarnContext(ctx, "failed to create source",
-				"title", bookmark.Title,
-				"error", err,
-			)
-			continue
+			return err
 		}
+		fmt.Fprintf(os.Stderr, "API token: %s\n", token)
+	}
 
-		imported++
+	logger := slog.Default()
+	p := parser.NewParser(cfg.MaxFileSize)
+	api := server.NewServer(
+		store,
+		generator.NewGenerator(store, p, logger),
+		p,
+		importer.NewImporter(store, p, logger),
+		logger,
+		token,
+	)
+
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{
+		Addr:              c.String("addr"),
+		Handler:           api.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	fmt.Printf("Imported %d/%d bookmarks\n", imported, len(bookmarks))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Serving API on http://%s/api (OpenAPI description at /api/openapi.json)\n", httpServer.Addr)
+	fmt.Fprintf(os.Stderr, "Web UI: http://%s/#token=%s\n", httpServer.Addr, url.QueryEscape(token))
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
 	return nil
 }
 
+// randomToken returns a random hex token for API authentication
+func randomToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// buildVersion returns the module version the binary was built from
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
 func launchTUI(c *cli.Context) error {
-	store, _, err := getStoreAndConfig(c)
+	store, cfg, err := getStoreAndConfig(c)
 	if err != nil {
 		return err
 	}
 	defer store.Close()
 
-	return tui.Run(store)
+	return tui.Run(store, cfg.TUI)
 }
 
 func truncate(s string, maxLen int) string {